@@ -3,7 +3,6 @@ package commands
 import (
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/camronlevanger/gobo/models"
 	"github.com/camronlevanger/gobo/utils"
@@ -13,14 +12,18 @@ type IActivateCommand interface {
 }
 
 type ActivateCommand struct {
-	logger         utils.ILogger
-	configService  utils.IConfigService
-	packageService utils.IPackageService
-	copyService    utils.ICopyService
-	moveService    utils.IMoveService
-	host           models.Host
-	gopath         string
-	gobopath       string
+	logger           utils.ILogger
+	configService    utils.IConfigService
+	packageService   utils.IPackageService
+	copyService      utils.ICopyService
+	moveService      utils.IMoveService
+	promptService    utils.IPromptService
+	toolchainService utils.IToolchainService
+	fileSystem       utils.IFileSystem
+	runner           utils.ICommandRunner
+	host             models.Host
+	gopath           string
+	gobopath         string
 }
 
 func GetActivateCommand(
@@ -29,6 +32,10 @@ func GetActivateCommand(
 	packageService utils.IPackageService,
 	copyService utils.ICopyService,
 	moveService utils.IMoveService,
+	promptService utils.IPromptService,
+	toolchainService utils.IToolchainService,
+	fileSystem utils.IFileSystem,
+	runner utils.ICommandRunner,
 	host models.Host,
 	gopath string,
 	gobopath string,
@@ -39,6 +46,10 @@ func GetActivateCommand(
 		packageService,
 		copyService,
 		moveService,
+		promptService,
+		toolchainService,
+		fileSystem,
+		runner,
 		host,
 		gopath,
 		gobopath,
@@ -49,55 +60,109 @@ func GetActivateCommand(
 
 func (activate *ActivateCommand) Run(name string) error {
 
-	env := activate.configService.ReadEnvironment(activate.gopath + "gobo.toml")
+	env, err := activate.configService.ReadEnvironment(activate.gopath + "gobo.toml")
+	if utils.IsConfigNotFound(err) {
+		return errors.New("there is no active environment to switch from, use gobo create to start managing this GOPATH")
+	}
+	if err != nil {
+		return errors.New("unable to read the active environment: " + err.Error())
+	}
 
 	if env.Name == name {
 		return errors.New(name + " is already the currently active environment.")
 	}
 
-	_, existsErr := os.Stat(activate.gopath + "gobo.toml")
-	if existsErr == nil {
+	// make sure the target is intact before anything is moved
+	target, err := activate.configService.ReadEnvironment(activate.gobopath + name + "/gobo.toml")
+	if utils.IsConfigNotFound(err) {
+		return errors.New(name + " is not a named environment.")
+	}
+	if err != nil {
+		return errors.New("unable to read environment " + name + ": " + err.Error())
+	}
 
-		activate.logger.Info("Running save on current environment first...")
+	goroot, err := resolveToolchain(activate.toolchainService, target)
+	if err != nil {
+		return errors.New("unable to select the toolchain of " + name + ": " + err.Error())
+	}
 
-		save := GetSaveCommand(
-			activate.logger,
-			activate.configService,
-			activate.packageService,
-			activate.copyService,
-			activate.host,
-			activate.gopath,
-			activate.gobopath,
-		)
+	// a failing pre hook leaves both environments as they are
+	err = activate.hook(env, "pre-deactivate", env.Name, name)
+	if err != nil {
+		return err
+	}
 
-		err := save.Run(false)
-		if err != nil {
-			activate.logger.Error("Error running gobo save command: " + err.Error())
-		}
+	err = activate.hook(target, "pre-activate", env.Name, name)
+	if err != nil {
+		return err
 	}
 
-	for _, dir := range models.GOPATHDIRECTORIES {
-		activate.logger.Info(fmt.Sprintf("Removing active %s directory at %s\n", dir, activate.gopath))
-		activate.moveService.Move(activate.gopath+dir, activate.gobopath+env.Name)
+	activate.logger.Info("Running save on current environment first...")
+
+	save := GetSaveCommand(
+		activate.logger,
+		activate.configService,
+		activate.packageService,
+		activate.copyService,
+		activate.promptService,
+		activate.host,
+		activate.gopath,
+		activate.gobopath,
+	)
+
+	err = save.Run(false)
+	if err != nil {
+		return errors.New("unable to save " + env.Name + " before switching, nothing was moved: " + err.Error())
 	}
 
-	activate.logger.Info("Removing gobo.toml...")
-	activate.moveService.Move(activate.gopath+"gobo.toml", activate.gobopath+env.Name)
+	// the moves go into the environment's directory, not in its place
+	err = activate.fileSystem.MkdirAll(activate.gobopath+env.Name, models.FILEMODE)
+	if err != nil {
+		return err
+	}
 
-	activate.logger.Info("Removing packages.toml...")
-	activate.moveService.Move(activate.gopath+"packages.toml", activate.gobopath+env.Name)
+	var moves []pendingMove
+	for _, dir := range models.GOPATHDIRECTORIES {
+		moves = append(moves, pendingMove{activate.gopath + dir, activate.gobopath + env.Name})
+	}
+	for _, file := range models.ENVIRONMENTFILES {
+		moves = append(moves, pendingMove{activate.gopath + file, activate.gobopath + env.Name})
+	}
+	for _, dir := range models.GOPATHDIRECTORIES {
+		moves = append(moves, pendingMove{activate.gobopath + name + "/" + dir, activate.gopath})
+	}
+	for _, file := range models.ENVIRONMENTFILES {
+		moves = append(moves, pendingMove{activate.gobopath + name + "/" + file, activate.gopath})
+	}
 
 	activate.logger.Info("Activating " + name)
-	for _, dir := range models.GOPATHDIRECTORIES {
-		activate.logger.Info(fmt.Sprintf("Moving %s to %s\n", activate.gobopath+name+"/"+dir, activate.gopath))
-		activate.moveService.Move(activate.gobopath+name+"/"+dir, activate.gopath)
+	err = moveAll(activate.logger, activate.fileSystem, activate.moveService, moves)
+	if err != nil {
+		return errors.New("unable to switch from " + env.Name + " to " + name + ", the moves done were undone: " + err.Error())
+	}
+
+	err = writeActivateScript(activate.fileSystem, activate.gobopath, target, goroot)
+	if err != nil {
+		return errors.New("unable to write " + activate.gobopath + activateScript + ": " + err.Error())
 	}
 
-	activate.logger.Info("Restoring gobo.toml...")
-	activate.moveService.Move(activate.gobopath+name+"/gobo.toml", activate.gopath)
+	if goroot != "" {
+		fmt.Println(name + " uses Go " + target.GoVersion + ", run . " + activate.gobopath + activateScript + " to switch to it.")
+	}
 
-	activate.logger.Info("Restoring packages.toml...")
-	activate.moveService.Move(activate.gobopath+name+"/packages.toml", activate.gopath)
+	// the switch has happened, so failing post hooks are only reported
+	for _, err := range []error{
+		activate.hook(env, "post-deactivate", env.Name, name),
+		activate.hook(target, "post-activate", env.Name, name),
+	} {
+		if err != nil {
+			activate.logger.Error(err.Error())
+		}
+	}
 
 	return nil
 }
+
+func (activate *ActivateCommand) hook(env models.Environment, hook string, oldEnv string, newEnv string) error {
+	return runHook(activate.logger, activate.runner, activate.gopath, activate.gobopath, env, hook, oldEnv, newEnv)
+}
@@ -0,0 +1,155 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// IAuditCommand is the interface to implement for checking the packages of an environment for vulnerabilities.
+type IAuditCommand interface {
+	Run(name string) error
+}
+
+// AuditCommand is the struct for this implementation of IAuditCommand.
+type AuditCommand struct {
+	logger          utils.ILogger
+	configService   utils.IConfigService
+	advisoryService utils.IAdvisoryService
+	database        string
+	asJSON          bool
+	gopath          string
+	gobopath        string
+}
+
+// GetAuditCommand returns a pointer to an implementation of IAuditCommand. database is the OSV advisory file, or
+// directory of them, the packages are checked against. Nothing is fetched, so it works offline.
+func GetAuditCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	advisoryService utils.IAdvisoryService,
+	database string,
+	asJSON bool,
+	gopath string,
+	gobopath string,
+) *AuditCommand {
+	var audit = AuditCommand{
+		logger,
+		configService,
+		advisoryService,
+		database,
+		asJSON,
+		gopath,
+		gobopath,
+	}
+
+	return &audit
+}
+
+// Run checks the revision of every package locked or listed by the environment name, or the active environment
+// when name is empty, against the advisory database, reports what affects them and fails when anything does, or
+// when a package could not be checked.
+func (audit *AuditCommand) Run(name string) error {
+
+	if audit.database == "" {
+		return errors.New("pass the advisory database to check against with -db")
+	}
+
+	if name == "" {
+		env, err := audit.configService.ReadEnvironment(audit.gopath + "gobo.toml")
+		if err != nil {
+			return err
+		}
+		name = env.Name
+	}
+
+	root, _, err := environmentRoot(audit.configService, audit.gopath, audit.gobopath, name)
+	if err != nil {
+		return err
+	}
+
+	advisories, err := audit.advisoryService.Load(audit.database)
+	if err != nil {
+		return err
+	}
+
+	findings := []models.AuditFinding{}
+	affected := map[string]bool{}
+	checked := 0
+	var unaudited []string
+
+	for _, pak := range environmentPackages(audit.logger, audit.configService, root, name) {
+		if pak.Revision == "" {
+			audit.logger.Warn(pak.Path + " has no locked revision to audit, run gobo save first.")
+			unaudited = append(unaudited, pak.Path)
+			continue
+		}
+
+		found, err := audit.advisoryService.Audit(advisories, pak.Path, pak.Revision, root+"src/"+pak.Path)
+		if err != nil {
+			audit.logger.Warn(err.Error())
+			unaudited = append(unaudited, pak.Path)
+		} else {
+			checked++
+		}
+
+		for _, finding := range found {
+			findings = append(findings, finding)
+			affected[finding.Path] = true
+		}
+	}
+
+	sort.SliceStable(findings, func(i int, j int) bool {
+		if findings[i].Path != findings[j].Path {
+			return findings[i].Path < findings[j].Path
+		}
+		return findings[i].ID < findings[j].ID
+	})
+
+	if audit.asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(findings); err != nil {
+			return err
+		}
+	} else {
+		audit.print(name, checked, len(advisories), findings)
+	}
+
+	if len(findings) > 0 {
+		return errors.New("found " + strconv.Itoa(len(findings)) + " vulnerabilities in " + strconv.Itoa(len(affected)) + " packages of " + name)
+	}
+
+	if len(unaudited) > 0 {
+		return errors.New("unable to audit " + strconv.Itoa(len(unaudited)) + " packages of " + name + ": " + strings.Join(unaudited, ", "))
+	}
+
+	return nil
+}
+
+func (audit *AuditCommand) print(name string, checked int, advisories int, findings []models.AuditFinding) {
+	if len(findings) == 0 {
+		fmt.Printf("No known vulnerabilities in the %d packages of %s, checked against %d advisories.\n", checked, name, advisories)
+		return
+	}
+
+	fmt.Printf("Vulnerabilities in %s:\n", name)
+	for _, finding := range findings {
+		fixed := "no fix"
+		if finding.Fixed != "" {
+			fixed = "fixed in " + finding.Fixed
+		}
+
+		fmt.Printf("    %s@%s\n        %s %s, %s\n", finding.Path, finding.Revision, finding.ID, finding.Severity, fixed)
+		if finding.Summary != "" {
+			fmt.Printf("        %s\n", finding.Summary)
+		}
+	}
+}
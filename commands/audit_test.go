@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+func TestAuditFailsOnFindings(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	err := f.configService.WriteLock(testGopath+"packages.lock", models.PackageLock{Package: []models.LockedPackage{
+		{Path: "example.com/lib", Revision: "v1.3.0"},
+		{Path: "example.com/safe", Revision: "v0.1.0"},
+	}})
+	if err != nil {
+		t.Fatalf("writing lock: %v", err)
+	}
+
+	f.write("/advisories/GO-2023-0001.json", `{
+  "id": "GO-2023-0001",
+  "affected": [{
+    "package": {"name": "example.com/lib"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.3.2"}]}]
+  }]
+}`)
+
+	audit := func(database string) error {
+		advisoryService := utils.GetAdvisoryService(f.logger, f.fileSystem, f.packageService)
+
+		return GetAuditCommand(f.logger, f.configService, advisoryService, database, false, testGopath, testGobo).Run("")
+	}
+
+	if err := audit(""); err == nil {
+		t.Error("audit without -db did not fail")
+	}
+
+	err = audit("/advisories")
+	if err == nil || !strings.Contains(err.Error(), "found 1 vulnerabilities in 1 packages") {
+		t.Errorf("expected audit to fail on the vulnerable package, got %v", err)
+	}
+
+	f.write("/advisories/GO-2023-0001.json", `{"id": "GO-2023-0001", "affected": [{"package": {"name": "example.com/other"}}]}`)
+	if err := audit("/advisories"); err != nil {
+		t.Errorf("expected a clean audit, got %v", err)
+	}
+
+	// a commit with no tags to place it in the range is not passed
+	err = f.configService.WriteLock(testGopath+"packages.lock", models.PackageLock{Package: []models.LockedPackage{
+		{Path: "example.com/lib", Revision: "0a1b2c3d"},
+	}})
+	if err != nil {
+		t.Fatalf("writing lock: %v", err)
+	}
+	f.write("/advisories/GO-2023-0001.json", `{
+  "id": "GO-2023-0001",
+  "affected": [{
+    "package": {"name": "example.com/lib"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "1.1.0"}, {"fixed": "1.3.2"}]}]
+  }]
+}`)
+
+	err = audit("/advisories")
+	if err == nil || !strings.Contains(err.Error(), "unable to audit 1 packages of dev: example.com/lib") {
+		t.Errorf("expected audit to fail on the package it could not check, got %v", err)
+	}
+}
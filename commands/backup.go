@@ -2,7 +2,6 @@ package commands
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/camronlevanger/gobo/models"
 	"github.com/camronlevanger/gobo/utils"
@@ -15,19 +14,25 @@ type IBackupCommand interface {
 
 // BackupCommand is the struct for this implementation of the IBackupCommand interface.
 type BackupCommand struct {
-	logger      utils.ILogger
-	copyService utils.ICopyService
-	home        string
-	gopath      string
-	gobo        string
-	restore     string
-	goboMaster  string
+	logger          utils.ILogger
+	copyService     utils.ICopyService
+	configService   utils.IConfigService
+	manifestService utils.IManifestService
+	fileSystem      utils.IFileSystem
+	home            string
+	gopath          string
+	gobo            string
+	restore         string
+	goboMaster      string
 }
 
 // GetBackupCommand returns a pointer to an implementation of the IBackupCommand interface.
 func GetBackupCommand(
 	logger utils.ILogger,
 	copyService utils.ICopyService,
+	configService utils.IConfigService,
+	manifestService utils.IManifestService,
+	fileSystem utils.IFileSystem,
 	home string,
 	gopath string,
 	gobo string,
@@ -37,6 +42,9 @@ func GetBackupCommand(
 	var backup = BackupCommand{
 		logger,
 		copyService,
+		configService,
+		manifestService,
+		fileSystem,
 		home,
 		gopath,
 		gobo,
@@ -47,24 +55,49 @@ func GetBackupCommand(
 	return &backup
 }
 
-// Run copies the current GOPATH into a gobo backup directory.
+// Run copies the src, pkg and bin directories of the current GOPATH into the gobo initial backup directory
+// the first time gobo runs, and records a manifest of the copy. A backup that fails part way is removed so
+// the next run tries again.
 func (backup *BackupCommand) Run() (bool, error) {
-	var err error
-	var initial bool
 
-	if _, err = os.Stat(backup.restore); err == nil {
+	if _, err := backup.fileSystem.Stat(backup.restore); err == nil {
 		backup.logger.Info("Gobo initial backup already exists.")
-	} else {
-		backup.logger.Info(fmt.Sprintf("Creating Gobo initial backup at %s...\n", backup.restore))
+		return false, nil
+	}
 
-		os.MkdirAll(backup.restore, models.FILEMODE)
+	backup.logger.Info(fmt.Sprintf("Creating Gobo initial backup at %s...\n", backup.restore))
 
-		err = backup.copyService.CopyDir(backup.gopath, backup.restore)
+	err := backup.fileSystem.MkdirAll(backup.restore, models.FILEMODE)
+	if err != nil {
+		return false, err
+	}
 
-		err = backup.copyService.CopyFile(backup.gopath+"bin/gobo", backup.gobo)
+	for _, dir := range models.GOPATHDIRECTORIES {
+		if _, statErr := backup.fileSystem.Stat(backup.gopath + dir); statErr != nil {
+			continue
+		}
+
+		err = backup.copyService.CopyDir(backup.gopath+dir, backup.restore)
+		if err != nil {
+			backup.fileSystem.RemoveAll(backup.restore)
+			return false, err
+		}
+	}
 
-		initial = true
+	err = writeManifest(backup.configService, backup.manifestService, backup.restore)
+	if err != nil {
+		backup.fileSystem.RemoveAll(backup.restore)
+		return false, err
+	}
 
+	if _, statErr := backup.fileSystem.Stat(backup.gopath + "bin/gobo"); statErr == nil {
+		err = backup.copyService.CopyFile(backup.gopath+"bin/gobo", backup.gobo)
+		if err != nil {
+			return true, err
+		}
+	} else {
+		backup.logger.Warn("gobo is not installed in " + backup.gopath + "bin, new environments will not include it.")
 	}
-	return initial, err
+
+	return true, nil
 }
@@ -0,0 +1,786 @@
+package commands
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+const (
+	testGopath = "/home/gopher/go/"
+	testGobo   = "/home/gopher/.gobo/"
+)
+
+// fixture wires the commands to an in-memory filesystem and a scripted runner.
+type fixture struct {
+	t                *testing.T
+	fileSystem       *utils.MemoryFileSystem
+	runner           *utils.FakeRunner
+	logger           utils.ILogger
+	configService    utils.IConfigService
+	copyService      *utils.CopyService
+	moveService      *utils.MoveService
+	importService    *utils.ImportService
+	packageService   *utils.PackageService
+	manifestService  *utils.ManifestService
+	toolchainService *utils.ToolchainService
+	resolverService  *utils.ResolverService
+}
+
+func newFixture(t *testing.T) *fixture {
+	fileSystem := utils.GetMemoryFileSystem()
+	runner := utils.GetFakeRunner()
+	logger, _ := utils.GetConfiguredLogger(utils.LogConfig{Level: utils.PANIC})
+
+	f := fixture{
+		t:                t,
+		fileSystem:       fileSystem,
+		runner:           runner,
+		logger:           logger,
+		configService:    utils.GetConfigService(logger, fileSystem),
+		copyService:      utils.GetCopyService(fileSystem),
+		moveService:      utils.GetMoveService(fileSystem),
+		importService:    utils.GetImportService(logger, fileSystem, testGopath, "/"),
+		manifestService:  utils.GetManifestService(logger, fileSystem),
+		toolchainService: utils.GetToolchainService(logger, fileSystem, testGobo+"toolchains/"),
+	}
+
+	f.packageService = utils.GetPackageService(logger, fileSystem, runner, f.importService, models.Host{}, testGopath, "/")
+	f.resolverService = utils.GetResolverService(logger, f.configService, f.packageService, testGopath, "/")
+
+	for _, dir := range models.GOPATHDIRECTORIES {
+		f.mkdir(testGopath + dir)
+	}
+	f.write(testGopath+"bin/gobo", "gobo binary")
+	f.write(testGopath+"src/example.com/original/main.go", "package main\n")
+
+	backup := GetBackupCommand(
+		logger,
+		f.copyService,
+		f.configService,
+		f.manifestService,
+		fileSystem,
+		"/home/gopher/",
+		testGopath,
+		testGobo,
+		testGobo+"initial/",
+		testGobo+"gobo_master.toml",
+	)
+
+	if initial, err := backup.Run(); err != nil || !initial {
+		t.Fatalf("initial backup returned %v, %v", initial, err)
+	}
+
+	return &f
+}
+
+// prompt returns a prompt service answering with the given lines.
+func (f *fixture) prompt(answers ...string) utils.IPromptService {
+	input := strings.Join(answers, "\n")
+	if len(answers) > 0 {
+		input += "\n"
+	}
+
+	return utils.GetPromptService(strings.NewReader(input), &strings.Builder{})
+}
+
+func (f *fixture) mkdir(path string) {
+	if err := f.fileSystem.MkdirAll(path, models.FILEMODE); err != nil {
+		f.t.Fatalf("mkdir %s: %v", path, err)
+	}
+}
+
+func (f *fixture) write(path string, content string) {
+	f.mkdir(filepath.Dir(path))
+	if err := f.fileSystem.WriteFile(path, []byte(content), 0644); err != nil {
+		f.t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func (f *fixture) exists(path string) bool {
+	_, err := f.fileSystem.Stat(path)
+
+	return err == nil
+}
+
+// addRepo creates a git repository in root/src checked out at the tag revision.
+func (f *fixture) addRepo(root string, path string, revision string) {
+	dir := root + "src/" + path
+	f.mkdir(dir + "/.git")
+	f.write(dir+"/main.go", "package main\n")
+	f.runner.Script(utils.FakeCommand{Command: "git describe --exact-match", Dir: dir, Stdout: revision + "\n"})
+}
+
+func (f *fixture) activeName() string {
+	env, err := f.configService.ReadEnvironment(testGopath + "gobo.toml")
+	if err != nil {
+		f.t.Fatalf("reading active environment: %v", err)
+	}
+
+	return env.Name
+}
+
+func (f *fixture) create(name string, initial bool) error {
+	return f.createPinned(name, initial, "")
+}
+
+func (f *fixture) createPinned(name string, initial bool, goVersion string) error {
+	create := GetCreateCommand(
+		f.logger,
+		f.configService,
+		f.copyService,
+		f.moveService,
+		f.packageService,
+		f.fileSystem,
+		f.prompt("y"),
+		f.toolchainService,
+		f.runner,
+		false,
+		goVersion,
+		testGopath,
+		testGobo,
+		models.Host{},
+		initial,
+	)
+
+	return create.Run(name)
+}
+
+func (f *fixture) activate(name string) error {
+	activate := GetActivateCommand(
+		f.logger,
+		f.configService,
+		f.packageService,
+		f.copyService,
+		f.moveService,
+		f.prompt("y"),
+		f.toolchainService,
+		f.fileSystem,
+		f.runner,
+		models.Host{},
+		testGopath,
+		testGobo,
+	)
+
+	return activate.Run(name)
+}
+
+func (f *fixture) save() error {
+	save := GetSaveCommand(
+		f.logger,
+		f.configService,
+		f.packageService,
+		f.copyService,
+		f.prompt(),
+		models.Host{},
+		testGopath,
+		testGobo,
+	)
+
+	return save.Run(true)
+}
+
+func (f *fixture) restore(force bool, to string, answers ...string) *RestoreCommand {
+	return GetRestoreCommand(
+		f.logger,
+		f.copyService,
+		f.configService,
+		f.manifestService,
+		utils.GetDiskUsageService(f.fileSystem),
+		f.fileSystem,
+		f.prompt(answers...),
+		force,
+		to,
+		testGopath,
+		testGobo,
+	)
+}
+
+func TestCreateInitialEnvironment(t *testing.T) {
+	f := newFixture(t)
+	f.write(testGopath+"src/example.com/mine/main.go", "package main\n")
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	if name := f.activeName(); name != "dev" {
+		t.Errorf("active environment is %q, want dev", name)
+	}
+
+	if !f.exists(testGobo + "dev") {
+		t.Error("environment directory was not created")
+	}
+
+	stashes, _ := f.fileSystem.ReadDir(testGobo + "unmanaged")
+	if len(stashes) != 1 || !f.exists(testGobo+"unmanaged/"+stashes[0].Name()+"/src/example.com/mine/main.go") {
+		t.Error("the unmanaged GOPATH was not moved aside")
+	}
+
+	if f.exists(testGopath + "src/example.com") {
+		t.Error("the new environment was not created empty")
+	}
+
+	if !f.exists(testGopath + "bin/gobo") {
+		t.Error("gobo was not copied into the new environment")
+	}
+}
+
+func TestCreateRefusesExistingNames(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	if err := f.create("dev", false); err == nil {
+		t.Error("creating the active environment again succeeded")
+	}
+
+	if err := f.create("initial", false); err == nil {
+		t.Error("creating a reserved environment name succeeded")
+	}
+}
+
+func TestCreateRefusesMalformedEnvironment(t *testing.T) {
+	f := newFixture(t)
+	f.write(testGopath+"gobo.toml", "name = [broken\n")
+
+	err := f.create("dev", false)
+	if err == nil || !strings.Contains(err.Error(), "parse error at line 1") {
+		t.Fatalf("create returned %v, want a parse error", err)
+	}
+}
+
+func TestActivateSwitchesEnvironments(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create dev returned %v", err)
+	}
+	f.write(testGopath+"src/example.com/dev/main.go", "package main\n")
+
+	if err := f.create("test", false); err != nil {
+		t.Fatalf("create test returned %v", err)
+	}
+
+	if !f.exists(testGobo + "dev/src/example.com/dev/main.go") {
+		t.Fatal("dev was not stored when test was created")
+	}
+
+	if err := f.activate("dev"); err != nil {
+		t.Fatalf("activate returned %v", err)
+	}
+
+	if name := f.activeName(); name != "dev" {
+		t.Errorf("active environment is %q, want dev", name)
+	}
+
+	if !f.exists(testGopath + "src/example.com/dev/main.go") {
+		t.Error("dev's sources were not restored to the GOPATH")
+	}
+
+	if !f.exists(testGobo + "test/gobo.toml") {
+		t.Error("test was not stored when dev was activated")
+	}
+}
+
+func TestActivateLeavesGopathAloneWhenTargetIsBroken(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.write(testGobo+"broken/gobo.toml", "name = \n")
+
+	if err := f.activate("broken"); err == nil {
+		t.Fatal("activating a broken environment succeeded")
+	}
+
+	if err := f.activate("missing"); err == nil {
+		t.Fatal("activating a missing environment succeeded")
+	}
+
+	if name := f.activeName(); name != "dev" {
+		t.Errorf("active environment is %q, want dev", name)
+	}
+
+	for _, dir := range models.GOPATHDIRECTORIES {
+		if !f.exists(testGopath + dir) {
+			t.Errorf("%s was moved out of the GOPATH", dir)
+		}
+	}
+}
+
+// failingMove is a move service which fails to move the path fail.
+type failingMove struct {
+	utils.IMoveService
+	fail string
+}
+
+func (move *failingMove) Move(source string, dest string) error {
+	if source == move.fail {
+		return errors.New("disk full")
+	}
+
+	return move.IMoveService.Move(source, dest)
+}
+
+func TestActivateUndoesMovesWhenOneFails(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create dev returned %v", err)
+	}
+	f.write(testGopath+"src/example.com/dev/main.go", "package main\n")
+	if err := f.create("test", false); err != nil {
+		t.Fatalf("create test returned %v", err)
+	}
+	f.write(testGopath+"src/example.com/test/main.go", "package main\n")
+
+	activate := GetActivateCommand(
+		f.logger,
+		f.configService,
+		f.packageService,
+		f.copyService,
+		&failingMove{f.moveService, testGobo + "dev/pkg"},
+		f.prompt("y"),
+		f.toolchainService,
+		f.fileSystem,
+		f.runner,
+		models.Host{},
+		testGopath,
+		testGobo,
+	)
+	if err := activate.Run("dev"); err == nil {
+		t.Fatal("activate succeeded although a move failed")
+	}
+
+	if name := f.activeName(); name != "test" {
+		t.Errorf("active environment is %q after a failed switch, want test", name)
+	}
+
+	for _, path := range []string{
+		testGopath + "src/example.com/test/main.go",
+		testGopath + "pkg",
+		testGopath + "bin",
+		testGobo + "dev/gobo.toml",
+		testGobo + "dev/src/example.com/dev/main.go",
+		testGobo + "dev/pkg",
+	} {
+		if !f.exists(path) {
+			t.Errorf("%s is missing after a failed switch", path)
+		}
+	}
+	if f.exists(testGobo + "test/src") {
+		t.Error("test's sources were left in the gobo home after a failed switch")
+	}
+}
+
+func TestActivateAbortsWhenSaveFails(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create dev returned %v", err)
+	}
+	if err := f.create("test", false); err != nil {
+		t.Fatalf("create test returned %v", err)
+	}
+	f.write(testGopath+"packages.lock", "[[package\n")
+
+	if err := f.activate("dev"); err == nil {
+		t.Fatal("activate succeeded although saving test failed")
+	}
+
+	if name := f.activeName(); name != "test" || !f.exists(testGopath+"src") || f.exists(testGobo+"test/src") {
+		t.Errorf("a failed save still moved test out of the GOPATH, %q is active", name)
+	}
+}
+
+func TestCreateAbortsWhenSaveFails(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create dev returned %v", err)
+	}
+	f.write(testGopath+"src/example.com/dev/main.go", "package main\n")
+	f.write(testGopath+"packages.lock", "[[package\n")
+
+	if err := f.create("test", false); err == nil {
+		t.Fatal("create succeeded although saving dev failed")
+	}
+
+	if name := f.activeName(); name != "dev" || !f.exists(testGopath+"src/example.com/dev/main.go") || f.exists(testGobo+"test") {
+		t.Errorf("a failed save still moved dev out of the GOPATH, %q is active", name)
+	}
+}
+
+func TestCreateUndoesMovesWhenOneFails(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create dev returned %v", err)
+	}
+	f.write(testGopath+"src/example.com/dev/main.go", "package main\n")
+
+	create := GetCreateCommand(
+		f.logger,
+		f.configService,
+		f.copyService,
+		&failingMove{f.moveService, testGopath + "packages.toml"},
+		f.packageService,
+		f.fileSystem,
+		f.prompt("y"),
+		f.toolchainService,
+		f.runner,
+		false,
+		"",
+		testGopath,
+		testGobo,
+		models.Host{},
+		false,
+	)
+	if err := create.Run("test"); err == nil {
+		t.Fatal("create succeeded although a move failed")
+	}
+
+	if name := f.activeName(); name != "dev" {
+		t.Errorf("active environment is %q after a failed create, want dev", name)
+	}
+
+	for _, path := range []string{
+		testGopath + "src/example.com/dev/main.go",
+		testGopath + "pkg",
+		testGopath + "bin/gobo",
+		testGopath + "packages.toml",
+		testGopath + "packages.lock",
+	} {
+		if !f.exists(path) {
+			t.Errorf("%s is missing after a failed create", path)
+		}
+	}
+	if f.exists(testGobo + "dev/src") {
+		t.Error("dev's sources were left in the gobo home after a failed create")
+	}
+}
+
+func TestSaveRecordsInstalledPackages(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.addRepo(testGopath, "github.com/pkg/errors", "v0.8.0")
+
+	if err := f.save(); err != nil {
+		t.Fatalf("save returned %v", err)
+	}
+
+	lock, err := f.configService.ReadLock(testGopath + "packages.lock")
+	if err != nil {
+		t.Fatalf("reading lock: %v", err)
+	}
+
+	if len(lock.Package) != 1 {
+		t.Fatalf("locked %d packages, want 1", len(lock.Package))
+	}
+
+	if lock.Package[0].Path != "github.com/pkg/errors" || lock.Package[0].Revision != "v0.8.0" {
+		t.Errorf("locked %+v", lock.Package[0])
+	}
+
+	paks, err := f.configService.ReadPackages(testGopath + "packages.toml")
+	if err != nil || len(paks.Package) != 0 {
+		t.Errorf("save rewrote the manifest to %+v, %v", paks.Package, err)
+	}
+}
+
+func TestInstallGetsPackagesAtRevision(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	f.write(testGopath+"deps.toml", "[[package]]\npath = \"github.com/pkg/errors\"\nrevision = \"v0.7.0\"\n")
+
+	f.runner.Script(
+		utils.FakeCommand{
+			Command: "go get github.com/pkg/errors",
+			Do: func(dir string, env []string) {
+				f.addRepo(testGopath, "github.com/pkg/errors", "v0.7.0")
+			},
+		},
+		utils.FakeCommand{Command: "git checkout v0.7.0", Dir: testGopath + "src/github.com/pkg/errors"},
+		utils.FakeCommand{Command: "go install github.com/pkg/errors"},
+	)
+
+	install := GetInstallCommand(
+		f.logger,
+		f.configService,
+		f.packageService,
+		f.resolverService,
+		f.importService,
+		f.copyService,
+		f.prompt(),
+		models.Host{},
+		testGopath,
+		testGobo,
+	)
+
+	if err := install.Run(testGopath + "deps.toml"); err != nil {
+		t.Fatalf("install returned %v", err)
+	}
+
+	for _, command := range []string{"go get github.com/pkg/errors", "git checkout v0.7.0", "go install github.com/pkg/errors"} {
+		if !f.runner.Ran(command) {
+			t.Errorf("install did not run %s", command)
+		}
+	}
+
+	lock, err := f.configService.ReadLock(testGopath + "packages.lock")
+	if err != nil {
+		t.Fatalf("reading lock: %v", err)
+	}
+
+	if len(lock.Package) != 1 || lock.Package[0].Revision != "v0.7.0" {
+		t.Errorf("locked %+v after install", lock.Package)
+	}
+}
+
+func TestDeleteEnvironment(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create dev returned %v", err)
+	}
+	if err := f.create("test", false); err != nil {
+		t.Fatalf("create test returned %v", err)
+	}
+
+	delete := GetDeleteCommand(f.logger, f.moveService, f.configService, testGobo, testGopath)
+
+	if err := delete.Run("test"); err == nil {
+		t.Error("deleting the active environment succeeded")
+	}
+
+	if err := delete.Run("dev"); err != nil {
+		t.Fatalf("delete returned %v", err)
+	}
+
+	if f.exists(testGobo + "dev") {
+		t.Error("dev still exists after delete")
+	}
+}
+
+func TestRestoreRequiresConfirmation(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	restore := f.restore(false, "", "no")
+	if err := restore.Run(nil); err != nil {
+		t.Fatalf("restore returned %v", err)
+	}
+
+	if !f.exists(testGobo+"dev") || !f.exists(testGopath+"gobo.toml") {
+		t.Fatal("restore changed something without confirmation")
+	}
+
+	restore = f.restore(false, "", "yes")
+	if err := restore.Run(nil); err != nil {
+		t.Fatalf("restore returned %v", err)
+	}
+
+	if f.exists(testGobo) {
+		t.Error("restore left the gobo home behind")
+	}
+
+	if f.exists(testGopath + "gobo.toml") {
+		t.Error("restore left the environment file in the GOPATH")
+	}
+
+	if !f.exists(testGopath+"src/example.com/original/main.go") || !f.exists(testGopath+"bin/gobo") {
+		t.Error("restore did not put the backed up GOPATH back")
+	}
+}
+
+func TestRestoreToDirectory(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	f.write("/tmp/full/README", "keep")
+	if err := f.restore(false, "/tmp/full").Run(nil); err == nil {
+		t.Fatal("restore extracted into a non-empty directory")
+	}
+
+	if err := f.restore(false, testGopath+"old").Run(nil); err == nil {
+		t.Fatal("restore extracted into the GOPATH")
+	}
+
+	if err := f.restore(false, "/tmp/extracted").Run(nil); err != nil {
+		t.Fatalf("restore -to returned %v", err)
+	}
+
+	if !f.exists("/tmp/extracted/src/example.com/original/main.go") {
+		t.Error("the backup was not extracted")
+	}
+
+	if !f.exists(testGobo+"dev") || !f.exists(testGopath+"gobo.toml") {
+		t.Error("restore -to changed the gobo home or GOPATH")
+	}
+}
+
+func TestRestorePackage(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.write(testGopath+"src/example.com/original/main.go", "changed")
+	f.write(testGopath+"src/example.com/mine/main.go", "package main\n")
+
+	if err := f.restore(false, "", "yes").Run([]string{"example.com/missing"}); err == nil {
+		t.Fatal("restore accepted a package that is not in the backup")
+	}
+
+	for _, target := range []string{"..", "example.com/../..", "/example.com/original", ".", "src/.."} {
+		if err := f.restore(false, "", "yes").Run([]string{target}); err == nil {
+			t.Errorf("restore accepted the target %s", target)
+		}
+	}
+	if !f.exists(testGopath+"src/example.com/mine/main.go") || !f.exists(testGobo+"dev") {
+		t.Fatal("restore of a target outside src deleted the GOPATH")
+	}
+
+	if err := f.restore(false, "", "yes").Run([]string{"./example.com//original/"}); err != nil {
+		t.Fatalf("restore returned %v", err)
+	}
+
+	data, _ := f.fileSystem.ReadFile(testGopath + "src/example.com/original/main.go")
+	if string(data) == "changed" {
+		t.Error("the package was not restored from the backup")
+	}
+
+	if !f.exists(testGopath+"src/example.com/mine/main.go") || !f.exists(testGobo+"dev") {
+		t.Error("a partial restore touched more than the package")
+	}
+}
+
+func TestRestoreRefusesCorruptedBackup(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.write(testGobo+"initial/src/example.com/original/main.go", "tampered")
+
+	if err := f.restore(false, "", "yes").Run(nil); err == nil {
+		t.Fatal("restore from a corrupted backup succeeded")
+	}
+
+	if !f.exists(testGobo+"dev") || !f.exists(testGopath+"gobo.toml") {
+		t.Fatal("a refused restore changed something")
+	}
+
+	if err := f.restore(true, "", "yes").Run(nil); err != nil {
+		t.Fatalf("forced restore returned %v", err)
+	}
+}
+
+func TestRestoreFromBackupWithoutManifest(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	// backups made before manifests were recorded have none
+	f.fileSystem.Remove(testGobo + "initial/" + utils.MANIFESTFILE)
+
+	err := f.restore(false, "", "yes").Run([]string{"example.com/original"})
+	if err == nil || !strings.Contains(err.Error(), "before gobo recorded manifests") {
+		t.Fatalf("expected restore from a backup without a manifest to be refused, got %v", err)
+	}
+
+	if err := f.restore(true, "", "yes").Run([]string{"example.com/original"}); err != nil {
+		t.Fatalf("forced restore from a backup without a manifest returned %v", err)
+	}
+
+	f.write(testGobo+"initial/src/example.com/original/main.go", "tampered")
+	if err := writeManifest(f.configService, f.manifestService, testGobo+"initial/"); err != nil {
+		t.Fatalf("writing manifest: %v", err)
+	}
+	f.write(testGobo+"initial/src/example.com/original/main.go", "tampered again")
+
+	if err := f.restore(false, "", "yes").Run([]string{"example.com/original"}); err == nil {
+		t.Error("restore from a backup which does not match its manifest succeeded")
+	}
+}
+
+func TestVerifyBackup(t *testing.T) {
+	f := newFixture(t)
+
+	verify := GetVerifyCommand(f.logger, f.configService, f.manifestService, f.fileSystem, testGobo)
+	if err := verify.Run("", ""); err != nil {
+		t.Fatalf("verifying a fresh backup returned %v", err)
+	}
+
+	if !f.exists(testGobo + "initial/bin/gobo") {
+		t.Error("the backup is missing the GOPATH bin directory")
+	}
+
+	f.fileSystem.Remove(testGobo + "initial/bin/gobo")
+	f.write(testGobo+"initial/src/example.com/added.go", "package example")
+
+	err := verify.Run("", "")
+	if err == nil {
+		t.Fatal("verifying a damaged backup succeeded")
+	}
+
+	for _, problem := range []string{"bin/gobo: missing", "src/example.com/added.go: not in the manifest"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("verification error %q does not report %q", err.Error(), problem)
+		}
+	}
+}
+
+func TestManifestRecordsSymlinks(t *testing.T) {
+	f := newFixture(t)
+
+	root := "/backup/"
+	f.write(root+"src/example.com/lib/lib.go", "package lib")
+	f.fileSystem.Symlink("lib", root+"src/example.com/alias")
+	f.fileSystem.Symlink("missing", root+"src/example.com/dangling")
+
+	if err := writeManifest(f.configService, f.manifestService, root); err != nil {
+		t.Fatalf("writing manifest: %v", err)
+	}
+	if err := verifyManifest(f.configService, f.manifestService, root); err != nil {
+		t.Fatalf("verifying an intact backup returned %v", err)
+	}
+
+	f.fileSystem.Remove(root + "src/example.com/alias")
+	f.fileSystem.Symlink("elsewhere", root+"src/example.com/alias")
+	f.fileSystem.Remove(root + "src/example.com/dangling")
+
+	err := verifyManifest(f.configService, f.manifestService, root)
+	if err == nil {
+		t.Fatal("verifying a backup with changed links succeeded")
+	}
+
+	for _, problem := range []string{`src/example.com/alias: links to "elsewhere", expected "lib"`, "src/example.com/dangling: missing"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("verification error %q does not report %q", err.Error(), problem)
+		}
+	}
+}
@@ -0,0 +1,401 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// ICompletionCommand is the interface to implement for printing shell completion scripts.
+type ICompletionCommand interface {
+	Run(shell string) error
+}
+
+// CompletionCommand is the struct for this implementation of ICompletionCommand.
+type CompletionCommand struct {
+	logger utils.ILogger
+	flags  []models.Flag
+}
+
+// GetCompletionCommand returns a pointer to an implementation of ICompletionCommand completing the given flags
+// and the commands in models.COMMANDS.
+func GetCompletionCommand(logger utils.ILogger, flags []models.Flag) *CompletionCommand {
+	var completion = CompletionCommand{
+		logger,
+		flags,
+	}
+
+	return &completion
+}
+
+// Run prints the completion script for shell, which is bash, zsh or fish.
+func (completion *CompletionCommand) Run(shell string) error {
+	var script string
+
+	switch shell {
+	case "bash":
+		script = completion.bash()
+	case "zsh":
+		script = completion.zsh()
+	case "fish":
+		script = completion.fish()
+	default:
+		return errors.New("unknown shell " + shell + ", expected bash, zsh or fish")
+	}
+
+	fmt.Print(script)
+
+	return nil
+}
+
+// completionCase is one branch of the completion scripts, completing position of command.
+type completionCase struct {
+	pattern  string
+	argument models.Argument
+}
+
+// cases returns the positional arguments worth completing as command:position patterns, repeated arguments
+// match every later position so they come after the rest.
+func cases() []completionCase {
+	var list []completionCase
+
+	for _, command := range models.COMMANDS {
+		for i, argument := range command.Arguments {
+			if argument.Kind == models.ARGTEXT && len(argument.Choices) == 0 {
+				continue
+			}
+
+			pattern := command.Name + ":" + strconv.Itoa(i)
+			if argument.Repeated {
+				pattern = command.Name + ":*"
+			}
+
+			list = append(list, completionCase{pattern, argument})
+		}
+	}
+
+	return list
+}
+
+func commandNames() []string {
+	var names []string
+
+	for _, command := range models.COMMANDS {
+		names = append(names, command.Name)
+	}
+
+	return names
+}
+
+// valueFlags returns the patterns matching the flags which take a value, for example -f|--f.
+func (completion *CompletionCommand) valueFlags() string {
+	var patterns []string
+
+	for _, flag := range completion.flags {
+		if !flag.Boolean {
+			patterns = append(patterns, "-"+flag.Name+"|--"+flag.Name)
+		}
+	}
+
+	return strings.Join(patterns, "|")
+}
+
+func (completion *CompletionCommand) flagNames() []string {
+	var names []string
+
+	for _, flag := range completion.flags {
+		names = append(names, "-"+flag.Name)
+	}
+
+	return names
+}
+
+// reservedPattern matches the gobo home directories which are not environments.
+func reservedPattern() string {
+	return strings.Join(models.RESERVEDDIRECTORIES[:], "|")
+}
+
+// packagesScript prints the package paths of the active packages.lock, or packages.toml, in bash or zsh.
+const packagesScript = `    local gopath=${GOPATH:-$HOME/go}
+    gopath=${gopath%%:*}
+    local file="$gopath/packages.lock"
+    [ -f "$file" ] || file="$gopath/packages.toml"
+    [ -f "$file" ] || return
+    sed -n 's/^[[:space:]]*path[[:space:]]*=[[:space:]]*"\(.*\)"[[:space:]]*$/\1/p' "$file"
+`
+
+func (completion *CompletionCommand) bash() string {
+	var script strings.Builder
+
+	script.WriteString("# bash completion for gobo, generated by gobo completion bash.\n")
+	script.WriteString("# Load it with: source <(gobo completion bash)\n\n")
+
+	script.WriteString("_gobo_environments() {\n")
+	script.WriteString("    local dir name\n")
+	script.WriteString(`    for dir in "$HOME/.gobo"/*/; do` + "\n")
+	script.WriteString(`        [ -d "$dir" ] || continue` + "\n")
+	script.WriteString("        name=${dir%/}\n")
+	script.WriteString("        name=${name##*/}\n")
+	script.WriteString(`        case "$name" in` + "\n")
+	script.WriteString("            " + reservedPattern() + ") ;;\n")
+	script.WriteString(`            *) printf '%s\n' "$name" ;;` + "\n")
+	script.WriteString("        esac\n")
+	script.WriteString("    done\n")
+	script.WriteString("}\n\n")
+
+	script.WriteString("_gobo_packages() {\n" + packagesScript + "}\n\n")
+
+	script.WriteString("_gobo() {\n")
+	script.WriteString("    local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}\n")
+	script.WriteString(`    local i command="" position=0` + "\n\n")
+
+	script.WriteString(`    case "$prev" in` + "\n")
+	for _, flag := range completion.flags {
+		if flag.Boolean {
+			continue
+		}
+		script.WriteString("        -" + flag.Name + "|--" + flag.Name + ") ")
+		switch flag.Value.Kind {
+		case models.ARGFILE:
+			script.WriteString(`COMPREPLY=($(compgen -f -- "$cur"))`)
+		case models.ARGDIRECTORY:
+			script.WriteString(`COMPREPLY=($(compgen -d -- "$cur"))`)
+		default:
+			script.WriteString(`COMPREPLY=($(compgen -W "` + bashWords(flag.Value) + `" -- "$cur"))`)
+		}
+		script.WriteString("; return ;;\n")
+	}
+	script.WriteString("    esac\n\n")
+
+	script.WriteString("    if [[ $cur == -* ]]; then\n")
+	script.WriteString(`        COMPREPLY=($(compgen -W "` + strings.Join(completion.flagNames(), " ") + `" -- "$cur"))` + "\n")
+	script.WriteString("        return\n")
+	script.WriteString("    fi\n\n")
+
+	script.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	script.WriteString(`        case "${COMP_WORDS[i]}" in` + "\n")
+	script.WriteString("            --) return ;;\n")
+	script.WriteString("            " + completion.valueFlags() + ") ((i++)) ;;\n")
+	script.WriteString("            -*) ;;\n")
+	script.WriteString("            *)\n")
+	script.WriteString(`                if [ -z "$command" ]; then command=${COMP_WORDS[i]}; else ((position++)); fi` + "\n")
+	script.WriteString("                ;;\n")
+	script.WriteString("        esac\n")
+	script.WriteString("    done\n\n")
+
+	script.WriteString(`    case "$command:$position" in` + "\n")
+	script.WriteString(`        :*) COMPREPLY=($(compgen -W "` + strings.Join(commandNames(), " ") + `" -- "$cur")) ;;` + "\n")
+	for _, c := range cases() {
+		script.WriteString("        " + c.pattern + `) COMPREPLY=($(compgen -W "` + bashWords(c.argument) + `" -- "$cur")) ;;` + "\n")
+	}
+	script.WriteString("    esac\n")
+	script.WriteString("}\n\n")
+
+	script.WriteString("complete -o default -F _gobo gobo\n")
+
+	return script.String()
+}
+
+// bashWords returns the word list of a compgen -W for argument, also used by zsh's compadd.
+func bashWords(argument models.Argument) string {
+	words := append([]string{}, argument.Choices...)
+
+	switch argument.Kind {
+	case models.ARGENVIRONMENT:
+		words = append(words, "$(_gobo_environments)")
+	case models.ARGPACKAGE:
+		words = append(words, "$(_gobo_packages)")
+	}
+
+	return strings.Join(words, " ")
+}
+
+func (completion *CompletionCommand) zsh() string {
+	var script strings.Builder
+
+	script.WriteString("#compdef gobo\n")
+	script.WriteString("# zsh completion for gobo, generated by gobo completion zsh.\n")
+	script.WriteString("# Load it with: source <(gobo completion zsh), or save it as _gobo in your $fpath.\n\n")
+
+	script.WriteString("_gobo_environments() {\n")
+	script.WriteString("    local dir\n")
+	script.WriteString(`    for dir in "$HOME/.gobo"/*(N/); do` + "\n")
+	script.WriteString(`        case "${dir:t}" in` + "\n")
+	script.WriteString("            " + reservedPattern() + ") ;;\n")
+	script.WriteString(`            *) print -r -- "${dir:t}" ;;` + "\n")
+	script.WriteString("        esac\n")
+	script.WriteString("    done\n")
+	script.WriteString("}\n\n")
+
+	script.WriteString("_gobo_packages() {\n" + packagesScript + "}\n\n")
+
+	script.WriteString("_gobo() {\n")
+	script.WriteString("    local cur=${words[CURRENT]} prev=${words[CURRENT-1]}\n")
+	script.WriteString(`    local i command="" position=0` + "\n")
+	script.WriteString("    local -a gobo_commands\n")
+	script.WriteString("    gobo_commands=(\n")
+	for _, command := range models.COMMANDS {
+		script.WriteString("        " + shellQuote(command.Name+":"+command.Summary) + "\n")
+	}
+	script.WriteString("    )\n\n")
+
+	script.WriteString(`    case "$prev" in` + "\n")
+	for _, flag := range completion.flags {
+		if flag.Boolean {
+			continue
+		}
+		script.WriteString("        -" + flag.Name + "|--" + flag.Name + ") ")
+		switch flag.Value.Kind {
+		case models.ARGFILE:
+			script.WriteString("_files")
+		case models.ARGDIRECTORY:
+			script.WriteString("_files -/")
+		default:
+			script.WriteString("compadd -- " + bashWords(flag.Value))
+		}
+		script.WriteString("; return ;;\n")
+	}
+	script.WriteString("    esac\n\n")
+
+	script.WriteString("    if [[ $cur == -* ]]; then\n")
+	script.WriteString("        compadd -- " + strings.Join(completion.flagNames(), " ") + "\n")
+	script.WriteString("        return\n")
+	script.WriteString("    fi\n\n")
+
+	script.WriteString("    for ((i = 2; i < CURRENT; i++)); do\n")
+	script.WriteString(`        case "${words[i]}" in` + "\n")
+	script.WriteString("            --) _files; return ;;\n")
+	script.WriteString("            " + completion.valueFlags() + ") ((i++)) ;;\n")
+	script.WriteString("            -*) ;;\n")
+	script.WriteString("            *)\n")
+	script.WriteString(`                if [ -z "$command" ]; then command=${words[i]}; else ((position++)); fi` + "\n")
+	script.WriteString("                ;;\n")
+	script.WriteString("        esac\n")
+	script.WriteString("    done\n\n")
+
+	script.WriteString(`    case "$command:$position" in` + "\n")
+	script.WriteString("        :*) _describe command gobo_commands ;;\n")
+	for _, c := range cases() {
+		script.WriteString("        " + c.pattern + ") compadd -- " + bashWords(c.argument) + " ;;\n")
+	}
+	script.WriteString("        *) _files ;;\n")
+	script.WriteString("    esac\n")
+	script.WriteString("}\n\n")
+
+	script.WriteString(`if [ "$funcstack[1]" = "_gobo" ]; then` + "\n")
+	script.WriteString(`    _gobo "$@"` + "\n")
+	script.WriteString("else\n")
+	script.WriteString("    compdef _gobo gobo\n")
+	script.WriteString("fi\n")
+
+	return script.String()
+}
+
+func (completion *CompletionCommand) fish() string {
+	var script strings.Builder
+
+	script.WriteString("# fish completion for gobo, generated by gobo completion fish.\n")
+	script.WriteString("# Load it with: gobo completion fish | source\n\n")
+
+	script.WriteString("function __gobo_environments\n")
+	script.WriteString("    for dir in $HOME/.gobo/*/\n")
+	script.WriteString("        set -l name (basename $dir)\n")
+	script.WriteString("        contains -- $name " + strings.Join(models.RESERVEDDIRECTORIES[:], " ") + "; or echo $name\n")
+	script.WriteString("    end\n")
+	script.WriteString("end\n\n")
+
+	script.WriteString("function __gobo_packages\n")
+	script.WriteString("    set -l gopath $GOPATH\n")
+	script.WriteString("    test -n \"$gopath\"; or set gopath $HOME/go\n")
+	script.WriteString("    set gopath (string split : -- $gopath)[1]\n")
+	script.WriteString("    set -l file $gopath/packages.lock\n")
+	script.WriteString("    test -f $file; or set file $gopath/packages.toml\n")
+	script.WriteString("    test -f $file; or return\n")
+	script.WriteString(`    string replace -rf '^\s*path\s*=\s*"(.*)"\s*$' '$1' < $file` + "\n")
+	script.WriteString("end\n\n")
+
+	var valueFlags []string
+	for _, flag := range completion.flags {
+		if !flag.Boolean {
+			valueFlags = append(valueFlags, "-"+flag.Name, "--"+flag.Name)
+		}
+	}
+
+	script.WriteString("function __gobo_position\n")
+	script.WriteString("    set -l command\n")
+	script.WriteString("    set -l position 0\n")
+	script.WriteString("    set -l skip 0\n")
+	script.WriteString("    for token in (commandline -opc)[2..-1]\n")
+	script.WriteString("        if test $skip = 1\n")
+	script.WriteString("            set skip 0\n")
+	script.WriteString("            continue\n")
+	script.WriteString("        end\n")
+	script.WriteString("        switch $token\n")
+	script.WriteString("            case --\n")
+	script.WriteString("                echo --\n")
+	script.WriteString("                return\n")
+	script.WriteString("            case " + strings.Join(valueFlags, " ") + "\n")
+	script.WriteString("                set skip 1\n")
+	script.WriteString("            case '-*'\n")
+	script.WriteString("            case '*'\n")
+	script.WriteString("                if test -z \"$command\"\n")
+	script.WriteString("                    set command $token\n")
+	script.WriteString("                else\n")
+	script.WriteString("                    set position (math $position + 1)\n")
+	script.WriteString("                end\n")
+	script.WriteString("        end\n")
+	script.WriteString("    end\n")
+	script.WriteString("    echo $command:$position\n")
+	script.WriteString("end\n\n")
+
+	script.WriteString("function __gobo_arguments\n")
+	script.WriteString("    switch (__gobo_position)\n")
+	script.WriteString("        case ':*'\n")
+	for _, command := range models.COMMANDS {
+		script.WriteString("            printf '%s\\t%s\\n' " + command.Name + " " + fishQuote(command.Summary) + "\n")
+	}
+	for _, c := range cases() {
+		script.WriteString("        case " + fishQuote(c.pattern) + "\n")
+		if len(c.argument.Choices) > 0 {
+			script.WriteString("            printf '%s\\n' " + strings.Join(c.argument.Choices, " ") + "\n")
+		}
+		switch c.argument.Kind {
+		case models.ARGENVIRONMENT:
+			script.WriteString("            __gobo_environments\n")
+		case models.ARGPACKAGE:
+			script.WriteString("            __gobo_packages\n")
+		}
+	}
+	script.WriteString("    end\n")
+	script.WriteString("end\n\n")
+
+	script.WriteString("complete -c gobo -f -a '(__gobo_arguments)'\n")
+	for _, flag := range completion.flags {
+		line := "complete -c gobo -o " + flag.Name
+		switch {
+		case flag.Boolean:
+		case flag.Value.Kind == models.ARGFILE:
+			line += " -r -F"
+		case flag.Value.Kind == models.ARGDIRECTORY:
+			line += " -x -a '(__fish_complete_directories)'"
+		case len(flag.Value.Choices) > 0:
+			line += " -x -a " + fishQuote(strings.Join(flag.Value.Choices, " "))
+		default:
+			line += " -x"
+		}
+		script.WriteString(line + " -d " + fishQuote(flag.Usage) + "\n")
+	}
+
+	return script.String()
+}
+
+// fishQuote quotes value for fish, which only escapes backslashes and quotes inside single quotes.
+func fishQuote(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+
+	return "'" + strings.Replace(value, "'", `\'`, -1) + "'"
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+func TestCompletionScripts(t *testing.T) {
+	f := newFixture(t)
+
+	flags := []models.Flag{
+		{Name: "f", Usage: "packages file", Value: models.FLAGVALUES["f"]},
+		{Name: "sort", Usage: "sort order", Value: models.FLAGVALUES["sort"]},
+		{Name: "yes", Usage: "skip the confirmation", Boolean: true},
+	}
+
+	completion := GetCompletionCommand(f.logger, flags)
+
+	scripts := map[string][]string{
+		completion.bash(): {
+			`activate:0) COMPREPLY=($(compgen -W "$(_gobo_environments)"`,
+			`restore:*) COMPREPLY=($(compgen -W "src pkg bin $(_gobo_packages)"`,
+			`-sort|--sort) COMPREPLY=($(compgen -W "size name"`,
+			`-f|--f) COMPREPLY=($(compgen -f`,
+			"complete -o default -F _gobo gobo",
+		},
+		completion.zsh(): {
+			"#compdef gobo",
+			"'activate:Save the active environment and switch the GOPATH to another one.'",
+			"completion:0) compadd -- bash zsh fish ;;",
+		},
+		completion.fish(): {
+			"case -f --f -sort --sort\n",
+			"case 'delete:0'\n            __gobo_environments",
+			"complete -c gobo -o yes -d 'skip the confirmation'",
+		},
+	}
+
+	for script, wants := range scripts {
+		for _, want := range wants {
+			if !strings.Contains(script, want) {
+				t.Errorf("completion script is missing %q:\n%s", want, script)
+			}
+		}
+	}
+
+	if err := completion.Run("powershell"); err == nil {
+		t.Error("completion accepted an unknown shell")
+	}
+}
@@ -3,8 +3,6 @@ package commands
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"os"
 	"time"
 
 	"github.com/camronlevanger/gobo/models"
@@ -18,26 +16,37 @@ type ICreateCommand interface {
 
 // CreateCommand is the struct for this instance of ICreateCommand interface.
 type CreateCommand struct {
-	logger         utils.ILogger
-	configService  utils.IConfigService
-	copyService    utils.ICopyService
-	moveService    utils.IMoveService
-	packageService utils.IPackageService
-	populate       bool
-	gopath         string
-	gobopath       string
-	host           models.Host
-	initial        bool
+	logger           utils.ILogger
+	configService    utils.IConfigService
+	copyService      utils.ICopyService
+	moveService      utils.IMoveService
+	packageService   utils.IPackageService
+	fileSystem       utils.IFileSystem
+	promptService    utils.IPromptService
+	toolchainService utils.IToolchainService
+	runner           utils.ICommandRunner
+	populate         bool
+	goVersion        string
+	gopath           string
+	gobopath         string
+	host             models.Host
+	initial          bool
 }
 
-// GetCreateCommand returns an implementation of ICreateCommand.
+// GetCreateCommand returns an implementation of ICreateCommand. A goVersion pins the new environment to that
+// Go toolchain.
 func GetCreateCommand(
 	logger utils.ILogger,
 	configService utils.IConfigService,
 	copyService utils.ICopyService,
 	moveService utils.IMoveService,
 	packageService utils.IPackageService,
+	fileSystem utils.IFileSystem,
+	promptService utils.IPromptService,
+	toolchainService utils.IToolchainService,
+	runner utils.ICommandRunner,
 	populate bool,
+	goVersion string,
 	gopath string,
 	gobopath string,
 	host models.Host,
@@ -49,7 +58,12 @@ func GetCreateCommand(
 		copyService,
 		moveService,
 		packageService,
+		fileSystem,
+		promptService,
+		toolchainService,
+		runner,
 		populate,
+		goVersion,
 		gopath,
 		gobopath,
 		host,
@@ -62,13 +76,33 @@ func GetCreateCommand(
 // Run creates a new virtual environment with the name provided.
 func (create *CreateCommand) Run(name string) error {
 
-	var current = "initial"
+	// an unmanaged GOPATH is stashed in the gobo home, the initial backup keeps its own pristine copy
+	var current = "unmanaged/" + time.Now().UTC().Format("20060102-150405")
 
-	_, existsErr := os.Stat(create.gopath + "gobo.toml")
-	if existsErr == nil {
+	if models.IsReserved(name) {
+		return errors.New(name + " is reserved by gobo and can't be used as an environment name.")
+	}
+
+	environment := models.Environment{}
+	environment.Name = name
+	environment.GoVersion = utils.NormalizeGoVersion(create.goVersion)
+	environment.Host = create.host
+
+	// make sure the toolchain is available before anything is moved
+	goroot, err := resolveToolchain(create.toolchainService, environment)
+	if err != nil {
+		return errors.New("unable to select the toolchain of " + name + ": " + err.Error())
+	}
+
+	env, err := create.configService.ReadEnvironment(create.gopath + "gobo.toml")
+	if err != nil && !utils.IsConfigNotFound(err) {
+		return errors.New("unable to read the active environment: " + err.Error())
+	}
+
+	// a missing gobo.toml means gobo has not managed this GOPATH yet
+	if err == nil {
 		create.logger.Info("A current environment seems to exist...")
 
-		env := create.configService.ReadEnvironment(create.gopath + "gobo.toml")
 		current = env.Name
 
 		// make sure we aren't creating the environment we are in
@@ -77,7 +111,7 @@ func (create *CreateCommand) Run(name string) error {
 		}
 
 		// make sure we aren't creating an existing environment
-		envs, _ := ioutil.ReadDir(create.gobopath)
+		envs, _ := create.fileSystem.ReadDir(create.gobopath)
 		count := 0
 		for _, e := range envs {
 			if e.IsDir() {
@@ -88,6 +122,12 @@ func (create *CreateCommand) Run(name string) error {
 			}
 		}
 
+		// a failing pre-deactivate hook leaves the current environment active
+		err = runHook(create.logger, create.runner, create.gopath, create.gobopath, env, "pre-deactivate", env.Name, name)
+		if err != nil {
+			return err
+		}
+
 		// if there is a current env, try to save it first.
 		if !create.initial {
 
@@ -98,6 +138,7 @@ func (create *CreateCommand) Run(name string) error {
 				create.configService,
 				create.packageService,
 				create.copyService,
+				create.promptService,
 				create.host,
 				create.gopath,
 				create.gobopath,
@@ -105,84 +146,116 @@ func (create *CreateCommand) Run(name string) error {
 
 			err := save.Run(false)
 			if err != nil {
-				create.logger.Error("Error running gobo save command: " + err.Error())
+				return errors.New("unable to save " + env.Name + " before creating " + name + ", nothing was moved: " + err.Error())
 			}
 		}
 	}
 
-	var installedPackages []models.Package
+	var lock models.PackageLock
+	var moves []pendingMove
 
 	if !create.populate {
 		for _, dir := range models.GOPATHDIRECTORIES {
-			create.logger.Info(fmt.Sprintf("Removing active %s directory at %s\n", dir, create.gopath))
-			err := create.moveService.Move(create.gopath+dir, create.gobopath+current)
-			if err != nil {
-				create.logger.Error("Error moving GOPATH directory: " + err.Error())
-			}
+			moves = append(moves, pendingMove{create.gopath + dir, create.gobopath + current})
 		}
 	} else {
 		create.logger.Info("Populating this new environment, so looking up installed packages and their bookmarks...")
 
-		installedPackages = create.packageService.GetInstalledPackages()
+		lock = create.packageService.GenerateLock(nil, nil)
 	}
 
 	if !create.initial {
-		err := create.moveService.Move(create.gopath+"gobo.toml", create.gobopath+current)
+		for _, file := range models.ENVIRONMENTFILES {
+			moves = append(moves, pendingMove{create.gopath + file, create.gobopath + current})
+		}
+	}
+
+	if len(moves) > 0 {
+		// the moves go into the directory of the current environment, not in its place
+		err = create.fileSystem.MkdirAll(create.gobopath+current, models.FILEMODE)
 		if err != nil {
-			create.logger.Error("Error moving current toml env file: " + err.Error())
+			return err
 		}
 
-		err = create.moveService.Move(create.gopath+"packages.toml", create.gobopath+current)
+		err = moveAll(create.logger, create.fileSystem, create.moveService, moves)
 		if err != nil {
-			create.logger.Error("Error moving current toml packages file: " + err.Error())
+			return errors.New("unable to move " + create.gopath + " aside for " + name + ", the moves done were undone: " + err.Error())
 		}
 	}
 
-	environment := models.Environment{}
-	environment.Name = name
-	environment.Host = create.host
 	environment.DateCreated = time.Now()
 	environment.DateModified = time.Now()
 
 	deps := models.Dependencies{}
-	deps.Package = installedPackages
+	for _, locked := range lock.Package {
+		deps.Package = append(deps.Package, models.Package{Path: locked.Path, Origin: locked.Origin})
+	}
 
 	envpath := create.gopath + "gobo.toml"
 	pakpath := create.gopath + "packages.toml"
+	lockpath := create.gopath + "packages.lock"
 
 	create.logger.Info("Writing environment file (gobo.toml).")
-	err := create.configService.WriteEnvironment(envpath, environment)
+	err = create.configService.WriteEnvironment(envpath, environment)
+	if err != nil {
+		return err
+	}
 
 	create.logger.Info("Writing packages file (packages.toml).")
 	err = create.configService.WritePackages(pakpath, deps)
+	if err != nil {
+		return err
+	}
 
+	create.logger.Info("Writing package lock (packages.lock).")
+	err = create.configService.WriteLock(lockpath, lock)
 	if err != nil {
 		return err
 	}
 
-	err = os.Mkdir(create.gobopath+name, models.FILEMODE)
+	err = create.fileSystem.Mkdir(create.gobopath+name, models.FILEMODE)
 	if err != nil {
 		return err
 
 	}
 
-	_, existsErr = os.Stat(create.gopath + "bin")
+	_, existsErr := create.fileSystem.Stat(create.gopath + "bin")
 	if existsErr != nil {
-		os.MkdirAll(create.gopath+"bin", models.FILEMODE)
+		create.fileSystem.MkdirAll(create.gopath+"bin", models.FILEMODE)
 	}
 
-	_, existsErr = os.Stat(create.gopath + "pkg")
+	_, existsErr = create.fileSystem.Stat(create.gopath + "pkg")
 	if existsErr != nil {
-		os.MkdirAll(create.gopath+"pkg", models.FILEMODE)
+		create.fileSystem.MkdirAll(create.gopath+"pkg", models.FILEMODE)
 	}
 
-	_, existsErr = os.Stat(create.gopath + "src")
+	_, existsErr = create.fileSystem.Stat(create.gopath + "src")
 	if existsErr != nil {
-		os.MkdirAll(create.gopath+"src", models.FILEMODE)
+		create.fileSystem.MkdirAll(create.gopath+"src", models.FILEMODE)
 	}
 
 	if !create.populate {
 		err = create.copyService.CopyFile(create.gobopath+"gobo", create.gopath+"bin")
+		if err != nil {
+			return err
+		}
 	}
-	return err
+
+	err = writeActivateScript(create.fileSystem, create.gobopath, environment, goroot)
+	if err != nil {
+		return err
+	}
+
+	if goroot != "" {
+		fmt.Println(name + " uses Go " + environment.GoVersion + ", run . " + create.gobopath + activateScript + " to switch to it.")
+	}
+
+	if env.Name != "" {
+		err = runHook(create.logger, create.runner, create.gopath, create.gobopath, env, "post-deactivate", env.Name, name)
+		if err != nil {
+			create.logger.Error(err.Error())
+		}
+	}
+
+	return nil
 }
@@ -42,14 +42,17 @@ func GetDeleteCommand(
 // Run deletes the virtual environment 'name'.
 func (delete *DeleteCommand) Run(name string) error {
 
-	env := delete.configService.ReadEnvironment(delete.gopath + "gobo.toml")
+	env, err := delete.configService.ReadEnvironment(delete.gopath + "gobo.toml")
+	if err != nil && !utils.IsConfigNotFound(err) {
+		return errors.New("unable to read the active environment: " + err.Error())
+	}
 
-	if env.Name == name {
+	if err == nil && env.Name == name {
 		return errors.New("You may not delete the active environment.")
 	}
 
 	delete.logger.Info("Removing virtual environment " + name + " at " + delete.gobopath + name)
-	err := delete.moveService.RemoveDirectory(delete.gobopath + name)
+	err = delete.moveService.RemoveDirectory(delete.gobopath + name)
 
 	return err
 }
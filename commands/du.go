@@ -0,0 +1,219 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// IDuCommand is the interface to implement for reporting the disk space used by environments.
+type IDuCommand interface {
+	Run(name string) error
+}
+
+// DuCommand is the struct for this implementation of IDuCommand.
+type DuCommand struct {
+	logger           utils.ILogger
+	configService    utils.IConfigService
+	diskUsageService utils.IDiskUsageService
+	fileSystem       utils.IFileSystem
+	sortBy           string
+	asJSON           bool
+	top              int
+	gopath           string
+	gobopath         string
+}
+
+// GetDuCommand returns a pointer to an implementation of IDuCommand. Environments are sorted by sortBy, either
+// "size" or "name", and top limits how many of the largest packages are shown per environment.
+func GetDuCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	diskUsageService utils.IDiskUsageService,
+	fileSystem utils.IFileSystem,
+	sortBy string,
+	asJSON bool,
+	top int,
+	gopath string,
+	gobopath string,
+) *DuCommand {
+	var du = DuCommand{
+		logger,
+		configService,
+		diskUsageService,
+		fileSystem,
+		sortBy,
+		asJSON,
+		top,
+		gopath,
+		gobopath,
+	}
+
+	return &du
+}
+
+// Run reports the disk space used by the environment name, or by every environment when name is empty.
+// Environments are measured in parallel.
+func (du *DuCommand) Run(name string) error {
+
+	if du.sortBy != "size" && du.sortBy != "name" {
+		return errors.New("unknown sort order " + du.sortBy + ", expected size or name")
+	}
+
+	names, err := du.environments(name)
+	if err != nil {
+		return err
+	}
+
+	usages := make([]models.EnvironmentUsage, len(names))
+	errs := make([]error, len(names))
+
+	var wait sync.WaitGroup
+	for i, env := range names {
+		wait.Add(1)
+		go func(i int, env string) {
+			defer wait.Done()
+			usages[i], errs[i] = du.measure(env)
+		}(i, env)
+	}
+	wait.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		if du.sortBy == "name" || usages[i].Total == usages[j].Total {
+			return usages[i].Name < usages[j].Name
+		}
+		return usages[i].Total > usages[j].Total
+	})
+
+	if du.asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(usages)
+	}
+
+	du.print(usages)
+
+	return nil
+}
+
+// environments returns the names of the environments to measure.
+func (du *DuCommand) environments(name string) ([]string, error) {
+	if name != "" {
+		_, _, err := environmentRoot(du.configService, du.gopath, du.gobopath, name)
+		return []string{name}, err
+	}
+
+	var names []string
+
+	active, err := du.configService.ReadEnvironment(du.gopath + "gobo.toml")
+	if err == nil {
+		names = append(names, active.Name)
+	}
+
+	entries, _ := du.fileSystem.ReadDir(du.gobopath)
+	for _, entry := range entries {
+		if !entry.IsDir() || models.IsReserved(entry.Name()) || entry.Name() == active.Name {
+			continue
+		}
+
+		if _, err := du.fileSystem.Stat(du.gobopath + entry.Name() + "/gobo.toml"); err == nil {
+			names = append(names, entry.Name())
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, errors.New("there are no environments to report on")
+	}
+
+	return names, nil
+}
+
+// measure totals the GOPATH directories of the environment name and the largest packages it locks or lists.
+func (du *DuCommand) measure(name string) (models.EnvironmentUsage, error) {
+	usage := models.EnvironmentUsage{Name: name}
+
+	root, active, err := environmentRoot(du.configService, du.gopath, du.gobopath, name)
+	if err != nil {
+		return usage, err
+	}
+	usage.Active = active
+
+	sizes := map[string]*int64{"src": &usage.Src, "pkg": &usage.Pkg, "bin": &usage.Bin}
+	for _, dir := range models.GOPATHDIRECTORIES {
+		dirUsage, err := du.diskUsageService.Usage(root + dir)
+		if err != nil {
+			return usage, errors.New("unable to measure " + root + dir + ": " + err.Error())
+		}
+
+		*sizes[dir] = dirUsage.Bytes
+		usage.Total += dirUsage.Bytes
+	}
+
+	for _, pak := range environmentPackages(du.logger, du.configService, root, name) {
+		pkgUsage, err := du.diskUsageService.Usage(root + "src/" + pak.Path)
+		if err != nil {
+			du.logger.Warn("Unable to measure package " + pak.Path + ": " + err.Error())
+			continue
+		}
+
+		usage.Packages = append(usage.Packages, models.PackageUsage{Path: pak.Path, Bytes: pkgUsage.Bytes})
+	}
+
+	sort.SliceStable(usage.Packages, func(i, j int) bool {
+		if usage.Packages[i].Bytes == usage.Packages[j].Bytes {
+			return usage.Packages[i].Path < usage.Packages[j].Path
+		}
+		return usage.Packages[i].Bytes > usage.Packages[j].Bytes
+	})
+
+	if du.top >= 0 && len(usage.Packages) > du.top {
+		usage.Packages = usage.Packages[:du.top]
+	}
+
+	return usage, nil
+}
+
+// print writes the report as a table.
+func (du *DuCommand) print(usages []models.EnvironmentUsage) {
+	var total int64
+
+	fmt.Printf("%-20s %10s %10s %10s %10s\n", "ENVIRONMENT", "SRC", "PKG", "BIN", "TOTAL")
+
+	for _, usage := range usages {
+		name := usage.Name
+		if usage.Active {
+			name += " *"
+		}
+
+		fmt.Printf(
+			"%-20s %10s %10s %10s %10s\n",
+			name,
+			utils.FormatBytes(usage.Src),
+			utils.FormatBytes(usage.Pkg),
+			utils.FormatBytes(usage.Bin),
+			utils.FormatBytes(usage.Total),
+		)
+
+		for _, pkg := range usage.Packages {
+			fmt.Printf("    %-50s %10s\n", pkg.Path, utils.FormatBytes(pkg.Bytes))
+		}
+
+		total += usage.Total
+	}
+
+	if len(usages) > 1 {
+		fmt.Printf("%-20s %43s\n", "ALL", utils.FormatBytes(total))
+	}
+}
@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+func (f *fixture) du(sortBy string, top int) *DuCommand {
+	return GetDuCommand(
+		f.logger,
+		f.configService,
+		utils.GetDiskUsageService(f.fileSystem),
+		f.fileSystem,
+		sortBy,
+		false,
+		top,
+		testGopath,
+		testGobo,
+	)
+}
+
+func TestDuMeasuresEnvironments(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.write(testGopath+"src/example.com/big/main.go", "0123456789")
+	f.write(testGopath+"src/example.com/small/main.go", "01")
+	f.write(testGopath+"pkg/linux_amd64/example.com/big.a", "0123")
+	f.configService.WritePackages(testGopath+"packages.toml", models.Dependencies{
+		Package: []models.Package{{Path: "example.com/small"}, {Path: "example.com/big"}},
+	})
+
+	if err := f.create("web", false); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	du := f.du("size", 1)
+
+	usage, err := du.measure("dev")
+	if err != nil {
+		t.Fatalf("measure returned %v", err)
+	}
+
+	if usage.Active || usage.Src != 12 || usage.Pkg != 4 || usage.Total != 16+usage.Bin {
+		t.Errorf("unexpected usage for dev: %+v", usage)
+	}
+
+	if len(usage.Packages) != 1 || usage.Packages[0].Path != "example.com/big" {
+		t.Errorf("expected only the largest package, got %+v", usage.Packages)
+	}
+
+	if err := du.Run(""); err != nil {
+		t.Fatalf("du returned %v", err)
+	}
+
+	if err := du.Run("missing"); err == nil {
+		t.Error("du accepted an unknown environment")
+	}
+
+	if err := f.du("colour", 1).Run(""); err == nil {
+		t.Error("du accepted an unknown sort order")
+	}
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// environmentRoot returns the directory holding the GOPATH directories and toml files of the environment name,
+// and whether it is the active environment, whose files live in the GOPATH rather than the gobo home.
+func environmentRoot(
+	configService utils.IConfigService,
+	gopath string,
+	gobopath string,
+	name string,
+) (string, bool, error) {
+
+	env, err := configService.ReadEnvironment(gopath + "gobo.toml")
+	if err != nil && !utils.IsConfigNotFound(err) {
+		return "", false, errors.New("unable to read the active environment: " + err.Error())
+	}
+
+	if err == nil && env.Name == name {
+		return gopath, true, nil
+	}
+
+	root := gobopath + name + "/"
+
+	_, err = configService.ReadEnvironment(root + "gobo.toml")
+	if utils.IsConfigNotFound(err) {
+		return "", false, errors.New(name + " is not a named environment.")
+	}
+	if err != nil {
+		return "", false, errors.New("unable to read environment " + name + ": " + err.Error())
+	}
+
+	return root, false, nil
+}
+
+// environmentPackages returns the packages of the environment name whose files are in root: every package of its
+// lock, then those of its manifest which are not locked. Unreadable files are warned about and skipped.
+func environmentPackages(logger utils.ILogger, configService utils.IConfigService, root string, name string) []models.LockedPackage {
+	var packages []models.LockedPackage
+	seen := map[string]bool{}
+
+	// the lock has every installed package, the manifest may name some which are not installed as repositories
+	lock, err := configService.ReadLock(root + "packages.lock")
+	for _, pak := range lock.Package {
+		packages = append(packages, pak)
+		seen[pak.Path] = true
+	}
+	if err != nil && !utils.IsConfigNotFound(err) {
+		logger.Warn("Unable to read the package lock of " + name + ": " + err.Error())
+	}
+
+	deps, err := configService.ReadPackages(root + "packages.toml")
+	for _, pak := range deps.Package {
+		if !seen[pak.Path] {
+			packages = append(packages, models.LockedPackage{
+				Path:     pak.Path,
+				Origin:   pak.Origin,
+				Revision: pak.Revision,
+				Version:  pak.Version,
+				Branch:   pak.Branch,
+			})
+			seen[pak.Path] = true
+		}
+	}
+	if err != nil && !utils.IsConfigNotFound(err) {
+		logger.Warn("Unable to read the packages of " + name + ": " + err.Error())
+	}
+
+	return packages
+}
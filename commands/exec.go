@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// IExecCommand is the interface to implement for running a command inside an environment without activating it.
+type IExecCommand interface {
+	Run(name string, command []string) (int, error)
+}
+
+// ExecCommand is the struct for this implementation of IExecCommand.
+type ExecCommand struct {
+	logger           utils.ILogger
+	configService    utils.IConfigService
+	toolchainService utils.IToolchainService
+	runner           utils.ICommandRunner
+	gopath           string
+	gobopath         string
+}
+
+// GetExecCommand returns a pointer to an implementation of IExecCommand.
+func GetExecCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	toolchainService utils.IToolchainService,
+	runner utils.ICommandRunner,
+	gopath string,
+	gobopath string,
+) *ExecCommand {
+	var execCommand = ExecCommand{
+		logger,
+		configService,
+		toolchainService,
+		runner,
+		gopath,
+		gobopath,
+	}
+
+	return &execCommand
+}
+
+// Run runs command with GOPATH, PATH and the variables of the environment name pointing at where the environment
+// is stored, and returns the command's exit code. The active environment is left untouched.
+func (execCommand *ExecCommand) Run(name string, command []string) (int, error) {
+
+	if len(command) > 0 && command[0] == "--" {
+		command = command[1:]
+	}
+
+	if name == "" || len(command) == 0 {
+		return 1, errors.New("usage: gobo exec <name> -- <command> [args...]")
+	}
+
+	root, env, goroot, err := loadEnvironment(
+		execCommand.configService,
+		execCommand.toolchainService,
+		execCommand.gopath,
+		execCommand.gobopath,
+		name,
+	)
+	if err != nil {
+		return 1, err
+	}
+
+	execCommand.logger.Info("Running " + strings.Join(command, " ") + " in " + name)
+
+	return execCommand.runner.Exec("", runEnvironment(root, env, goroot), command[0], command[1:]...)
+}
+
+// loadEnvironment returns where the environment name is stored, its settings and the GOROOT of its toolchain.
+func loadEnvironment(
+	configService utils.IConfigService,
+	toolchainService utils.IToolchainService,
+	gopath string,
+	gobopath string,
+	name string,
+) (string, models.Environment, string, error) {
+
+	root, _, err := environmentRoot(configService, gopath, gobopath, name)
+	if err != nil {
+		return "", models.Environment{}, "", err
+	}
+
+	env, err := configService.ReadEnvironment(root + "gobo.toml")
+	if err != nil {
+		return "", env, "", err
+	}
+
+	goroot, err := resolveToolchain(toolchainService, env)
+	if err != nil {
+		return "", env, "", errors.New("unable to select the toolchain of " + name + ": " + err.Error())
+	}
+
+	return root, env, goroot, nil
+}
+
+// runEnvironment returns the variables which point a process at the environment stored in root.
+func runEnvironment(root string, env models.Environment, goroot string) []string {
+	gopath := strings.TrimSuffix(root, "/")
+
+	path := []string{gopath + "/bin"}
+	if goroot != "" {
+		path = append([]string{goroot + "/bin"}, path...)
+	}
+	if current := os.Getenv("PATH"); current != "" {
+		path = append(path, current)
+	}
+
+	variables := append(
+		utils.EnvironmentVariables(env),
+		"GOBO_ENV="+env.Name,
+		"GOPATH="+gopath,
+		"PATH="+strings.Join(path, string(os.PathListSeparator)),
+	)
+
+	if goroot != "" {
+		variables = append(variables, "GOROOT="+goroot)
+	}
+
+	return variables
+}
@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+func TestExecRunsInStoredEnvironment(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	if err := f.setenv("dev", false, "CGO_ENABLED=0"); err != nil {
+		t.Fatalf("setenv returned %v", err)
+	}
+	if err := f.create("web", false); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	f.runner.Script(utils.FakeCommand{Command: "go test ./...", ExitCode: 3})
+
+	execCommand := GetExecCommand(f.logger, f.configService, f.toolchainService, f.runner, testGopath, testGobo)
+
+	code, err := execCommand.Run("dev", []string{"--", "go", "test", "./..."})
+	if err != nil {
+		t.Fatalf("exec returned %v", err)
+	}
+	if code != 3 {
+		t.Errorf("expected the child's exit code 3, got %d", code)
+	}
+
+	calls := f.runner.Calls()
+	env := strings.Join(calls[len(calls)-1].Env, "\n")
+	for _, want := range []string{"GOPATH=/home/gopher/.gobo/dev", "PATH=/home/gopher/.gobo/dev/bin", "CGO_ENABLED=0"} {
+		if !strings.Contains(env, want) {
+			t.Errorf("exec ran without %s:\n%s", want, env)
+		}
+	}
+
+	if f.activeName() != "web" {
+		t.Error("exec changed the active environment")
+	}
+
+	if _, err := execCommand.Run("missing", []string{"go", "version"}); err == nil {
+		t.Error("exec accepted an unknown environment")
+	}
+}
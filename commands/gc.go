@@ -0,0 +1,277 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// IGcCommand is the interface to implement for reclaiming space from stale environment data.
+type IGcCommand interface {
+	Run() error
+}
+
+// GcCommand is the struct for this implementation of IGcCommand.
+type GcCommand struct {
+	logger           utils.ILogger
+	configService    utils.IConfigService
+	diskUsageService utils.IDiskUsageService
+	fileSystem       utils.IFileSystem
+	promptService    utils.IPromptService
+	yes              bool
+	binaries         bool
+	gopath           string
+	gobopath         string
+}
+
+// garbage is a category of stale data found by gc and the paths that belong to it.
+type garbage struct {
+	category string
+	paths    []string
+}
+
+// GetGcCommand returns a pointer to an implementation of IGcCommand. With yes set nothing is asked before deleting.
+// Binaries no package is named after are only listed unless binaries is set, gobo can't tell a binary it built
+// from one installed from a module or by hand.
+func GetGcCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	diskUsageService utils.IDiskUsageService,
+	fileSystem utils.IFileSystem,
+	promptService utils.IPromptService,
+	yes bool,
+	binaries bool,
+	gopath string,
+	gobopath string,
+) *GcCommand {
+	var gc = GcCommand{
+		logger,
+		configService,
+		diskUsageService,
+		fileSystem,
+		promptService,
+		yes,
+		binaries,
+		gopath,
+		gobopath,
+	}
+
+	return &gc
+}
+
+// Run finds orphaned environments, leftovers of failed moves, stale package archives and binaries whose source
+// is gone, reports the space each category uses and deletes them once confirmed. The binaries are kept unless
+// the command was told to delete them too.
+func (gc *GcCommand) Run() error {
+
+	active, err := gc.configService.ReadEnvironment(gc.gopath + "gobo.toml")
+	if err != nil && !utils.IsConfigNotFound(err) {
+		return errors.New("unable to read the active environment: " + err.Error())
+	}
+
+	orphans := garbage{category: "Orphaned environments"}
+	leftovers := garbage{category: "Leftovers of failed moves"}
+	archives := garbage{category: "Package archives without source"}
+	binaries := garbage{category: "Binaries without source"}
+
+	var roots []string
+	if err == nil {
+		roots = append(roots, gc.gopath)
+	}
+
+	entries, _ := gc.fileSystem.ReadDir(gc.gobopath)
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if isLeftover(name) {
+			leftovers.paths = append(leftovers.paths, gc.gobopath+name)
+			continue
+		}
+
+		// the active environment's directory stays empty while its files live in the GOPATH
+		if !entry.IsDir() || models.IsReserved(name) || name == active.Name {
+			continue
+		}
+
+		root := gc.gobopath + name + "/"
+		if _, err := gc.fileSystem.Stat(root + "gobo.toml"); err != nil {
+			orphans.paths = append(orphans.paths, gc.gobopath+name)
+			continue
+		}
+
+		roots = append(roots, root)
+	}
+
+	for _, root := range roots {
+		archives.paths = append(archives.paths, gc.staleArchives(root)...)
+		binaries.paths = append(binaries.paths, gc.staleBinaries(root)...)
+	}
+
+	categories := []garbage{orphans, leftovers, archives}
+	if gc.binaries {
+		categories = append(categories, binaries)
+	} else if len(binaries.paths) > 0 {
+		fmt.Printf("Binaries without source, kept as they may come from modules or elsewhere, -binaries deletes them:\n    %s\n\n",
+			strings.Join(binaries.paths, "\n    "))
+	}
+
+	var total utils.DiskUsage
+	count := 0
+	for _, category := range categories {
+		usage := reportGarbage(gc.logger, gc.diskUsageService, category)
+		total.Files += usage.Files
+		total.Bytes += usage.Bytes
+		count += len(category.paths)
+	}
+
+	if count == 0 {
+		fmt.Println("Nothing to clean up.")
+		return nil
+	}
+
+	fmt.Printf("Total: %s\n\n", total)
+
+	if !gc.yes {
+		answer := gc.promptService.Ask("Delete everything listed above? (yes/no): ")
+		if answer != "yes" && answer != "YES" {
+			fmt.Println("Nothing was deleted.")
+			return nil
+		}
+	}
+
+	for _, category := range categories {
+		for _, path := range category.paths {
+			gc.logger.Info("Removing " + path)
+
+			err := gc.fileSystem.RemoveAll(path)
+			if err != nil {
+				return errors.New("GC - Error removing " + path + ": " + err.Error())
+			}
+		}
+	}
+
+	fmt.Printf("Reclaimed %s.\n", utils.FormatBytes(total.Bytes))
+
+	return nil
+}
+
+// reportGarbage prints the paths of a category with their size and returns the category's total usage.
+func reportGarbage(logger utils.ILogger, diskUsageService utils.IDiskUsageService, category garbage) utils.DiskUsage {
+	var total utils.DiskUsage
+
+	if len(category.paths) == 0 {
+		return total
+	}
+
+	var lines []string
+	for _, path := range category.paths {
+		usage, err := diskUsageService.Usage(path)
+		if err != nil {
+			logger.Warn("Unable to measure " + path + ": " + err.Error())
+		}
+
+		total.Files += usage.Files
+		total.Bytes += usage.Bytes
+		lines = append(lines, fmt.Sprintf("    %-10s %s", utils.FormatBytes(usage.Bytes), path))
+	}
+
+	fmt.Printf("%s (%s):\n%s\n\n", category.category, total, strings.Join(lines, "\n"))
+
+	return total
+}
+
+// staleArchives returns the compiled package archives below root's pkg directory whose package is no
+// longer in src. The module cache is left alone.
+func (gc *GcCommand) staleArchives(root string) []string {
+	var stale []string
+
+	pkg := root + "pkg"
+
+	gc.fileSystem.Walk(pkg, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(pkg, path)
+		parts := strings.SplitN(filepath.ToSlash(rel), "/", 2)
+
+		if info.IsDir() {
+			if len(parts) == 1 && (parts[0] == "mod" || parts[0] == "dep" || parts[0] == "sumdb") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// archives live in pkg/<os>_<arch>/<import path>.a
+		if len(parts) < 2 || !strings.HasSuffix(path, ".a") {
+			return nil
+		}
+
+		source := root + "src/" + strings.TrimSuffix(parts[1], ".a")
+		if _, err := gc.fileSystem.Stat(source); err != nil {
+			stale = append(stale, path)
+		}
+
+		return nil
+	})
+
+	return stale
+}
+
+// staleBinaries returns the binaries in root's bin directory that no package in src is named after.
+// The gobo binary itself is always kept.
+func (gc *GcCommand) staleBinaries(root string) []string {
+	var stale []string
+
+	bins, err := gc.fileSystem.ReadDir(root + "bin")
+	if err != nil || len(bins) == 0 {
+		return stale
+	}
+
+	names := map[string]bool{"gobo": true}
+
+	src := root + "src"
+	gc.fileSystem.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.IsDir() && path != src {
+			names[info.Name()] = true
+		}
+		return nil
+	})
+
+	for _, bin := range bins {
+		// cross compiled binaries live in subdirectories such as bin/linux_arm
+		if bin.IsDir() {
+			continue
+		}
+
+		if !names[strings.TrimSuffix(bin.Name(), ".exe")] {
+			stale = append(stale, root+"bin/"+bin.Name())
+		}
+	}
+
+	sort.Strings(stale)
+
+	return stale
+}
+
+// isLeftover reports whether name at the top of the gobo home is a GOPATH directory or environment file that
+// a failed move left behind instead of inside an environment.
+func isLeftover(name string) bool {
+	if isGopathDirectory(name) {
+		return true
+	}
+
+	for _, file := range models.ENVIRONMENTFILES {
+		if name == file {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+func (f *fixture) gc(yes bool, binaries bool, answers ...string) error {
+	gc := GetGcCommand(
+		f.logger,
+		f.configService,
+		utils.GetDiskUsageService(f.fileSystem),
+		f.fileSystem,
+		f.prompt(answers...),
+		yes,
+		binaries,
+		testGopath,
+		testGobo,
+	)
+
+	return gc.Run()
+}
+
+func TestGcRemovesStaleData(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	if err := f.create("web", false); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	// dev is inactive now, its files live in the gobo home
+	f.write(testGobo+"dev/src/example.com/original/main.go", "package main\n")
+	f.write(testGobo+"dev/pkg/linux_amd64/example.com/gone.a", "archive")
+	f.write(testGobo+"dev/pkg/linux_amd64/example.com/original.a", "archive")
+	f.write(testGobo+"dev/pkg/mod/example.com/cached@v1.0.0/go.mod", "module example.com/cached")
+	f.write(testGobo+"dev/bin/gone", "binary")
+	f.write(testGobo+"dev/bin/original", "binary")
+	f.write(testGopath+"bin/stale", "binary")
+	f.write(testGobo+"broken/src/example.com/lost/main.go", "package main\n")
+	f.write(testGobo+"packages.toml", "")
+
+	if err := f.gc(false, false, "no"); err != nil {
+		t.Fatalf("gc returned %v", err)
+	}
+	if !f.exists(testGobo + "broken") {
+		t.Fatal("gc deleted something without confirmation")
+	}
+
+	if err := f.gc(true, false); err != nil {
+		t.Fatalf("gc returned %v", err)
+	}
+	if !f.exists(testGobo+"dev/bin/gone") || !f.exists(testGopath+"bin/stale") {
+		t.Fatal("gc deleted binaries without -binaries")
+	}
+
+	if err := f.gc(true, true); err != nil {
+		t.Fatalf("gc returned %v", err)
+	}
+
+	for _, gone := range []string{
+		testGobo + "dev/pkg/linux_amd64/example.com/gone.a",
+		testGobo + "dev/bin/gone",
+		testGopath + "bin/stale",
+		testGobo + "broken",
+		testGobo + "packages.toml",
+	} {
+		if f.exists(gone) {
+			t.Errorf("gc left %s behind", gone)
+		}
+	}
+
+	for _, kept := range []string{
+		testGobo + "dev/pkg/linux_amd64/example.com/original.a",
+		testGobo + "dev/pkg/mod/example.com/cached@v1.0.0/go.mod",
+		testGobo + "dev/bin/original",
+		testGobo + "dev/gobo.toml",
+		testGobo + "web",
+		testGopath + "bin/gobo",
+		testGobo + "initial/manifest.toml",
+	} {
+		if !f.exists(kept) {
+			t.Errorf("gc removed %s", kept)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// IGraphCommand is the interface to implement for exporting the import graph of an environment.
+type IGraphCommand interface {
+	Run() error
+}
+
+// GraphCommand is the struct for this implementation of IGraphCommand.
+type GraphCommand struct {
+	logger         utils.ILogger
+	configService  utils.IConfigService
+	packageService utils.IPackageService
+	importService  utils.IImportService
+	format         string
+	root           string
+	packages       bool
+	standard       bool
+	gopath         string
+}
+
+// GetGraphCommand returns a pointer to an implementation of IGraphCommand. format is dot, json or mermaid, a non
+// empty root limits the graph to what the packages under it import. Subpackages are collapsed into their
+// repositories unless packages is set, and the standard library is left out unless standard is set.
+func GetGraphCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	packageService utils.IPackageService,
+	importService utils.IImportService,
+	format string,
+	root string,
+	packages bool,
+	standard bool,
+	gopath string,
+) *GraphCommand {
+	var graph = GraphCommand{
+		logger,
+		configService,
+		packageService,
+		importService,
+		format,
+		root,
+		packages,
+		standard,
+		gopath,
+	}
+
+	return &graph
+}
+
+// Run prints the import graph of the active environment in the chosen format, its repositories annotated with
+// the revisions of packages.lock, or of packages.toml when there is no lock.
+func (graphCommand *GraphCommand) Run() error {
+
+	if graphCommand.format != "dot" && graphCommand.format != "json" && graphCommand.format != "mermaid" {
+		return errors.New("unknown format " + graphCommand.format + ", expected dot, json or mermaid")
+	}
+
+	env, err := graphCommand.configService.ReadEnvironment(graphCommand.gopath + "gobo.toml")
+	if err != nil {
+		return err
+	}
+
+	repositories := graphCommand.packageService.Repositories()
+
+	imports, err := graphCommand.importService.Graph(repositories)
+	if err != nil {
+		return errors.New("unable to read the imports of the environment: " + err.Error())
+	}
+
+	locked, err := graphCommand.lockedRevisions()
+	if err != nil {
+		return err
+	}
+
+	graph, err := dependencyGraph(imports, locked, graphCommand.root, graphCommand.packages, graphCommand.standard)
+	if err != nil {
+		return err
+	}
+	graph.Environment = env.Name
+
+	switch graphCommand.format {
+	case "json":
+		data, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case "mermaid":
+		fmt.Print(renderMermaid(graph))
+	default:
+		fmt.Print(renderDot(graph))
+	}
+
+	return nil
+}
+
+// lockedRevisions returns the entries of packages.lock by path, or those of packages.toml when there is no lock.
+func (graphCommand *GraphCommand) lockedRevisions() (map[string]models.LockedPackage, error) {
+	locked := map[string]models.LockedPackage{}
+
+	lock, err := graphCommand.configService.ReadLock(graphCommand.gopath + "packages.lock")
+	if err == nil {
+		for _, pak := range lock.Package {
+			locked[pak.Path] = pak
+		}
+
+		return locked, nil
+	}
+	if !utils.IsConfigNotFound(err) {
+		return nil, err
+	}
+
+	manifest, err := graphCommand.configService.ReadPackages(graphCommand.gopath + "packages.toml")
+	if err != nil && !utils.IsConfigNotFound(err) {
+		return nil, err
+	}
+
+	for _, pak := range manifest.Package {
+		locked[pak.Path] = models.LockedPackage{Path: pak.Path, Revision: pak.Revision, Version: pak.Version, Branch: pak.Branch}
+	}
+
+	return locked, nil
+}
+
+// dependencyGraph turns the package import graph into the graph gobo graph exports. Only what the packages under
+// root import is kept when root is set, subpackages are collapsed into their repositories unless packages is set
+// and the standard library is dropped unless standard is set.
+func dependencyGraph(
+	imports models.ImportGraph,
+	locked map[string]models.LockedPackage,
+	root string,
+	packages bool,
+	standard bool,
+) (models.DependencyGraph, error) {
+	var graph models.DependencyGraph
+
+	edges := map[string]map[string]bool{}
+	for pkg, imported := range imports.Imports {
+		edges[pkg] = map[string]bool{}
+
+		for _, path := range imported {
+			if _, found := imports.Imports[path]; found || (standard && utils.IsStandard(path)) {
+				edges[pkg][path] = true
+			}
+		}
+	}
+
+	if root != "" {
+		root = strings.TrimSuffix(root, "/")
+
+		var starts []string
+		for pkg := range imports.Imports {
+			if utils.Containing(pkg, []string{root}) != "" {
+				starts = append(starts, pkg)
+			}
+		}
+		if len(starts) == 0 {
+			return graph, errors.New("no package in the environment is at or below the root " + root)
+		}
+
+		edges = reachableEdges(edges, starts)
+	}
+
+	node := func(pkg string) string {
+		if packages {
+			return pkg
+		}
+
+		return imports.Node(pkg)
+	}
+
+	// nodes maps every node to the repository holding it, "" for code outside any repository
+	nodes := map[string]string{}
+	collapsed := map[models.GraphEdge]bool{}
+	for pkg, targets := range edges {
+		from := node(pkg)
+		nodes[from] = imports.Repositories[pkg]
+
+		for path := range targets {
+			to := node(path)
+			nodes[to] = imports.Repositories[path]
+
+			if to != from {
+				collapsed[models.GraphEdge{From: from, To: to}] = true
+			}
+		}
+	}
+
+	var ids []string
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		_, inGopath := imports.Imports[id]
+		graphNode := models.GraphNode{ID: id, Repository: nodes[id]}
+		graphNode.Standard = graphNode.Repository == "" && !inGopath && utils.IsStandard(id)
+
+		if pak, ok := locked[graphNode.Repository]; ok {
+			graphNode.Revision = pak.Revision
+			graphNode.Version = pak.Version
+			graphNode.Branch = pak.Branch
+		}
+
+		graph.Nodes = append(graph.Nodes, graphNode)
+	}
+
+	for edge := range collapsed {
+		graph.Edges = append(graph.Edges, edge)
+	}
+	sort.Slice(graph.Edges, func(i int, j int) bool {
+		if graph.Edges[i].From != graph.Edges[j].From {
+			return graph.Edges[i].From < graph.Edges[j].From
+		}
+		return graph.Edges[i].To < graph.Edges[j].To
+	})
+
+	return graph, nil
+}
+
+// reachableEdges keeps the packages of edges reachable from starts and the imports between them.
+func reachableEdges(edges map[string]map[string]bool, starts []string) map[string]map[string]bool {
+	kept := map[string]map[string]bool{}
+	queue := starts
+
+	for _, start := range starts {
+		kept[start] = edges[start]
+	}
+
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+
+		for path := range edges[pkg] {
+			if _, seen := kept[path]; !seen {
+				kept[path] = edges[path]
+				queue = append(queue, path)
+			}
+		}
+	}
+
+	return kept
+}
+
+// label is the text shown for a node, its id and the revision it is locked at.
+func label(node models.GraphNode) string {
+	if node.Revision == "" {
+		return node.ID
+	}
+
+	return node.ID + "\n" + node.Revision
+}
+
+// renderDot formats graph for Graphviz.
+func renderDot(graph models.DependencyGraph) string {
+	var out strings.Builder
+
+	out.WriteString("digraph " + strconv.Quote(graph.Environment) + " {\n")
+	out.WriteString("    rankdir=LR;\n    node [shape=box];\n")
+
+	for _, node := range graph.Nodes {
+		attributes := "label=" + strconv.Quote(label(node))
+		if node.Standard {
+			attributes += ", style=dashed"
+		}
+		out.WriteString("    " + strconv.Quote(node.ID) + " [" + attributes + "];\n")
+	}
+
+	for _, edge := range graph.Edges {
+		out.WriteString("    " + strconv.Quote(edge.From) + " -> " + strconv.Quote(edge.To) + ";\n")
+	}
+
+	out.WriteString("}\n")
+
+	return out.String()
+}
+
+// renderMermaid formats graph as a Mermaid flowchart. Mermaid ids can't hold import paths, so nodes are numbered.
+func renderMermaid(graph models.DependencyGraph) string {
+	var out strings.Builder
+	ids := map[string]string{}
+
+	out.WriteString("graph LR\n")
+
+	for i, node := range graph.Nodes {
+		ids[node.ID] = "n" + strconv.Itoa(i)
+
+		text := strings.Replace(label(node), "\"", "#quot;", -1)
+		text = strings.Replace(text, "\n", "<br/>", -1)
+		out.WriteString("    " + ids[node.ID] + "[\"" + text + "\"]\n")
+	}
+
+	for _, edge := range graph.Edges {
+		out.WriteString("    " + ids[edge.From] + " --> " + ids[edge.To] + "\n")
+	}
+
+	return out.String()
+}
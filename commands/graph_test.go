@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+func testImportGraph() models.ImportGraph {
+	return models.ImportGraph{
+		Imports: map[string][]string{
+			"example.com/me/app":  {"example.com/lib/a", "fmt"},
+			"example.com/lib/a":   {"example.com/lib/b", "strings"},
+			"example.com/lib/b":   {"example.com/base"},
+			"example.com/base":    nil,
+			"example.com/unused":  {"example.com/base"},
+			"example.com/me/tool": {"os"},
+		},
+		Repositories: map[string]string{
+			"example.com/lib/a":  "example.com/lib",
+			"example.com/lib/b":  "example.com/lib",
+			"example.com/base":   "example.com/base",
+			"example.com/unused": "example.com/unused",
+		},
+	}
+}
+
+func TestGraphCollapsesRepositories(t *testing.T) {
+	locked := map[string]models.LockedPackage{
+		"example.com/lib":  {Path: "example.com/lib", Revision: "v1.2.0", Version: "^1.2"},
+		"example.com/base": {Path: "example.com/base", Revision: "abc123"},
+	}
+
+	graph, err := dependencyGraph(testImportGraph(), locked, "example.com/me/app", false, false)
+	if err != nil {
+		t.Fatalf("dependencyGraph returned %v", err)
+	}
+
+	expected := []models.GraphEdge{
+		{From: "example.com/lib", To: "example.com/base"},
+		{From: "example.com/me/app", To: "example.com/lib"},
+	}
+	if !reflect.DeepEqual(graph.Edges, expected) {
+		t.Errorf("expected edges %v, got %v", expected, graph.Edges)
+	}
+
+	if len(graph.Nodes) != 3 || graph.Nodes[1].ID != "example.com/lib" || graph.Nodes[1].Revision != "v1.2.0" {
+		t.Errorf("expected the root's repositories annotated with their revisions, got %+v", graph.Nodes)
+	}
+
+	graph, err = dependencyGraph(testImportGraph(), locked, "", true, true)
+	if err != nil {
+		t.Fatalf("dependencyGraph returned %v", err)
+	}
+
+	var standard []string
+	for _, node := range graph.Nodes {
+		if node.Standard {
+			standard = append(standard, node.ID)
+		}
+		if node.ID == "example.com/lib/b" && node.Revision != "v1.2.0" {
+			t.Errorf("expected a package to carry its repository's revision, got %+v", node)
+		}
+	}
+	if !reflect.DeepEqual(standard, []string{"fmt", "os", "strings"}) {
+		t.Errorf("expected the standard library packages with -std, got %v", standard)
+	}
+
+	if _, err := dependencyGraph(testImportGraph(), locked, "example.com/missing", false, false); err == nil {
+		t.Error("dependencyGraph accepted a root with no packages")
+	}
+}
+
+func TestGraphFormats(t *testing.T) {
+	graph, _ := dependencyGraph(testImportGraph(), map[string]models.LockedPackage{
+		"example.com/lib": {Path: "example.com/lib", Revision: "v1.2.0"},
+	}, "example.com/me/app", false, false)
+	graph.Environment = "dev"
+
+	dot := renderDot(graph)
+	for _, line := range []string{
+		`digraph "dev" {`,
+		`    "example.com/lib" [label="example.com/lib\nv1.2.0"];`,
+		`    "example.com/me/app" -> "example.com/lib";`,
+	} {
+		if !strings.Contains(dot, line+"\n") {
+			t.Errorf("dot output is missing %q:\n%s", line, dot)
+		}
+	}
+
+	mermaid := renderMermaid(graph)
+	for _, line := range []string{
+		"graph LR",
+		`    n1["example.com/lib<br/>v1.2.0"]`,
+		"    n2 --> n1",
+	} {
+		if !strings.Contains(mermaid, line+"\n") {
+			t.Errorf("mermaid output is missing %q:\n%s", line, mermaid)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// hooksPath returns the directory holding the hook scripts of the environment name.
+func hooksPath(gobopath string, name string) string {
+	return gobopath + name + "/hooks/"
+}
+
+// runHook runs the script env lists for hook, if any. The script sees the environment's variables plus
+// GOBO_HOOK, GOBO_OLD_ENV, GOBO_NEW_ENV and GOPATH, runs in the GOPATH with gobo's stdin, stdout and stderr,
+// and fails when it exits with a non-zero status.
+func runHook(
+	logger utils.ILogger,
+	runner utils.ICommandRunner,
+	gopath string,
+	gobopath string,
+	env models.Environment,
+	hook string,
+	oldEnv string,
+	newEnv string,
+) error {
+
+	script, ok := env.Hooks[hook]
+	if !ok {
+		return nil
+	}
+
+	path := hooksPath(gobopath, env.Name) + script
+
+	variables := append(
+		utils.EnvironmentVariables(env),
+		"GOBO_HOOK="+hook,
+		"GOBO_OLD_ENV="+oldEnv,
+		"GOBO_NEW_ENV="+newEnv,
+		"GOPATH="+strings.TrimSuffix(gopath, "/"),
+	)
+
+	logger.Info("Running the " + hook + " hook of " + env.Name + ": " + path)
+
+	// the hook shares gobo's terminal, so its output shows as it runs and a daemon it starts can't hold gobo up
+	code, err := runner.Exec(gopath, variables, path)
+	if err != nil {
+		return errors.New("the " + hook + " hook of " + env.Name + " failed: " + err.Error())
+	}
+	if code != 0 {
+		return errors.New("the " + hook + " hook of " + env.Name + " failed with exit status " + strconv.Itoa(code))
+	}
+
+	return nil
+}
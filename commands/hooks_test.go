@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/camronlevanger/gobo/models"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// addHook lists script as the hook of the inactive environment name and creates it in the hooks directory.
+func (f *fixture) addHook(name string, hook string, script string) {
+	path := testGobo + name + "/gobo.toml"
+
+	env, err := f.configService.ReadEnvironment(path)
+	if err != nil {
+		f.t.Fatalf("reading %s: %v", path, err)
+	}
+
+	if env.Hooks == nil {
+		env.Hooks = map[string]string{}
+	}
+	env.Hooks[hook] = script
+
+	if err := f.configService.WriteEnvironment(path, env); err != nil {
+		f.t.Fatalf("writing %s: %v", path, err)
+	}
+
+	f.write(hooksPath(testGobo, name)+script, "#!/bin/sh\n")
+}
+
+func TestFailingPreActivateHookAbortsSwitch(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	if err := f.create("web", false); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	f.addHook("dev", "pre-activate", "up.sh")
+	f.runner.Script(utils.FakeCommand{
+		Command:  hooksPath(testGobo, "dev") + "up.sh",
+		ExitCode: 1,
+	})
+
+	if err := f.activate("dev"); err == nil {
+		t.Fatal("activate ignored a failing pre-activate hook")
+	}
+
+	if f.activeName() != "web" {
+		t.Error("a failing pre-activate hook did not abort the switch")
+	}
+}
+
+func TestActivateRunsHooks(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	if err := f.create("web", false); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	f.addHook("dev", "post-activate", "up.sh")
+	f.runner.Script(utils.FakeCommand{Command: hooksPath(testGobo, "dev") + "up.sh"})
+
+	if err := f.activate("dev"); err != nil {
+		t.Fatalf("activate returned %v", err)
+	}
+
+	var env []string
+	for _, call := range f.runner.Calls() {
+		if call.Command == hooksPath(testGobo, "dev")+"up.sh" {
+			env = call.Env
+		}
+	}
+
+	want := map[string]bool{
+		"GOBO_HOOK=post-activate": true,
+		"GOBO_OLD_ENV=web":        true,
+		"GOBO_NEW_ENV=dev":        true,
+		"GOPATH=/home/gopher/go":  true,
+	}
+	for _, variable := range env {
+		delete(want, variable)
+	}
+
+	if len(want) > 0 {
+		t.Errorf("the hook ran with %v, missing %v", env, want)
+	}
+}
+
+func TestHookStartingDaemonDoesNotHang(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a shell script")
+	}
+
+	dir := t.TempDir()
+	gobopath := dir + "/gobo/"
+	pidFile := filepath.Join(dir, "daemon.pid")
+
+	// the daemon keeps the hook's stdout and stderr open long after the hook exits
+	script := "#!/bin/sh\necho starting the database\nsleep 30 &\necho $! > " + pidFile + "\n"
+	if err := os.MkdirAll(hooksPath(gobopath, "dev"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(hooksPath(gobopath, "dev")+"up.sh", []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.Create(filepath.Join(dir, "output"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = output, output
+	defer func() {
+		os.Stdout, os.Stderr = stdout, stderr
+		output.Close()
+
+		if pid, err := ioutil.ReadFile(pidFile); err == nil {
+			if n, err := strconv.Atoi(strings.TrimSpace(string(pid))); err == nil {
+				if daemon, err := os.FindProcess(n); err == nil {
+					daemon.Kill()
+				}
+			}
+		}
+	}()
+
+	logger, _ := utils.GetConfiguredLogger(utils.LogConfig{Level: utils.PANIC})
+	env := models.Environment{Name: "dev", Hooks: map[string]string{"post-activate": "up.sh"}}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runHook(logger, utils.GetCommandRunner(), dir+"/", gobopath, env, "post-activate", "web", "dev")
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("runHook returned %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("runHook waited for the daemon the hook started")
+	}
+
+	if data, _ := ioutil.ReadFile(filepath.Join(dir, "output")); !strings.Contains(string(data), "starting the database") {
+		t.Errorf("the hook's output did not reach gobo's stdout, got %q", data)
+	}
+}
@@ -1,6 +1,8 @@
 package commands
 
 import (
+	"strings"
+
 	"github.com/camronlevanger/gobo/models"
 	"github.com/camronlevanger/gobo/utils"
 )
@@ -12,13 +14,16 @@ type IInstallCommand interface {
 
 // InstallCommand is the struct for this implementation of IInstallCommand.
 type InstallCommand struct {
-	logger         utils.ILogger
-	configService  utils.IConfigService
-	packageService utils.IPackageService
-	copyService    utils.ICopyService
-	host           models.Host
-	gopath         string
-	gobopath       string
+	logger          utils.ILogger
+	configService   utils.IConfigService
+	packageService  utils.IPackageService
+	resolverService utils.IResolverService
+	importService   utils.IImportService
+	copyService     utils.ICopyService
+	promptService   utils.IPromptService
+	host            models.Host
+	gopath          string
+	gobopath        string
 }
 
 // GetInstallCommand returns a pointer to an implmentation of IInstallCommand.
@@ -26,7 +31,10 @@ func GetInstallCommand(
 	logger utils.ILogger,
 	configService utils.IConfigService,
 	packageService utils.IPackageService,
+	resolverService utils.IResolverService,
+	importService utils.IImportService,
 	copyService utils.ICopyService,
+	promptService utils.IPromptService,
 	host models.Host,
 	gopath string,
 	gobopath string,
@@ -35,7 +43,10 @@ func GetInstallCommand(
 		logger,
 		configService,
 		packageService,
+		resolverService,
+		importService,
 		copyService,
+		promptService,
 		host,
 		gopath,
 		gobopath,
@@ -44,20 +55,67 @@ func GetInstallCommand(
 	return &install
 }
 
-// Run loops through all packages in the install file and then runs get, checkout, install utils on them.
+// Run installs the packages of file, a packages.lock or a packages.toml manifest, defaulting to the lock of the
+// active environment and its manifest when there is no lock. A lock is reproduced exactly, packages in a manifest
+// with a version or branch requirement are checked out at the revision the resolver selects. Once every package
+// is checked out they are installed in import order, dependencies first.
 func (install *InstallCommand) Run(file string) error {
-	paks := install.configService.ReadPackages(file)
-
-	install.logger.Info("Installing packages from environment file: " + file)
+	packages, locked, err := install.read(file)
+	if err != nil {
+		return err
+	}
 
-	packages := paks.Package
-	var err error
+	env, err := install.configService.ReadEnvironment(install.gopath + "gobo.toml")
+	if err == nil {
+		install.packageService.SetEnvironment(utils.EnvironmentVariables(env))
+		install.packageService.CheckGoVersion(env.GoVersion)
+	}
 
+	downloaded := make([]bool, len(packages))
 	for i := 0; i < len(packages); i++ {
-		err = install.packageService.Get(packages[i].Path, packages[i].Revision)
+		err = install.packageService.Download(packages[i].Path)
 		if err != nil {
 			install.logger.Error("Error installing " + packages[i].Path + " because: " + err.Error())
 		}
+		downloaded[i] = err == nil
+	}
+
+	resolved := map[string]string{}
+	if !locked {
+		resolved, err = install.resolverService.Resolve(packages)
+		if err != nil {
+			return err
+		}
+	}
+
+	var paths []string
+	for i := 0; i < len(packages); i++ {
+		if !downloaded[i] {
+			continue
+		}
+
+		revision, ok := resolved[packages[i].Path]
+		if !ok {
+			revision = packages[i].Revision
+		}
+
+		// a manifest entry without a revision or requirement stays at whatever go get fetched
+		if revision != "" {
+			err = install.packageService.Checkout(packages[i].Path, revision)
+			if err != nil {
+				install.logger.Error("Error installing " + packages[i].Path + " because: " + err.Error())
+				continue
+			}
+		}
+
+		paths = append(paths, packages[i].Path)
+	}
+
+	for _, path := range install.order(paths) {
+		err = install.packageService.Install(path)
+		if err != nil {
+			install.logger.Error("Error installing " + path + " because: " + err.Error())
+		}
 	}
 
 	install.logger.Info("Saving the environment.")
@@ -66,6 +124,7 @@ func (install *InstallCommand) Run(file string) error {
 		install.configService,
 		install.packageService,
 		install.copyService,
+		install.promptService,
 		install.host,
 		install.gopath,
 		install.gobopath,
@@ -75,3 +134,63 @@ func (install *InstallCommand) Run(file string) error {
 
 	return err
 }
+
+// read returns the packages to install from file and whether it is a lock, files ending in .lock are locks.
+func (install *InstallCommand) read(file string) ([]models.Package, bool, error) {
+	if file == "" {
+		file = install.gopath + "packages.lock"
+		if _, err := install.configService.ReadLock(file); utils.IsConfigNotFound(err) {
+			file = install.gopath + "packages.toml"
+		}
+	}
+
+	install.logger.Info("Installing packages from: " + file)
+
+	if !strings.HasSuffix(file, ".lock") {
+		paks, err := install.configService.ReadPackages(file)
+
+		return paks.Package, false, err
+	}
+
+	lock, err := install.configService.ReadLock(file)
+	if err != nil {
+		return nil, true, err
+	}
+
+	var packages []models.Package
+	for _, pak := range lock.Package {
+		packages = append(packages, models.Package{Path: pak.Path, Origin: pak.Origin, Revision: pak.Revision})
+	}
+
+	return packages, true, nil
+}
+
+// order sorts the checked out packages at paths so each comes after the packages it imports.
+func (install *InstallCommand) order(paths []string) []string {
+	graph, err := install.importService.Graph(paths)
+	if err != nil {
+		install.logger.Warn("Unable to read the imports of the packages, installing them in file order: " + err.Error())
+		return paths
+	}
+
+	nodes := utils.NodeGraph(graph)
+	for _, path := range paths {
+		if _, ok := nodes[path]; !ok {
+			nodes[path] = nil
+		}
+	}
+
+	wanted := map[string]bool{}
+	for _, path := range paths {
+		wanted[path] = true
+	}
+
+	var ordered []string
+	for _, node := range utils.TopologicalOrder(nodes) {
+		if wanted[node] {
+			ordered = append(ordered, node)
+		}
+	}
+
+	return ordered
+}
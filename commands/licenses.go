@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// ILicensesCommand is the interface to implement for reporting the licenses of the packages of an environment.
+type ILicensesCommand interface {
+	Run(name string) error
+}
+
+// LicensesCommand is the struct for this implementation of ILicensesCommand.
+type LicensesCommand struct {
+	logger         utils.ILogger
+	configService  utils.IConfigService
+	licenseService utils.ILicenseService
+	deny           []string
+	asJSON         bool
+	gopath         string
+	gobopath       string
+}
+
+// GetLicensesCommand returns a pointer to an implementation of ILicensesCommand. deny lists the licenses, or
+// families of them such as GPL, which make the report fail.
+func GetLicensesCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	licenseService utils.ILicenseService,
+	deny []string,
+	asJSON bool,
+	gopath string,
+	gobopath string,
+) *LicensesCommand {
+	var licenses = LicensesCommand{
+		logger,
+		configService,
+		licenseService,
+		deny,
+		asJSON,
+		gopath,
+		gobopath,
+	}
+
+	return &licenses
+}
+
+// Run reports the licenses found in the repository of every package locked or listed by the environment name,
+// or the active environment when name is empty, and fails when one of them is denied.
+func (licenses *LicensesCommand) Run(name string) error {
+
+	if name == "" {
+		env, err := licenses.configService.ReadEnvironment(licenses.gopath + "gobo.toml")
+		if err != nil {
+			return err
+		}
+		name = env.Name
+	}
+
+	root, _, err := environmentRoot(licenses.configService, licenses.gopath, licenses.gobopath, name)
+	if err != nil {
+		return err
+	}
+
+	var report []models.PackageLicense
+	var denied []string
+
+	for _, pak := range environmentPackages(licenses.logger, licenses.configService, root, name) {
+		found, files, err := licenses.licenseService.Detect(root + "src/" + pak.Path)
+		if err != nil {
+			return errors.New("unable to read the licenses of " + pak.Path + ": " + err.Error())
+		}
+
+		license := models.PackageLicense{Path: pak.Path, Revision: pak.Revision, Licenses: found, Files: files}
+		for _, id := range found {
+			if utils.LicenseDenied(id, licenses.deny) {
+				license.Denied = true
+			}
+		}
+		if license.Denied {
+			denied = append(denied, pak.Path+" ("+strings.Join(found, ", ")+")")
+		}
+
+		report = append(report, license)
+	}
+
+	if licenses.asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		licenses.print(name, report)
+	}
+
+	if len(denied) > 0 {
+		return errors.New(strconv.Itoa(len(denied)) + " packages use a denied license: " + strings.Join(denied, ", "))
+	}
+
+	return nil
+}
+
+func (licenses *LicensesCommand) print(name string, report []models.PackageLicense) {
+	if len(report) == 0 {
+		fmt.Println(name + " has no packages.")
+		return
+	}
+
+	fmt.Println("Licenses of " + name + ":")
+	for _, license := range report {
+		mark := ""
+		if license.Denied {
+			mark = "  DENIED"
+		}
+
+		files := ""
+		if len(license.Files) > 0 {
+			files = " (" + strings.Join(license.Files, ", ") + ")"
+		}
+
+		fmt.Printf("    %-50s %s%s%s\n", license.Path, strings.Join(license.Licenses, ", "), files, mark)
+	}
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+func TestLicensesAppliesDenyPolicy(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	f.write(testGopath+"packages.toml",
+		"[[package]]\npath = \"example.com/mit\"\n\n[[package]]\npath = \"example.com/gpl\"\n\n[[package]]\npath = \"example.com/bare\"\n")
+	f.write(testGopath+"src/example.com/mit/LICENSE.md",
+		"Permission is hereby granted, free of charge, to any person. The above copyright notice and this permission notice shall be included in all copies.")
+	f.write(testGopath+"src/example.com/gpl/COPYING", "GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007")
+	f.mkdir(testGopath + "src/example.com/bare")
+
+	licenses := func(deny ...string) error {
+		return GetLicensesCommand(f.logger, f.configService, utils.GetLicenseService(f.fileSystem), deny, true, testGopath, testGobo).Run("")
+	}
+
+	if err := licenses(); err != nil {
+		t.Fatalf("licenses without a policy returned %v", err)
+	}
+
+	err := licenses("GPL")
+	if err == nil || !strings.Contains(err.Error(), "example.com/gpl (GPL-3.0)") || strings.Contains(err.Error(), "example.com/mit") {
+		t.Errorf("expected only the GPL package to be denied, got %v", err)
+	}
+
+	err = licenses("none")
+	if err == nil || !strings.Contains(err.Error(), "example.com/bare (none)") {
+		t.Errorf("expected the package without a license to be denied, got %v", err)
+	}
+}
@@ -1,10 +1,7 @@
 package commands
 
 import (
-	"bufio"
 	"fmt"
-	"io/ioutil"
-	"os"
 	"strconv"
 	"strings"
 
@@ -19,14 +16,18 @@ type IListCommand interface {
 
 // ListCommand is the struct for this implementation of IListCommand.
 type ListCommand struct {
-	logger         utils.ILogger
-	configService  utils.IConfigService
-	packageService utils.IPackageService
-	copyService    utils.ICopyService
-	moveService    utils.IMoveService
-	host           models.Host
-	gopath         string
-	gobopath       string
+	logger           utils.ILogger
+	configService    utils.IConfigService
+	packageService   utils.IPackageService
+	copyService      utils.ICopyService
+	moveService      utils.IMoveService
+	fileSystem       utils.IFileSystem
+	promptService    utils.IPromptService
+	toolchainService utils.IToolchainService
+	runner           utils.ICommandRunner
+	host             models.Host
+	gopath           string
+	gobopath         string
 }
 
 // GetListCommand returns a pointer to an implementation of IListCommand.
@@ -36,6 +37,10 @@ func GetListCommand(
 	packageService utils.IPackageService,
 	copyService utils.ICopyService,
 	moveService utils.IMoveService,
+	fileSystem utils.IFileSystem,
+	promptService utils.IPromptService,
+	toolchainService utils.IToolchainService,
+	runner utils.ICommandRunner,
 	host models.Host,
 	gopath string,
 	gobopath string,
@@ -46,6 +51,10 @@ func GetListCommand(
 		packageService,
 		copyService,
 		moveService,
+		fileSystem,
+		promptService,
+		toolchainService,
+		runner,
 		host,
 		gopath,
 		gobopath,
@@ -60,10 +69,10 @@ func (list *ListCommand) Run(dir string) {
 	fmt.Println("Available environments:")
 	var envs []string
 
-	files, _ := ioutil.ReadDir(dir)
+	files, _ := list.fileSystem.ReadDir(dir)
 	count := 0
 	for _, f := range files {
-		if f.IsDir() && f.Name() != "initial" {
+		if f.IsDir() && !models.IsReserved(f.Name()) {
 			count++
 			fmt.Println(strconv.Itoa(count) + ". " + f.Name())
 			envs = append(envs, f.Name())
@@ -72,17 +81,14 @@ func (list *ListCommand) Run(dir string) {
 
 	fmt.Println("")
 
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("Enter the environment number to activate (Enter to cancel): ")
-	answer, _ := reader.ReadString('\n')
-	if answer == "\n" {
+	answer := list.promptService.Ask("Enter the environment number to activate (Enter to cancel): ")
+	if answer == "" {
 		list.logger.Info("Not saving environment updates.")
 		fmt.Println("Goodbye.")
 		return
 	}
 
-	selection := strings.Split(answer, "\n")
-	list.logger.Info("Chose to activate option: " + selection[0])
+	list.logger.Info("Chose to activate option: " + answer)
 
 	activate := GetActivateCommand(
 		list.logger,
@@ -90,17 +96,25 @@ func (list *ListCommand) Run(dir string) {
 		list.packageService,
 		list.copyService,
 		list.moveService,
+		list.promptService,
+		list.toolchainService,
+		list.fileSystem,
+		list.runner,
 		list.host,
 		list.gopath,
 		list.gobopath,
 	)
 
-	num, _ := strconv.Atoi(selection[0])
+	num, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || num < 1 || num > len(envs) {
+		list.logger.Error(answer + " is not one of the listed environments.")
+		return
+	}
 	name := envs[num-1]
 
-	list.logger.Info("Option " + selection[0] + " is " + name + ", activating...")
+	list.logger.Info("Option " + answer + " is " + name + ", activating...")
 
-	err := activate.Run(name)
+	err = activate.Run(name)
 	if err != nil {
 		list.logger.Fatal("Error running gobo activate command: " + err.Error())
 	}
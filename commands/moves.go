@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// pendingMove is a path create or activate moves into the directory destination.
+type pendingMove struct {
+	source      string
+	destination string
+}
+
+// moveAll makes the moves which have a source in order. When one fails those already made are moved back, so the
+// GOPATH is never left split between two environments.
+func moveAll(logger utils.ILogger, fileSystem utils.IFileSystem, moveService utils.IMoveService, moves []pendingMove) error {
+	var done []pendingMove
+
+	for _, move := range moves {
+		if _, err := fileSystem.Stat(move.source); err != nil {
+			continue
+		}
+
+		logger.Info(fmt.Sprintf("Moving %s to %s", move.source, move.destination))
+
+		err := moveService.Move(move.source, move.destination)
+		if err != nil {
+			for i := len(done) - 1; i >= 0; i-- {
+				moved := filepath.Join(done[i].destination, filepath.Base(done[i].source))
+
+				undoErr := moveService.Move(moved, filepath.Dir(filepath.Clean(done[i].source)))
+				if undoErr != nil {
+					logger.Error("Unable to move " + moved + " back to " + done[i].source + ": " + undoErr.Error())
+				}
+			}
+
+			return err
+		}
+
+		done = append(done, move)
+	}
+
+	return nil
+}
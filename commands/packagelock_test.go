@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+func TestInstallReproducesLock(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	err := f.configService.WriteLock(testGopath+"packages.lock", models.PackageLock{Package: []models.LockedPackage{
+		{Path: "example.com/zeta", Origin: "example.com/zeta", Revision: "v2.0.0"},
+		{Path: "example.com/alpha", Origin: "example.com/alpha", Revision: "v1.0.0"},
+	}})
+	if err != nil {
+		t.Fatalf("writing lock: %v", err)
+	}
+
+	for path, revision := range map[string]string{"example.com/zeta": "v2.0.0", "example.com/alpha": "v1.0.0"} {
+		path, revision := path, revision
+
+		f.runner.Script(
+			utils.FakeCommand{
+				Command: "go get " + path,
+				Do: func(dir string, env []string) {
+					f.addRepo(testGopath, path, revision)
+				},
+			},
+			utils.FakeCommand{Command: "git checkout " + revision, Dir: testGopath + "src/" + path},
+			utils.FakeCommand{Command: "go install " + path},
+			utils.FakeCommand{
+				Command: "git log -1 --format=%cI HEAD",
+				Dir:     testGopath + "src/" + path,
+				Stdout:  "2020-05-01T12:00:00+02:00\n",
+			},
+		)
+	}
+
+	install := GetInstallCommand(
+		f.logger,
+		f.configService,
+		f.packageService,
+		f.resolverService,
+		f.importService,
+		f.copyService,
+		f.prompt(),
+		models.Host{},
+		testGopath,
+		testGobo,
+	)
+
+	if err := install.Run(""); err != nil {
+		t.Fatalf("install returned %v", err)
+	}
+
+	if !f.runner.Ran("git checkout v2.0.0") || !f.runner.Ran("git checkout v1.0.0") {
+		t.Error("install did not check out the locked revisions")
+	}
+
+	lock, err := f.configService.ReadLock(testGopath + "packages.lock")
+	if err != nil {
+		t.Fatalf("reading lock: %v", err)
+	}
+
+	if len(lock.Package) != 2 || lock.Package[0].Path != "example.com/alpha" {
+		t.Fatalf("expected the regenerated lock sorted by path, got %+v", lock.Package)
+	}
+	if lock.Package[0].RevisionTime != "2020-05-01T10:00:00Z" {
+		t.Errorf("expected the commit time in UTC, got %q", lock.Package[0].RevisionTime)
+	}
+
+	data, _ := f.fileSystem.ReadFile(testGopath + "packages.lock")
+	if !strings.HasPrefix(string(data), "# Generated by gobo") {
+		t.Errorf("packages.lock does not say it is generated:\n%s", data)
+	}
+}
+
+func TestSaveMovesOldRevisionsToLock(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.addRepo(testGopath, "github.com/pkg/errors", "v0.8.0")
+
+	f.fileSystem.RemoveAll(testGopath + "packages.lock")
+	f.write(testGopath+"packages.toml", "[[package]]\npath = \"github.com/pkg/errors\"\nrevision = \"v0.7.0\"\n")
+
+	if err := f.save(); err != nil {
+		t.Fatalf("save returned %v", err)
+	}
+
+	paks, err := f.configService.ReadPackages(testGopath + "packages.toml")
+	if err != nil || len(paks.Package) != 1 || paks.Package[0].Revision != "" {
+		t.Errorf("expected the manifest without revisions, got %+v, %v", paks.Package, err)
+	}
+
+	lock, err := f.configService.ReadLock(testGopath + "packages.lock")
+	if err != nil || len(lock.Package) != 1 || lock.Package[0].Revision != "v0.8.0" {
+		t.Errorf("expected the installed revision in the lock, got %+v, %v", lock.Package, err)
+	}
+}
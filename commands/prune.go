@@ -0,0 +1,263 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// IPruneCommand is the interface to implement for removing the repositories nothing in an environment imports.
+type IPruneCommand interface {
+	Run() error
+}
+
+// PruneCommand is the struct for this implementation of IPruneCommand.
+type PruneCommand struct {
+	logger           utils.ILogger
+	configService    utils.IConfigService
+	packageService   utils.IPackageService
+	importService    utils.IImportService
+	diskUsageService utils.IDiskUsageService
+	fileSystem       utils.IFileSystem
+	promptService    utils.IPromptService
+	roots            []string
+	yes              bool
+	dryRun           bool
+	gopath           string
+}
+
+// GetPruneCommand returns a pointer to an implementation of IPruneCommand. roots are the import paths kept with
+// everything they import, the environment's projects when empty. With yes set nothing is asked before deleting,
+// with dryRun set nothing is deleted.
+func GetPruneCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	packageService utils.IPackageService,
+	importService utils.IImportService,
+	diskUsageService utils.IDiskUsageService,
+	fileSystem utils.IFileSystem,
+	promptService utils.IPromptService,
+	roots []string,
+	yes bool,
+	dryRun bool,
+	gopath string,
+) *PruneCommand {
+	var prune = PruneCommand{
+		logger,
+		configService,
+		packageService,
+		importService,
+		diskUsageService,
+		fileSystem,
+		promptService,
+		roots,
+		yes,
+		dryRun,
+		gopath,
+	}
+
+	return &prune
+}
+
+// Run lists the repositories in the active environment's src which no package under the roots imports, directly
+// or not, together with their archives in pkg, deletes them once confirmed and drops them from packages.toml
+// and packages.lock.
+func (prune *PruneCommand) Run() error {
+
+	env, err := prune.configService.ReadEnvironment(prune.gopath + "gobo.toml")
+	if err != nil {
+		return err
+	}
+
+	roots := prune.roots
+	if len(roots) == 0 {
+		roots = env.Projects
+	}
+	if len(roots) == 0 {
+		return errors.New("nothing says which code to keep, pass -roots or set projects in gobo.toml")
+	}
+
+	prune.packageService.SetEnvironment(utils.EnvironmentVariables(env))
+
+	repositories := prune.packageService.Repositories()
+
+	graph, err := prune.importService.Graph(repositories)
+	if err != nil {
+		return errors.New("unable to read the imports of the environment: " + err.Error())
+	}
+
+	reached := utils.Reachable(graph, roots)
+	for _, root := range roots {
+		if !reachedUnder(reached, root) {
+			return errors.New("no package in " + prune.gopath + "src is at or below the root " + root)
+		}
+	}
+
+	kept := map[string]bool{}
+	for pkg := range reached {
+		kept[graph.Node(pkg)] = true
+	}
+
+	var keptNodes []string
+	for node := range kept {
+		keptNodes = append(keptNodes, node)
+	}
+
+	var unreachable []string
+	for _, repository := range repositories {
+		if kept[repository] {
+			continue
+		}
+
+		// a checkout nested in kept code, such as a submodule under vendor, is part of what is kept
+		if outer := utils.Containing(repository, keptNodes); outer != "" {
+			prune.logger.Info("Keeping " + repository + " because it is inside " + outer + ", which is imported.")
+			continue
+		}
+
+		// removing a repository removes every repository checked out inside it
+		if nested := keptInside(kept, repository); nested != "" {
+			prune.logger.Warn("Keeping " + repository + " because " + nested + " inside it is imported.")
+			continue
+		}
+
+		unreachable = append(unreachable, repository)
+	}
+
+	if len(unreachable) == 0 {
+		fmt.Println("Nothing to prune, every repository is imported from the roots.")
+		return nil
+	}
+
+	sources := garbage{category: "Repositories nothing imports"}
+	archives := garbage{category: "Their package archives"}
+	for _, repository := range unreachable {
+		sources.paths = append(sources.paths, prune.gopath+"src/"+repository)
+		archives.paths = append(archives.paths, prune.archives(repository)...)
+	}
+
+	var total utils.DiskUsage
+	for _, category := range []garbage{sources, archives} {
+		usage := reportGarbage(prune.logger, prune.diskUsageService, category)
+		total.Files += usage.Files
+		total.Bytes += usage.Bytes
+	}
+
+	fmt.Printf("Total: %s\n\n", total)
+
+	if prune.dryRun {
+		fmt.Println("Dry run, nothing was deleted.")
+		return nil
+	}
+
+	if !prune.yes {
+		answer := prune.promptService.Ask("Delete everything listed above? (yes/no): ")
+		if answer != "yes" && answer != "YES" {
+			fmt.Println("Nothing was deleted.")
+			return nil
+		}
+	}
+
+	for _, path := range append(sources.paths, archives.paths...) {
+		prune.logger.Info("Removing " + path)
+
+		err := prune.fileSystem.RemoveAll(path)
+		if err != nil {
+			return errors.New("Prune - Error removing " + path + ": " + err.Error())
+		}
+	}
+
+	err = prune.forget(unreachable, env.Projects)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Pruned %d repositories, reclaimed %s.\n", len(unreachable), utils.FormatBytes(total.Bytes))
+
+	return nil
+}
+
+// archives returns the compiled archives of the repository and its packages in every platform directory of pkg.
+func (prune *PruneCommand) archives(repository string) []string {
+	var found []string
+
+	platforms, _ := prune.fileSystem.ReadDir(prune.gopath + "pkg")
+	for _, platform := range platforms {
+		name := platform.Name()
+		if !platform.IsDir() || name == "mod" || name == "dep" || name == "sumdb" {
+			continue
+		}
+
+		// archives live in pkg/<os>_<arch>/<import path>.a, those of the packages below it in a directory
+		for _, path := range []string{
+			prune.gopath + "pkg/" + name + "/" + repository + ".a",
+			prune.gopath + "pkg/" + name + "/" + repository,
+		} {
+			if _, err := prune.fileSystem.Stat(path); err == nil {
+				found = append(found, path)
+			}
+		}
+	}
+
+	return found
+}
+
+// forget drops the pruned repositories from packages.toml, and regenerates packages.lock without them.
+func (prune *PruneCommand) forget(pruned []string, projects []string) error {
+	pakFile := prune.gopath + "packages.toml"
+
+	manifest, err := prune.configService.ReadPackages(pakFile)
+	if err != nil && !utils.IsConfigNotFound(err) {
+		return err
+	}
+
+	if err == nil {
+		var kept []models.Package
+		for _, pak := range manifest.Package {
+			if utils.Containing(pak.Path, pruned) == "" {
+				kept = append(kept, pak)
+			}
+		}
+
+		if len(kept) != len(manifest.Package) {
+			manifest.Package = kept
+
+			err = prune.configService.WritePackages(pakFile, manifest)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	lockFile := prune.gopath + "packages.lock"
+	if _, err := prune.fileSystem.Stat(lockFile); err != nil {
+		return nil
+	}
+
+	return prune.configService.WriteLock(lockFile, prune.packageService.GenerateLock(manifest.Package, projects))
+}
+
+// reachedUnder reports whether a reached package is root or below it.
+func reachedUnder(reached map[string]bool, root string) bool {
+	for pkg := range reached {
+		if utils.Containing(pkg, []string{root}) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keptInside returns a kept repository checked out below repository, or "" if there is none.
+func keptInside(kept map[string]bool, repository string) string {
+	for path := range kept {
+		if strings.HasPrefix(path, repository+"/") {
+			return path
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+func (f *fixture) prune(roots []string, yes bool, dryRun bool, answers ...string) error {
+	prune := GetPruneCommand(
+		f.logger,
+		f.configService,
+		f.packageService,
+		f.importService,
+		utils.GetDiskUsageService(f.fileSystem),
+		f.fileSystem,
+		f.prompt(answers...),
+		roots,
+		yes,
+		dryRun,
+		testGopath,
+	)
+
+	return prune.Run()
+}
+
+func TestPruneRemovesUnimportedRepositories(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	f.write(testGopath+"src/example.com/me/app/main.go",
+		"package main\n\nimport \"example.com/used/lib\"\n\nfunc main() { lib.Do() }\n")
+	f.addRepo(testGopath, "example.com/used", "v1.0.0")
+	f.write(testGopath+"src/example.com/used/lib/lib.go", "package lib\n\nimport \"example.com/deep\"\n\nfunc Do() { deep.Do() }\n")
+	f.addRepo(testGopath, "example.com/deep", "v1.0.0")
+	f.write(testGopath+"src/example.com/deep/deep.go", "package deep\n\nfunc Do() {}\n")
+	f.write(testGopath+"src/example.com/me/app/main_windows.go",
+		"//go:build windows\n\npackage main\n\nimport _ \"example.com/winonly\"\n")
+	f.addRepo(testGopath, "example.com/winonly", "v1.0.0")
+	f.addRepo(testGopath, "example.com/stale", "v0.1.0")
+	// a submodule under the vendor directory of a kept repository, which the import graph never reaches
+	f.addRepo(testGopath, "example.com/used/vendor/example.com/sub", "v0.2.0")
+	f.write(testGopath+"pkg/linux_amd64/example.com/stale.a", "archive")
+	f.write(testGopath+"pkg/linux_amd64/example.com/stale/sub.a", "archive")
+	f.write(testGopath+"pkg/linux_amd64/example.com/deep.a", "archive")
+	f.write(testGopath+"packages.toml",
+		"[[package]]\npath = \"example.com/used\"\n\n[[package]]\npath = \"example.com/stale\"\n")
+
+	if err := f.prune(nil, true, false); err == nil {
+		t.Error("prune without roots or projects did not fail")
+	}
+	if err := f.prune([]string{"example.com/missing"}, true, false); err == nil {
+		t.Error("prune accepted a root with no packages")
+	}
+
+	if err := f.prune([]string{"example.com/me"}, true, true); err != nil {
+		t.Fatalf("prune returned %v", err)
+	}
+	if !f.exists(testGopath + "src/example.com/stale") {
+		t.Fatal("prune deleted something on a dry run")
+	}
+
+	if err := f.prune([]string{"example.com/me"}, false, false, "no"); err != nil {
+		t.Fatalf("prune returned %v", err)
+	}
+	if !f.exists(testGopath + "src/example.com/stale") {
+		t.Fatal("prune deleted something without confirmation")
+	}
+
+	if err := f.prune([]string{"example.com/me"}, false, false, "yes"); err != nil {
+		t.Fatalf("prune returned %v", err)
+	}
+
+	for _, gone := range []string{
+		testGopath + "src/example.com/stale",
+		testGopath + "pkg/linux_amd64/example.com/stale.a",
+		testGopath + "pkg/linux_amd64/example.com/stale",
+	} {
+		if f.exists(gone) {
+			t.Errorf("prune left %s behind", gone)
+		}
+	}
+
+	for _, kept := range []string{
+		testGopath + "src/example.com/me/app/main.go",
+		testGopath + "src/example.com/used/lib/lib.go",
+		testGopath + "src/example.com/deep/deep.go",
+		testGopath + "src/example.com/winonly/main.go",
+		testGopath + "src/example.com/used/vendor/example.com/sub/main.go",
+		testGopath + "pkg/linux_amd64/example.com/deep.a",
+	} {
+		if !f.exists(kept) {
+			t.Errorf("prune removed %s, which the roots import", kept)
+		}
+	}
+
+	paks, err := f.configService.ReadPackages(testGopath + "packages.toml")
+	if err != nil || len(paks.Package) != 1 || paks.Package[0].Path != "example.com/used" {
+		t.Errorf("expected only example.com/used left in packages.toml, got %+v, %v", paks.Package, err)
+	}
+
+	lock, err := f.configService.ReadLock(testGopath + "packages.lock")
+	if err != nil {
+		t.Fatalf("reading lock: %v", err)
+	}
+	for _, locked := range lock.Package {
+		if locked.Path == "example.com/stale" {
+			t.Error("packages.lock still locks the pruned repository")
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package commands
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
-	"os"
+	"path"
+	"path/filepath"
+	"strings"
 
 	"github.com/camronlevanger/gobo/models"
 	"github.com/camronlevanger/gobo/utils"
@@ -11,27 +13,55 @@ import (
 
 // IRestoreCommand is the interface to implement for restoring the system to its pre-gobo state.
 type IRestoreCommand interface {
-	Run() error
+	Run(targets []string) error
 }
 
 // RestoreCommand is the struct for this implementation of IRestoreCommand.
 type RestoreCommand struct {
-	logger      utils.ILogger
-	copyService utils.ICopyService
-	gopath      string
-	gobopath    string
+	logger           utils.ILogger
+	copyService      utils.ICopyService
+	configService    utils.IConfigService
+	manifestService  utils.IManifestService
+	diskUsageService utils.IDiskUsageService
+	fileSystem       utils.IFileSystem
+	promptService    utils.IPromptService
+	force            bool
+	to               string
+	gopath           string
+	gobopath         string
 }
 
-// GetRestoreCommand returns a pointer to an implementation of IRestoreCommand.
+// restoreItem is a single directory restored from the initial backup.
+type restoreItem struct {
+	source      string
+	destination string
+}
+
+// GetRestoreCommand returns a pointer to an implementation of IRestoreCommand. When to is set the backup is
+// extracted into that directory and nothing else is touched.
 func GetRestoreCommand(
 	logger utils.ILogger,
 	copyService utils.ICopyService,
+	configService utils.IConfigService,
+	manifestService utils.IManifestService,
+	diskUsageService utils.IDiskUsageService,
+	fileSystem utils.IFileSystem,
+	promptService utils.IPromptService,
+	force bool,
+	to string,
 	gopath string,
 	gobopath string,
 ) *RestoreCommand {
 	var restore = RestoreCommand{
 		logger,
 		copyService,
+		configService,
+		manifestService,
+		diskUsageService,
+		fileSystem,
+		promptService,
+		force,
+		to,
 		gopath,
 		gobopath,
 	}
@@ -39,33 +69,208 @@ func GetRestoreCommand(
 	return &restore
 }
 
-// Run deletes all traces of gobo and restores the original gopath from ~/.gobo/initial.
-func (restore *RestoreCommand) Run() error {
+// Run restores the GOPATH from ~/.gobo/initial after showing what will change and asking for confirmation.
+// With no targets every GOPATH directory is restored and all traces of gobo are deleted. Targets may name
+// GOPATH directories (src, pkg, bin) or package import paths, which are restored from the backup's src
+// while gobo keeps managing the environment.
+func (restore *RestoreCommand) Run(targets []string) error {
 
-	reader := bufio.NewReader(os.Stdin)
+	backup := restore.gobopath + "initial/"
 
-	fmt.Print("The restore command deletes all virtual environments and gobo files, then restores your GOPATH to the state it was in before you ran gobo for the first time. Are you sure this is what you want to do? (yes/no): ")
-	answer, _ := reader.ReadString('\n')
-	if answer == "yes\n" || answer == "YES\n" {
-		for _, dir := range models.GOPATHDIRECTORIES {
-			restore.logger.Error(fmt.Sprintf("Removing active %s directory at %s\n", dir, restore.gopath))
-			err := os.RemoveAll(restore.gopath + dir)
-			if err != nil {
-				restore.logger.Error("RESTORE - Error cleaning up current GOPATH: " + err.Error())
-			}
+	if _, err := restore.fileSystem.Stat(backup); err != nil {
+		return errors.New("there is no initial backup at " + backup + " to restore from")
+	}
+
+	err := verifyManifest(restore.configService, restore.manifestService, backup)
+	if err != nil {
+		if !restore.force {
+			return errors.New(err.Error() + "\nrefusing to restore from a backup that can't be verified, use -force to restore anyway")
 		}
 
+		restore.logger.Warn("Restoring from a backup that failed verification: " + err.Error())
+	}
+
+	destination := restore.gopath
+	if restore.to != "" {
+		destination, err = restore.extractTarget()
+		if err != nil {
+			return err
+		}
+	}
+
+	items, err := restore.items(backup, destination, targets)
+	if err != nil {
+		return err
+	}
+
+	full := len(targets) == 0 && restore.to == ""
+
+	restore.preview(items, full)
+
+	if restore.to == "" {
+		question := "Restore the listed items from the initial backup? (yes/no): "
+		if full {
+			question = "The restore command deletes all virtual environments and gobo files, then restores your GOPATH to the state it was in before you ran gobo for the first time. Are you sure this is what you want to do? (yes/no): "
+		}
+
+		answer := restore.promptService.Ask(question)
+		if answer != "yes" && answer != "YES" {
+			fmt.Println("Nothing was restored.")
+			return nil
+		}
+	}
+
+	for _, item := range items {
+		restore.logger.Info(fmt.Sprintf("Restoring %s from %s\n", item.destination, item.source))
+
+		err = restore.fileSystem.RemoveAll(item.destination)
+		if err != nil {
+			return errors.New("RESTORE - Error cleaning up " + item.destination + ": " + err.Error())
+		}
+
+		if _, statErr := restore.fileSystem.Stat(item.source); statErr != nil {
+			continue
+		}
+
+		err = restore.fileSystem.MkdirAll(filepath.Dir(item.destination), models.FILEMODE)
+		if err == nil {
+			err = restore.copyService.CopyDir(item.source, item.destination)
+		}
+		if err != nil {
+			return errors.New(
+				"RESTORE - Error copying " + item.source + " to " + item.destination + ", the gobo home was left in place: " + err.Error(),
+			)
+		}
+	}
+
+	if !full {
+		if restore.to == "" {
+			fmt.Println("Run gobo save to record the restored packages in the active environment.")
+		}
+		return nil
+	}
+
+	for _, file := range models.ENVIRONMENTFILES {
+		restore.fileSystem.RemoveAll(restore.gopath + file)
+	}
+
+	return restore.fileSystem.RemoveAll(restore.gobopath)
+}
+
+// items works out which backup directories are restored where.
+func (restore *RestoreCommand) items(backup string, destination string, targets []string) ([]restoreItem, error) {
+	var items []restoreItem
+
+	if len(targets) == 0 {
 		for _, dir := range models.GOPATHDIRECTORIES {
-			restore.logger.Error(fmt.Sprintf("Restoring %s directory from %s\n", dir, restore.gobopath+dir))
-			err := restore.copyService.CopyDir(restore.gobopath+"initial"+dir, restore.gopath)
-			if err != nil {
-				restore.logger.Error("RESTORE - Error copying initial backup directory to GOPATH: " + err.Error())
-			}
+			items = append(items, restoreItem{backup + dir, destination + dir})
+		}
+
+		return items, nil
+	}
 
+	for _, target := range targets {
+		target, err := restoreTarget(target)
+		if err != nil {
+			return nil, err
 		}
 
-		os.RemoveAll(restore.gobopath)
+		if isGopathDirectory(target) {
+			items = append(items, restoreItem{backup + target, destination + target})
+			continue
+		}
+
+		source := backup + "src/" + target
+		if _, err := restore.fileSystem.Stat(source); err != nil {
+			return nil, errors.New(target + " is not a GOPATH directory or a package in the initial backup")
+		}
+
+		items = append(items, restoreItem{source, destination + "src/" + target})
+	}
+
+	return items, nil
+}
+
+// restoreTarget cleans a target named on the command line and refuses one which is absolute, climbs with .. or
+// names the whole GOPATH, so it stays inside the backup and the GOPATH.
+func restoreTarget(target string) (string, error) {
+	slashed := filepath.ToSlash(target)
+	if path.IsAbs(slashed) || filepath.IsAbs(target) {
+		return "", errors.New(target + " must be a GOPATH directory or a package path, such as github.com/pkg/errors")
+	}
+
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return "", errors.New(target + " must not contain ..")
+		}
+	}
+
+	cleaned := path.Clean(slashed)
+	if cleaned == "." {
+		return "", errors.New("name a GOPATH directory or package to restore instead of " + target)
+	}
+
+	return cleaned, nil
+}
+
+// extractTarget checks the -to directory is safe to extract into and returns it with a trailing separator.
+func (restore *RestoreCommand) extractTarget() (string, error) {
+	to, err := filepath.Abs(restore.to)
+	if err != nil {
+		return "", err
+	}
+
+	for _, managed := range []string{restore.gopath, restore.gobopath} {
+		managed = filepath.Clean(managed)
+		if to == managed || strings.HasPrefix(to, managed+string(filepath.Separator)) {
+			return "", errors.New("-to must be outside of " + managed)
+		}
+	}
+
+	if entries, err := restore.fileSystem.ReadDir(to); err == nil && len(entries) > 0 {
+		return "", errors.New(to + " is not empty, -to needs a new or empty directory")
+	}
+
+	err = restore.fileSystem.MkdirAll(to, models.FILEMODE)
+
+	return to + string(filepath.Separator), err
+}
+
+// preview prints what the restore removes and what it puts back.
+func (restore *RestoreCommand) preview(items []restoreItem, full bool) {
+	fmt.Println("The restore will:")
+
+	for _, item := range items {
+		current, _ := restore.diskUsageService.Usage(item.destination)
+		if current.Files > 0 {
+			fmt.Printf("    remove  %s (%s)\n", item.destination, current)
+		}
+
+		backup, _ := restore.diskUsageService.Usage(item.source)
+		fmt.Printf("    restore %s from %s (%s)\n", item.destination, item.source, backup)
+	}
+
+	if full {
+		envs, _ := restore.fileSystem.ReadDir(restore.gobopath)
+		for _, env := range envs {
+			if env.IsDir() && !models.IsReserved(env.Name()) {
+				fmt.Printf("    delete  environment %s\n", env.Name())
+			}
+		}
+
+		fmt.Printf("    delete  %s and every gobo file in %s\n", restore.gobopath, restore.gopath)
+	}
+
+	fmt.Println("")
+}
+
+// isGopathDirectory reports whether name is one of the directories gobo manages in a GOPATH.
+func isGopathDirectory(name string) bool {
+	for _, dir := range models.GOPATHDIRECTORIES {
+		if name == dir {
+			return true
+		}
 	}
 
-	return nil
+	return false
 }
@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// IRollbackCommand is the interface to implement for returning an environment to a snapshot.
+type IRollbackCommand interface {
+	Run(name string, ref string) error
+}
+
+// RollbackCommand is the struct for this implementation of IRollbackCommand.
+type RollbackCommand struct {
+	logger          utils.ILogger
+	configService   utils.IConfigService
+	copyService     utils.ICopyService
+	manifestService utils.IManifestService
+	fileSystem      utils.IFileSystem
+	keep            int
+	force           bool
+	gopath          string
+	gobopath        string
+}
+
+// GetRollbackCommand returns a pointer to an implementation of IRollbackCommand.
+func GetRollbackCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	copyService utils.ICopyService,
+	manifestService utils.IManifestService,
+	fileSystem utils.IFileSystem,
+	keep int,
+	force bool,
+	gopath string,
+	gobopath string,
+) *RollbackCommand {
+	rollback := RollbackCommand{
+		logger,
+		configService,
+		copyService,
+		manifestService,
+		fileSystem,
+		keep,
+		force,
+		gopath,
+		gobopath,
+	}
+
+	return &rollback
+}
+
+// Run replaces the GOPATH directories and toml files of the environment name with those in the snapshot ref,
+// which is a snapshot id or label. The current state is snapshotted first so the rollback can be undone.
+// An environment that has been deleted is recreated in the gobo home. Snapshots which fail verification
+// against their manifest are refused unless forced.
+func (rollback *RollbackCommand) Run(name string, ref string) error {
+
+	if name == "" || ref == "" {
+		return errors.New("an environment name and a snapshot are required")
+	}
+
+	target, err := findSnapshot(rollback.configService, rollback.fileSystem, rollback.gobopath, name, ref)
+	if err != nil {
+		return err
+	}
+	source := snapshotsPath(rollback.gobopath, name) + target.ID + "/"
+
+	err = verifyManifest(rollback.configService, rollback.manifestService, source)
+	if err != nil {
+		if !rollback.force {
+			return errors.New(err.Error() + "\nuse -force to roll back anyway")
+		}
+
+		rollback.logger.Warn("Rolling back from a snapshot that failed verification: " + err.Error())
+	}
+
+	snapshot := GetSnapshotCommand(
+		rollback.logger,
+		rollback.configService,
+		rollback.copyService,
+		rollback.manifestService,
+		rollback.fileSystem,
+		rollback.keep,
+		rollback.gopath,
+		rollback.gobopath,
+	)
+
+	root, _, err := environmentRoot(rollback.configService, rollback.gopath, rollback.gobopath, name)
+	if err == nil {
+		// retention is applied once the rollback is done, so the target can't be pruned from under it
+		snapshot.keep = 0
+
+		undo, err := snapshot.Run(name, "before-rollback-"+target.ID)
+		if err != nil {
+			return errors.New("unable to snapshot " + name + " before rolling back: " + err.Error())
+		}
+
+		fmt.Println("Saved the current state of " + name + " as snapshot " + undo.ID + ".")
+	} else {
+		rollback.logger.Warn(name + " does not exist, recreating it from snapshot " + target.ID)
+
+		root = rollback.gobopath + name + "/"
+		err = rollback.fileSystem.MkdirAll(root, models.FILEMODE)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, dir := range models.GOPATHDIRECTORIES {
+		rollback.logger.Info(fmt.Sprintf("Rolling back %s%s to snapshot %s", root, dir, target.ID))
+
+		err = rollback.fileSystem.RemoveAll(root + dir)
+		if err != nil {
+			return err
+		}
+
+		if _, statErr := rollback.fileSystem.Stat(source + dir); statErr != nil {
+			rollback.fileSystem.MkdirAll(root+dir, models.FILEMODE)
+			continue
+		}
+
+		err = rollback.copyService.CopyDir(source+dir, root)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, file := range models.ENVIRONMENTFILES {
+		// a file the snapshot was taken without, such as a lock written later, would disagree with the code
+		if _, statErr := rollback.fileSystem.Stat(source + file); statErr != nil {
+			err = rollback.fileSystem.RemoveAll(root + file)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = rollback.copyService.CopyFile(source+file, root)
+		if err != nil {
+			return err
+		}
+	}
+
+	snapshot.keep = rollback.keep
+	snapshot.prune(name)
+
+	return nil
+}
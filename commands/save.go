@@ -1,9 +1,7 @@
 package commands
 
 import (
-	"bufio"
-	"fmt"
-	"os"
+	"reflect"
 
 	"github.com/camronlevanger/gobo/models"
 	"github.com/camronlevanger/gobo/utils"
@@ -20,6 +18,7 @@ type SaveCommand struct {
 	configService  utils.IConfigService
 	packageService utils.IPackageService
 	copyService    utils.ICopyService
+	promptService  utils.IPromptService
 	host           models.Host
 	gopath         string
 	gobopath       string
@@ -31,6 +30,7 @@ func GetSaveCommand(
 	configService utils.IConfigService,
 	packageService utils.IPackageService,
 	copyService utils.ICopyService,
+	promptService utils.IPromptService,
 	host models.Host,
 	gopath string,
 	gobopath string,
@@ -40,6 +40,7 @@ func GetSaveCommand(
 		configService,
 		packageService,
 		copyService,
+		promptService,
 		host,
 		gopath,
 		gobopath,
@@ -48,35 +49,87 @@ func GetSaveCommand(
 	return &save
 }
 
-// Run diffs the environment file to the filesystem, and confirms writing the detected changes if found.
+// Run regenerates packages.lock from the packages installed in the GOPATH, and confirms writing it when it
+// changed. packages.toml is only written to create it when it is missing, or to move the revisions of an old
+// packages.toml into the lock.
 func (save *SaveCommand) Run(silent bool) error {
 
 	envFile := save.gopath + "gobo.toml"
 	pakFile := save.gopath + "packages.toml"
+	lockFile := save.gopath + "packages.lock"
 
-	env := save.configService.ReadEnvironment(envFile)
-	pak := save.configService.ReadPackages(pakFile)
+	env, err := save.configService.ReadEnvironment(envFile)
+	if err != nil {
+		return err
+	}
+
+	save.packageService.SetEnvironment(utils.EnvironmentVariables(env))
+	save.packageService.CheckGoVersion(env.GoVersion)
+
+	pak, err := save.configService.ReadPackages(pakFile)
+	missing := utils.IsConfigNotFound(err)
+	if missing {
+		save.logger.Warn(pakFile + " is missing, it will be recreated from the filesystem.")
+	} else if err != nil {
+		return err
+	}
+
+	old, err := save.configService.ReadLock(lockFile)
+	unlocked := utils.IsConfigNotFound(err)
+	if err != nil && !unlocked {
+		return err
+	}
+
+	lock := save.packageService.GenerateLock(pak.Package, env.Projects)
+
+	manifestChanged := false
+	if missing {
+		for _, locked := range lock.Package {
+			pak.Package = append(pak.Package, models.Package{Path: locked.Path, Origin: locked.Origin})
+		}
+		manifestChanged = true
+	} else if unlocked {
+		manifestChanged = stripRevisions(pak.Package)
+		if manifestChanged {
+			save.logger.Warn("Moving the revisions in " + pakFile + " to " + lockFile + ", packages.toml is now only the manifest.")
+		}
+	}
+
+	for _, locked := range lock.Package {
+		if !listed(pak.Package, locked.Path) {
+			save.logger.Info(locked.Path + " is installed but not in " + pakFile + ", it is only recorded in the lock.")
+		}
+	}
 
-	changed, updatedPackages := save.packageService.DiffAndUpdatePackages(pak.Package)
-	env.Host = save.host
-	pak.Package = updatedPackages
+	changed := manifestChanged || unlocked || !reflect.DeepEqual(old.Package, lock.Package)
 
 	if changed {
 		if !silent {
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Print("The current environment has uncommited changes, update now? (y): ")
-			answer, _ := reader.ReadString('\n')
-			if answer == "n\n" || answer == "N\n" {
+			answer := save.promptService.Ask("The current environment has uncommited changes, update now? (y): ")
+			if answer == "n" || answer == "N" {
 				save.logger.Info("Not saving environment updates.")
 				return nil
 			}
+		}
+
+		save.logger.Info("Commiting updates to " + env.Name)
+
+		env.Host = save.host
+		err = save.configService.WriteEnvironment(envFile, env)
+		if err != nil {
+			return err
+		}
+
+		if manifestChanged {
+			err = save.configService.WritePackages(pakFile, pak)
+			if err != nil {
+				return err
+			}
+		}
 
-			save.logger.Info("Commiting updates to " + env.Name)
-			save.configService.WriteEnvironment(save.gopath, env)
-			save.configService.WritePackages(save.gopath, pak)
-		} else {
-			save.configService.WriteEnvironment(save.gopath, env)
-			save.configService.WritePackages(save.gopath, pak)
+		err = save.configService.WriteLock(lockFile, lock)
+		if err != nil {
+			return err
 		}
 	} else {
 		save.logger.Info("No environment changes detected.")
@@ -84,3 +137,29 @@ func (save *SaveCommand) Run(silent bool) error {
 
 	return nil
 }
+
+// stripRevisions clears the revisions an old packages.toml recorded, reporting whether there were any.
+func stripRevisions(packages []models.Package) bool {
+	stripped := false
+
+	for i := range packages {
+		if packages[i].Revision != "" || packages[i].RevisionTime != "" {
+			packages[i].Revision = ""
+			packages[i].RevisionTime = ""
+			stripped = true
+		}
+	}
+
+	return stripped
+}
+
+// listed reports whether the package path is in packages.
+func listed(packages []models.Package, path string) bool {
+	for _, pak := range packages {
+		if pak.Path == path {
+			return true
+		}
+	}
+
+	return false
+}
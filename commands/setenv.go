@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// ISetenvCommand is the interface to implement for managing the environment variables of an environment.
+type ISetenvCommand interface {
+	Run(name string, assignments []string) error
+}
+
+// SetenvCommand is the struct for this implementation of ISetenvCommand.
+type SetenvCommand struct {
+	logger           utils.ILogger
+	configService    utils.IConfigService
+	toolchainService utils.IToolchainService
+	fileSystem       utils.IFileSystem
+	unset            bool
+	gopath           string
+	gobopath         string
+}
+
+// GetSetenvCommand returns a pointer to an implementation of ISetenvCommand. With unset the arguments name the
+// variables to remove instead of KEY=VALUE pairs to set.
+func GetSetenvCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	toolchainService utils.IToolchainService,
+	fileSystem utils.IFileSystem,
+	unset bool,
+	gopath string,
+	gobopath string,
+) *SetenvCommand {
+	var setenv = SetenvCommand{
+		logger,
+		configService,
+		toolchainService,
+		fileSystem,
+		unset,
+		gopath,
+		gobopath,
+	}
+
+	return &setenv
+}
+
+// Run sets or unsets the variables of the environment name, or prints them when no assignments are given.
+func (setenv *SetenvCommand) Run(name string, assignments []string) error {
+
+	if name == "" {
+		return errors.New("setenv needs the name of an environment")
+	}
+
+	root, active, err := environmentRoot(setenv.configService, setenv.gopath, setenv.gobopath, name)
+	if err != nil {
+		return err
+	}
+
+	env, err := setenv.configService.ReadEnvironment(root + "gobo.toml")
+	if err != nil {
+		return err
+	}
+
+	if len(assignments) == 0 {
+		for _, variable := range utils.EnvironmentVariables(env) {
+			fmt.Println(variable)
+		}
+		return nil
+	}
+
+	if env.Env == nil {
+		env.Env = map[string]string{}
+	}
+
+	for _, assignment := range assignments {
+		key, value := assignment, ""
+		if i := strings.Index(assignment, "="); i >= 0 {
+			key, value = assignment[:i], assignment[i+1:]
+		} else if !setenv.unset {
+			return errors.New(assignment + " is not a KEY=VALUE assignment")
+		}
+
+		if err := utils.ValidateVariable(key); err != nil {
+			return err
+		}
+
+		if setenv.unset {
+			delete(env.Env, key)
+		} else {
+			env.Env[key] = value
+		}
+	}
+
+	env.DateModified = time.Now()
+
+	err = setenv.configService.WriteEnvironment(root+"gobo.toml", env)
+	if err != nil {
+		return err
+	}
+
+	if !active {
+		return nil
+	}
+
+	goroot, err := resolveToolchain(setenv.toolchainService, env)
+	if err != nil {
+		setenv.logger.Warn("Unable to select the toolchain of " + name + ": " + err.Error())
+	}
+
+	err = writeActivateScript(setenv.fileSystem, setenv.gobopath, env, goroot)
+	if err != nil {
+		return errors.New("unable to write " + setenv.gobopath + activateScript + ": " + err.Error())
+	}
+
+	fmt.Println("Run . " + setenv.gobopath + activateScript + " to apply the changes to your shell.")
+
+	return nil
+}
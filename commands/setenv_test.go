@@ -0,0 +1,100 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+func (f *fixture) setenv(name string, unset bool, assignments ...string) error {
+	setenv := GetSetenvCommand(
+		f.logger,
+		f.configService,
+		f.toolchainService,
+		f.fileSystem,
+		unset,
+		testGopath,
+		testGobo,
+	)
+
+	return setenv.Run(name, assignments)
+}
+
+func TestSetenvManagesVariables(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	for _, bad := range []string{"CGO_ENABLED", "1FLAGS=x", "GOPATH=/tmp", "GOBO_ENV=x"} {
+		if err := f.setenv("dev", false, bad); err == nil {
+			t.Errorf("setenv accepted %s", bad)
+		}
+	}
+
+	if err := f.setenv("dev", false, "CGO_ENABLED=0", "GOFLAGS=-tags=integration"); err != nil {
+		t.Fatalf("setenv returned %v", err)
+	}
+
+	env, _ := f.configService.ReadEnvironment(testGopath + "gobo.toml")
+	if env.Env["CGO_ENABLED"] != "0" || env.Env["GOFLAGS"] != "-tags=integration" {
+		t.Fatalf("unexpected variables %v", env.Env)
+	}
+
+	script := f.activateScript()
+	if !strings.Contains(script, "export CGO_ENABLED='0'") || !strings.Contains(script, "export GOBO_ENV_KEYS='CGO_ENABLED GOFLAGS'") {
+		t.Errorf("the activate script does not export the variables:\n%s", script)
+	}
+
+	if err := f.setenv("dev", true, "GOFLAGS"); err != nil {
+		t.Fatalf("setenv -unset returned %v", err)
+	}
+
+	env, _ = f.configService.ReadEnvironment(testGopath + "gobo.toml")
+	if _, ok := env.Env["GOFLAGS"]; ok || env.Env["CGO_ENABLED"] != "0" {
+		t.Errorf("unexpected variables after unset %v", env.Env)
+	}
+
+	if err := f.create("web", false); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	if strings.Contains(f.activateScript(), "export CGO_ENABLED") {
+		t.Error("switching environments kept the previous environment's variables")
+	}
+}
+
+func TestSaveAppliesVariables(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	if err := f.setenv("dev", false, "GOPRIVATE=example.com"); err != nil {
+		t.Fatalf("setenv returned %v", err)
+	}
+	f.addRepo(testGopath, "example.com/private", "v1.0.0")
+
+	if err := f.save(); err != nil {
+		t.Fatalf("save returned %v", err)
+	}
+
+	var calls []utils.FakeCall
+	for _, call := range f.runner.Calls() {
+		if call.Command == "git describe --exact-match" {
+			calls = append(calls, call)
+		}
+	}
+
+	if len(calls) == 0 {
+		t.Fatal("save did not inspect the repository")
+	}
+
+	for _, call := range calls {
+		if len(call.Env) != 1 || call.Env[0] != "GOPRIVATE=example.com" {
+			t.Errorf("save ran git with environment %v", call.Env)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// IShellCommand is the interface to implement for spawning a shell scoped to an environment.
+type IShellCommand interface {
+	Run(name string) (int, error)
+}
+
+// ShellCommand is the struct for this implementation of IShellCommand.
+type ShellCommand struct {
+	logger           utils.ILogger
+	configService    utils.IConfigService
+	toolchainService utils.IToolchainService
+	fileSystem       utils.IFileSystem
+	runner           utils.ICommandRunner
+	shell            string
+	gopath           string
+	gobopath         string
+}
+
+// GetShellCommand returns a pointer to an implementation of IShellCommand. shell is the user's $SHELL, /bin/sh
+// is used when it is empty.
+func GetShellCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	toolchainService utils.IToolchainService,
+	fileSystem utils.IFileSystem,
+	runner utils.ICommandRunner,
+	shell string,
+	gopath string,
+	gobopath string,
+) *ShellCommand {
+	var shellCommand = ShellCommand{
+		logger,
+		configService,
+		toolchainService,
+		fileSystem,
+		runner,
+		shell,
+		gopath,
+		gobopath,
+	}
+
+	return &shellCommand
+}
+
+// Run spawns the user's shell with GOPATH, PATH and the variables of the environment name set and its name in the
+// prompt, and returns the shell's exit code. Nothing is moved, leaving the shell returns to the previous state.
+func (shellCommand *ShellCommand) Run(name string) (int, error) {
+
+	if name == "" {
+		return 1, errors.New("shell needs the name of an environment")
+	}
+
+	if current := os.Getenv("GOBO_SHELL"); current != "" {
+		return 1, errors.New("already in a gobo shell for " + current + ", exit it first")
+	}
+
+	root, env, goroot, err := loadEnvironment(
+		shellCommand.configService,
+		shellCommand.toolchainService,
+		shellCommand.gopath,
+		shellCommand.gobopath,
+		name,
+	)
+	if err != nil {
+		return 1, err
+	}
+
+	shell := shellCommand.shell
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+
+	variables := append(runEnvironment(root, env, goroot), "GOBO_SHELL="+name)
+
+	args, extra, err := shellCommand.prompt(shell, name)
+	if err != nil {
+		return 1, err
+	}
+
+	shellCommand.logger.Info("Starting " + shell + " in " + name + ", exit the shell to leave it")
+
+	return shellCommand.runner.Exec("", append(variables, extra...), shell, args...)
+}
+
+// prompt prepares the shell so its prompt starts with the environment name, returning the arguments and the
+// extra variables to start the shell with.
+func (shellCommand *ShellCommand) prompt(shell string, name string) ([]string, []string, error) {
+	prefix := "(" + name + ") "
+	dir := shellCommand.gobopath + "shell/"
+
+	err := shellCommand.fileSystem.MkdirAll(dir, models.FILEMODE)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch strings.TrimSuffix(filepath.Base(shell), ".exe") {
+	case "bash":
+		rcfile := dir + name + ".bashrc"
+		err = shellCommand.write(rcfile,
+			`if [ -f "$HOME/.bashrc" ]; then . "$HOME/.bashrc"; fi`,
+			"PS1="+shellQuote(prefix)+`"$PS1"`,
+		)
+
+		return []string{"--rcfile", rcfile, "-i"}, nil, err
+
+	case "zsh":
+		// zsh reads its startup files from ZDOTDIR, ours load the user's own before changing the prompt
+		zdotdir := dir + name + ".zsh"
+		original := os.Getenv("ZDOTDIR")
+		if original == "" {
+			original = os.Getenv("HOME")
+		}
+
+		err = shellCommand.fileSystem.MkdirAll(zdotdir, models.FILEMODE)
+		if err == nil {
+			err = shellCommand.write(zdotdir+"/.zshenv",
+				`if [ -f "$GOBO_ZDOTDIR/.zshenv" ]; then . "$GOBO_ZDOTDIR/.zshenv"; fi`,
+			)
+		}
+		if err == nil {
+			err = shellCommand.write(zdotdir+"/.zshrc",
+				`ZDOTDIR="$GOBO_ZDOTDIR"`,
+				`if [ -f "$ZDOTDIR/.zshrc" ]; then . "$ZDOTDIR/.zshrc"; fi`,
+				"PROMPT="+shellQuote(prefix)+`"$PROMPT"`,
+			)
+		}
+
+		return []string{"-i"}, []string{"ZDOTDIR=" + zdotdir, "GOBO_ZDOTDIR=" + original}, err
+
+	case "fish":
+		command := "functions -c fish_prompt _gobo_fish_prompt; " +
+			"function fish_prompt; echo -n " + shellQuote(prefix) + "; _gobo_fish_prompt; end"
+
+		return []string{"-i", "-C", command}, nil, nil
+	}
+
+	return []string{"-i"}, []string{"PS1=" + prefix + promptOr(os.Getenv("PS1"), "$ ")}, nil
+}
+
+func (shellCommand *ShellCommand) write(path string, lines ...string) error {
+	script := "# Written by gobo shell.\n" + strings.Join(lines, "\n") + "\n"
+
+	return shellCommand.fileSystem.WriteFile(path, []byte(script), models.FILEMODE)
+}
+
+func promptOr(prompt string, fallback string) string {
+	if prompt == "" {
+		return fallback
+	}
+
+	return prompt
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+func TestShellPrefixesPrompt(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	if err := f.create("web", false); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	rcfile := testGobo + "shell/dev.bashrc"
+	f.runner.Script(utils.FakeCommand{Command: "/bin/bash --rcfile " + rcfile + " -i", ExitCode: 4})
+
+	shell := GetShellCommand(
+		f.logger,
+		f.configService,
+		f.toolchainService,
+		f.fileSystem,
+		f.runner,
+		"/bin/bash",
+		testGopath,
+		testGobo,
+	)
+
+	code, err := shell.Run("dev")
+	if err != nil {
+		t.Fatalf("shell returned %v", err)
+	}
+	if code != 4 {
+		t.Errorf("expected the shell's exit code 4, got %d", code)
+	}
+
+	data, _ := f.fileSystem.ReadFile(rcfile)
+	if !strings.Contains(string(data), `PS1='(dev) '"$PS1"`) {
+		t.Errorf("the rcfile does not prefix the prompt:\n%s", data)
+	}
+
+	calls := f.runner.Calls()
+	env := strings.Join(calls[len(calls)-1].Env, "\n")
+	if !strings.Contains(env, "GOPATH=/home/gopher/.gobo/dev") || !strings.Contains(env, "GOBO_SHELL=dev") {
+		t.Errorf("the shell does not point at dev:\n%s", env)
+	}
+
+	if f.activeName() != "web" || !f.exists(testGobo+"dev/src") {
+		t.Error("shell moved environments")
+	}
+}
@@ -0,0 +1,219 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// snapshotIDFormat names snapshots after the UTC time they were taken, so ids sort oldest first.
+const snapshotIDFormat = "20060102-150405.000000000"
+
+// ISnapshotCommand is the interface to implement for capturing a point in time copy of an environment.
+type ISnapshotCommand interface {
+	Run(name string, label string) (models.Snapshot, error)
+}
+
+// SnapshotCommand is the struct for this implementation of ISnapshotCommand.
+type SnapshotCommand struct {
+	logger          utils.ILogger
+	configService   utils.IConfigService
+	copyService     utils.ICopyService
+	manifestService utils.IManifestService
+	fileSystem      utils.IFileSystem
+	keep            int
+	gopath          string
+	gobopath        string
+}
+
+// GetSnapshotCommand returns a pointer to an implementation of ISnapshotCommand. Only the newest keep
+// snapshots of an environment are kept, a keep of 0 keeps them all.
+func GetSnapshotCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	copyService utils.ICopyService,
+	manifestService utils.IManifestService,
+	fileSystem utils.IFileSystem,
+	keep int,
+	gopath string,
+	gobopath string,
+) *SnapshotCommand {
+	snapshot := SnapshotCommand{
+		logger,
+		configService,
+		copyService,
+		manifestService,
+		fileSystem,
+		keep,
+		gopath,
+		gobopath,
+	}
+
+	return &snapshot
+}
+
+// Run copies the src, pkg and bin directories and toml files of the environment name into a new snapshot
+// and records a manifest of it.
+func (snapshot *SnapshotCommand) Run(name string, label string) (models.Snapshot, error) {
+
+	var taken models.Snapshot
+
+	if name == "" {
+		return taken, errors.New("an environment name is required")
+	}
+
+	root, _, err := environmentRoot(snapshot.configService, snapshot.gopath, snapshot.gobopath, name)
+	if err != nil {
+		return taken, err
+	}
+
+	now := time.Now()
+	base := snapshotsPath(snapshot.gobopath, name)
+	id := now.UTC().Format(snapshotIDFormat)
+	for snapshot.exists(base + id) {
+		now = now.Add(time.Nanosecond)
+		id = now.UTC().Format(snapshotIDFormat)
+	}
+
+	dest := base + id + "/"
+	err = snapshot.fileSystem.MkdirAll(dest, models.FILEMODE)
+	if err != nil {
+		return taken, err
+	}
+
+	snapshot.logger.Info(fmt.Sprintf("Snapshotting %s at %s into %s", name, root, dest))
+
+	for _, dir := range models.GOPATHDIRECTORIES {
+		if !snapshot.exists(root + dir) {
+			continue
+		}
+
+		err = snapshot.copyService.CopyDir(root+dir, dest)
+		if err != nil {
+			snapshot.fileSystem.RemoveAll(dest)
+			return taken, err
+		}
+	}
+
+	for _, file := range models.ENVIRONMENTFILES {
+		if !snapshot.exists(root + file) {
+			continue
+		}
+
+		err = snapshot.copyService.CopyFile(root+file, dest)
+		if err != nil {
+			snapshot.fileSystem.RemoveAll(dest)
+			return taken, err
+		}
+	}
+
+	taken = models.Snapshot{
+		ID:          id,
+		Environment: name,
+		Label:       label,
+		Created:     now,
+	}
+
+	err = snapshot.configService.WriteSnapshot(dest+"snapshot.toml", taken)
+	if err != nil {
+		snapshot.fileSystem.RemoveAll(dest)
+		return taken, err
+	}
+
+	err = writeManifest(snapshot.configService, snapshot.manifestService, dest)
+	if err != nil {
+		snapshot.fileSystem.RemoveAll(dest)
+		return taken, err
+	}
+
+	snapshot.prune(name)
+
+	return taken, nil
+}
+
+// prune removes the oldest snapshots of the environment name beyond the retention limit.
+func (snapshot *SnapshotCommand) prune(name string) {
+	if snapshot.keep <= 0 {
+		return
+	}
+
+	snapshots := listSnapshots(snapshot.configService, snapshot.fileSystem, snapshot.gobopath, name)
+	for i := 0; i < len(snapshots)-snapshot.keep; i++ {
+		snapshot.logger.Info("Removing old snapshot " + snapshots[i].ID + " of " + name)
+		err := snapshot.fileSystem.RemoveAll(snapshotsPath(snapshot.gobopath, name) + snapshots[i].ID)
+		if err != nil {
+			snapshot.logger.Warn("Unable to remove old snapshot " + snapshots[i].ID + ": " + err.Error())
+		}
+	}
+}
+
+func (snapshot *SnapshotCommand) exists(path string) bool {
+	_, err := snapshot.fileSystem.Stat(path)
+
+	return err == nil
+}
+
+// snapshotsPath returns the directory holding the snapshots of the environment name.
+func snapshotsPath(gobopath string, name string) string {
+	return gobopath + "snapshots/" + name + "/"
+}
+
+// listSnapshots returns the snapshots of the environment name, oldest first by id. Directories without readable
+// snapshot metadata are skipped.
+func listSnapshots(
+	configService utils.IConfigService,
+	fileSystem utils.IFileSystem,
+	gobopath string,
+	name string,
+) []models.Snapshot {
+	var snapshots []models.Snapshot
+
+	dirs, _ := fileSystem.ReadDir(snapshotsPath(gobopath, name))
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+
+		snapshot, err := configService.ReadSnapshot(snapshotsPath(gobopath, name) + dir.Name() + "/snapshot.toml")
+		if err != nil {
+			continue
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].ID < snapshots[j].ID
+	})
+
+	return snapshots
+}
+
+// findSnapshot returns the snapshot of the environment name whose id is ref, or the newest one labelled ref.
+func findSnapshot(
+	configService utils.IConfigService,
+	fileSystem utils.IFileSystem,
+	gobopath string,
+	name string,
+	ref string,
+) (models.Snapshot, error) {
+	snapshots := listSnapshots(configService, fileSystem, gobopath, name)
+
+	for _, snapshot := range snapshots {
+		if snapshot.ID == ref {
+			return snapshot, nil
+		}
+	}
+
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if snapshots[i].Label == ref {
+			return snapshots[i], nil
+		}
+	}
+
+	return models.Snapshot{}, errors.New(name + " has no snapshot called " + ref)
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"testing"
+)
+
+func (f *fixture) snapshot(name string, label string, keep int) string {
+	snapshot := GetSnapshotCommand(
+		f.logger,
+		f.configService,
+		f.copyService,
+		f.manifestService,
+		f.fileSystem,
+		keep,
+		testGopath,
+		testGobo,
+	)
+
+	taken, err := snapshot.Run(name, label)
+	if err != nil {
+		f.t.Fatalf("snapshot returned %v", err)
+	}
+
+	return taken.ID
+}
+
+func TestRollbackRestoresSnapshot(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.write(testGopath+"src/example.com/app/main.go", "version one")
+
+	f.fileSystem.RemoveAll(testGopath + "packages.lock")
+	id := f.snapshot("dev", "stable", 0)
+
+	f.write(testGopath+"src/example.com/app/main.go", "version two")
+	f.write(testGopath+"packages.lock", "# locks version two\n")
+	f.write(testGopath+"src/example.com/extra/extra.go", "extra")
+
+	rollback := GetRollbackCommand(
+		f.logger,
+		f.configService,
+		f.copyService,
+		f.manifestService,
+		f.fileSystem,
+		0,
+		false,
+		testGopath,
+		testGobo,
+	)
+	if err := rollback.Run("dev", "stable"); err != nil {
+		t.Fatalf("rollback returned %v", err)
+	}
+
+	data, err := f.fileSystem.ReadFile(testGopath + "src/example.com/app/main.go")
+	if err != nil || string(data) != "version one" {
+		t.Errorf("main.go reads %q, %v after rollback", data, err)
+	}
+
+	if f.exists(testGopath + "src/example.com/extra") {
+		t.Error("files added after the snapshot survived the rollback")
+	}
+
+	if f.exists(testGopath + "packages.lock") {
+		t.Error("the lock written after the snapshot survived the rollback")
+	}
+
+	if name := f.activeName(); name != "dev" {
+		t.Errorf("active environment is %q after rollback", name)
+	}
+
+	snapshots := listSnapshots(f.configService, f.fileSystem, testGobo, "dev")
+	if len(snapshots) != 2 || snapshots[0].ID != id || snapshots[1].Label != "before-rollback-"+id {
+		t.Errorf("snapshots after rollback are %+v", snapshots)
+	}
+}
+
+func TestSnapshotRetention(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		ids = append(ids, f.snapshot("dev", "", 2))
+	}
+
+	snapshots := listSnapshots(f.configService, f.fileSystem, testGobo, "dev")
+	if len(snapshots) != 2 {
+		t.Fatalf("kept %d snapshots, want 2", len(snapshots))
+	}
+
+	if snapshots[0].ID != ids[2] || snapshots[1].ID != ids[3] {
+		t.Errorf("kept %s and %s, want the newest %s and %s", snapshots[0].ID, snapshots[1].ID, ids[2], ids[3])
+	}
+}
+
+func TestSnapshotUnknownEnvironment(t *testing.T) {
+	f := newFixture(t)
+
+	snapshot := GetSnapshotCommand(
+		f.logger,
+		f.configService,
+		f.copyService,
+		f.manifestService,
+		f.fileSystem,
+		0,
+		testGopath,
+		testGobo,
+	)
+	if _, err := snapshot.Run("nope", ""); err == nil {
+		t.Error("snapshotting a missing environment succeeded")
+	}
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// ISnapshotsCommand is the interface to implement for listing environment snapshots.
+type ISnapshotsCommand interface {
+	Run(name string) error
+}
+
+// SnapshotsCommand is the struct for this implementation of ISnapshotsCommand.
+type SnapshotsCommand struct {
+	logger        utils.ILogger
+	configService utils.IConfigService
+	fileSystem    utils.IFileSystem
+	gobopath      string
+}
+
+// GetSnapshotsCommand returns a pointer to an implementation of ISnapshotsCommand.
+func GetSnapshotsCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	fileSystem utils.IFileSystem,
+	gobopath string,
+) *SnapshotsCommand {
+	snapshots := SnapshotsCommand{
+		logger,
+		configService,
+		fileSystem,
+		gobopath,
+	}
+
+	return &snapshots
+}
+
+// Run prints the snapshots of the environment name, or of every environment when name is empty.
+func (snapshots *SnapshotsCommand) Run(name string) error {
+
+	names := []string{name}
+	if name == "" {
+		names = nil
+		dirs, _ := snapshots.fileSystem.ReadDir(snapshots.gobopath + "snapshots")
+		for _, dir := range dirs {
+			if dir.IsDir() {
+				names = append(names, dir.Name())
+			}
+		}
+	}
+
+	count := 0
+	for _, env := range names {
+		for _, snapshot := range listSnapshots(snapshots.configService, snapshots.fileSystem, snapshots.gobopath, env) {
+			if count == 0 {
+				fmt.Printf("%-20s %-20s %-25s %s\n", "ENVIRONMENT", "SNAPSHOT", "CREATED", "LABEL")
+			}
+			count++
+
+			fmt.Printf(
+				"%-20s %-20s %-25s %s\n",
+				snapshot.Environment,
+				snapshot.ID,
+				snapshot.Created.Format(time.RFC3339),
+				snapshot.Label,
+			)
+		}
+	}
+
+	if count == 0 {
+		fmt.Println("No snapshots found.")
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// activateScript is the script in the gobo home a shell sources to pick up the active environment's settings.
+const activateScript = "activate.sh"
+
+// resolveToolchain returns the GOROOT of the toolchain env is pinned to, or an empty string when it isn't pinned.
+func resolveToolchain(toolchainService utils.IToolchainService, env models.Environment) (string, error) {
+	if env.GoVersion == "" {
+		return "", nil
+	}
+
+	return toolchainService.Resolve(env.GoVersion)
+}
+
+// writeActivateScript writes the activate script for env. Sourcing it undoes what the previous script changed,
+// exports the environment's variables and points GOROOT and PATH at goroot when it is set.
+func writeActivateScript(fileSystem utils.IFileSystem, gobopath string, env models.Environment, goroot string) error {
+	lines := []string{
+		"# Written by gobo when " + env.Name + " was activated, source it to use the environment's settings.",
+		`if [ -n "${GOBO_OLD_PATH+x}" ]; then`,
+		`    PATH="$GOBO_OLD_PATH"`,
+		`    unset GOBO_OLD_PATH`,
+		`fi`,
+		`if [ -n "${GOBO_OLD_GOROOT+x}" ]; then`,
+		`    if [ -n "$GOBO_OLD_GOROOT" ]; then export GOROOT="$GOBO_OLD_GOROOT"; else unset GOROOT; fi`,
+		`    unset GOBO_OLD_GOROOT`,
+		`fi`,
+		`for key in ${GOBO_ENV_KEYS-}; do unset "$key"; done`,
+		`unset GOBO_ENV_KEYS`,
+		"export GOBO_ENV=" + shellQuote(env.Name),
+	}
+
+	keys := utils.VariableNames(env)
+	for _, key := range keys {
+		lines = append(lines, "export "+key+"="+shellQuote(env.Env[key]))
+	}
+	if len(keys) > 0 {
+		lines = append(lines, "export GOBO_ENV_KEYS="+shellQuote(strings.Join(keys, " ")))
+	}
+
+	if goroot != "" {
+		lines = append(lines,
+			`export GOBO_OLD_PATH="$PATH"`,
+			`export GOBO_OLD_GOROOT="${GOROOT-}"`,
+			"export GOROOT="+shellQuote(goroot),
+			"export PATH="+shellQuote(goroot+"/bin")+`:"$PATH"`,
+		)
+	}
+
+	script := strings.Join(lines, "\n") + "\n"
+
+	return fileSystem.WriteFile(gobopath+activateScript, []byte(script), models.FILEMODE)
+}
+
+// shellQuote quotes value for a POSIX shell.
+func shellQuote(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// addToolchainArchive places a Go distribution archive for version in the toolchains directory.
+func (f *fixture) addToolchainArchive(version string) {
+	var buf bytes.Buffer
+	compressed := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(compressed)
+
+	archive.WriteHeader(&tar.Header{Name: "go/", Typeflag: tar.TypeDir, Mode: 0755})
+	archive.WriteHeader(&tar.Header{Name: "go/bin/", Typeflag: tar.TypeDir, Mode: 0755})
+	archive.WriteHeader(&tar.Header{Name: "go/bin/go", Typeflag: tar.TypeReg, Mode: 0755, Size: 2})
+	archive.Write([]byte("go"))
+	archive.Close()
+	compressed.Close()
+
+	f.write(testGobo+"toolchains/"+version+"."+runtime.GOOS+"-"+runtime.GOARCH+".tar.gz", buf.String())
+}
+
+func (f *fixture) activateScript() string {
+	data, err := f.fileSystem.ReadFile(testGobo + activateScript)
+	if err != nil {
+		f.t.Fatalf("reading the activate script: %v", err)
+	}
+
+	return string(data)
+}
+
+func TestCreatePinnedEnvironment(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.createPinned("dev", true, "1.99.0"); err == nil {
+		t.Fatal("create accepted a toolchain which isn't available")
+	}
+	if f.exists(testGopath + "gobo.toml") {
+		t.Fatal("a refused create changed the GOPATH")
+	}
+
+	f.addToolchainArchive("go1.99.0")
+
+	if err := f.createPinned("dev", true, "1.99.0"); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	if !f.exists(testGobo + "toolchains/go1.99.0/bin/go") {
+		t.Fatal("the toolchain was not unpacked")
+	}
+
+	env, _ := f.configService.ReadEnvironment(testGopath + "gobo.toml")
+	if env.GoVersion != "go1.99.0" {
+		t.Errorf("expected the environment to be pinned to go1.99.0, got %q", env.GoVersion)
+	}
+
+	if !strings.Contains(f.activateScript(), "export GOROOT='"+testGobo+"toolchains/go1.99.0'") {
+		t.Errorf("the activate script does not select the toolchain:\n%s", f.activateScript())
+	}
+}
+
+func TestActivateSelectsToolchain(t *testing.T) {
+	f := newFixture(t)
+	f.addToolchainArchive("go1.99.0")
+
+	if err := f.createPinned("dev", true, "go1.99.0"); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	if err := f.create("web", false); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	if strings.Contains(f.activateScript(), "export GOROOT='") {
+		t.Error("an unpinned environment sets GOROOT")
+	}
+
+	if err := f.activate("dev"); err != nil {
+		t.Fatalf("activate returned %v", err)
+	}
+
+	if !strings.Contains(f.activateScript(), "export GOROOT='"+testGobo+"toolchains/go1.99.0'") {
+		t.Errorf("the activate script does not select the toolchain:\n%s", f.activateScript())
+	}
+}
@@ -0,0 +1,283 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// IUpdateCommand is the interface to implement for moving packages to newer revisions.
+type IUpdateCommand interface {
+	Run(paths []string) error
+}
+
+// UpdateCommand is the struct for this implementation of IUpdateCommand.
+type UpdateCommand struct {
+	logger          utils.ILogger
+	configService   utils.IConfigService
+	packageService  utils.IPackageService
+	resolverService utils.IResolverService
+	to              string
+	dryRun          bool
+	gopath          string
+	separator       string
+}
+
+// packageUpdate is the move of one package from one revision to another.
+type packageUpdate struct {
+	path string
+	from string
+	to   string
+	err  error
+
+	// version is the constraint packages.toml places on the package, if any.
+	version string
+}
+
+// GetUpdateCommand returns a pointer to an implementation of IUpdateCommand. A non empty to is the revision the
+// package is moved to, with dryRun set the updates are only reported.
+func GetUpdateCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	packageService utils.IPackageService,
+	resolverService utils.IResolverService,
+	to string,
+	dryRun bool,
+	gopath string,
+	separator string,
+) *UpdateCommand {
+	var updateCommand = UpdateCommand{
+		logger,
+		configService,
+		packageService,
+		resolverService,
+		to,
+		dryRun,
+		gopath,
+		separator,
+	}
+
+	return &updateCommand
+}
+
+// Run fetches the packages at paths, or every locked package when none are given, checks each out at the -to
+// revision, the revision resolved from its requirements in packages.toml, or else its newest tag or the tip of
+// its branch, installs it and regenerates packages.lock.
+func (updateCommand *UpdateCommand) Run(paths []string) error {
+
+	if updateCommand.to != "" && len(paths) != 1 {
+		return errors.New("-to needs exactly one package to update")
+	}
+
+	env, err := updateCommand.configService.ReadEnvironment(updateCommand.gopath + "gobo.toml")
+	if err != nil {
+		return err
+	}
+
+	updateCommand.packageService.SetEnvironment(utils.EnvironmentVariables(env))
+	updateCommand.packageService.CheckGoVersion(env.GoVersion)
+
+	pakFile := updateCommand.gopath + "packages.toml"
+	manifest, err := updateCommand.configService.ReadPackages(pakFile)
+	if err != nil && !utils.IsConfigNotFound(err) {
+		return err
+	}
+
+	lock, err := updateCommand.configService.ReadLock(updateCommand.gopath + "packages.lock")
+	if utils.IsConfigNotFound(err) {
+		lock = updateCommand.packageService.GenerateLock(manifest.Package, env.Projects)
+	} else if err != nil {
+		return err
+	}
+
+	packages := lockedPackages(manifest.Package, lock)
+
+	targets, err := selectPackages(packages, paths)
+	if err != nil {
+		return err
+	}
+
+	updates := make([]packageUpdate, len(targets))
+	for n, i := range targets {
+		updates[n] = packageUpdate{path: packages[i].Path, from: packages[i].Revision, version: packages[i].Version}
+		updates[n].err = updateCommand.packageService.Fetch(packages[i].Path)
+	}
+
+	// only the targets are resolved, a conflict elsewhere is no reason to hold them back
+	targetPaths := make([]string, len(updates))
+	for n, result := range updates {
+		targetPaths[n] = result.path
+	}
+	resolved, failures := updateCommand.resolverService.ResolvePaths(packages, targetPaths)
+
+	failed := 0
+	for n := range targets {
+		result := &updates[n]
+		if result.err == nil {
+			result.err = failures[result.path]
+		}
+		if result.err == nil {
+			updateCommand.update(result, resolved[result.path])
+		}
+
+		if result.err != nil {
+			updateCommand.logger.Error("Error updating " + result.path + " because: " + result.err.Error())
+			failed++
+		}
+	}
+
+	updateCommand.report(updates)
+
+	if !updateCommand.dryRun {
+		err = updateCommand.configService.WriteLock(
+			updateCommand.gopath+"packages.lock",
+			updateCommand.packageService.GenerateLock(manifest.Package, env.Projects),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return errors.New("unable to update " + strconv.Itoa(failed) + " of " + strconv.Itoa(len(updates)) + " packages")
+	}
+
+	return nil
+}
+
+// update moves one fetched package to its target revision, or only works the target out on a dry run. resolved
+// is the revision its requirements select, if it has any.
+func (updateCommand *UpdateCommand) update(result *packageUpdate, resolved string) {
+	dir := updateCommand.gopath + "src" + updateCommand.separator + result.path
+
+	if updateCommand.to != "" {
+		result.err = updateCommand.checkConstraint(result)
+		if result.err != nil {
+			return
+		}
+	}
+
+	result.to = updateCommand.to
+	if result.to == "" {
+		result.to = resolved
+	}
+	if result.to == "" {
+		tagged, _ := updateCommand.packageService.IsATag(dir)
+		result.to, result.err = updateCommand.packageService.LatestRevision(result.path, tagged)
+		if result.err != nil {
+			return
+		}
+	}
+
+	if result.to == result.from || updateCommand.dryRun {
+		return
+	}
+
+	result.err = updateCommand.packageService.Checkout(result.path, result.to)
+	if result.err != nil {
+		return
+	}
+
+	// record what was checked out, a branch name given to -to becomes the tag or hash it points at
+	result.to = updateCommand.packageService.DetermineBookmark(dir)
+
+	result.err = updateCommand.packageService.Install(result.path)
+}
+
+// checkConstraint refuses a -to revision which is a version outside the constraint packages.toml places on the
+// package. A branch or hash given to -to can't be checked, so it is only warned about.
+func (updateCommand *UpdateCommand) checkConstraint(result *packageUpdate) error {
+	if result.version == "" {
+		return nil
+	}
+
+	constraint, err := utils.ParseConstraint(result.version)
+	if err != nil {
+		return errors.New("packages.toml: " + err.Error())
+	}
+
+	version, err := utils.ParseVersion(updateCommand.to)
+	if err != nil {
+		updateCommand.logger.Warn(updateCommand.to + " is not a version, so it can't be checked against the " +
+			result.version + " constraint packages.toml places on " + result.path)
+		return nil
+	}
+
+	if !constraint.Check(version) {
+		return errors.New(updateCommand.to + " does not satisfy the " + result.version + " constraint packages.toml " +
+			"places on " + result.path + ", change the constraint first")
+	}
+
+	return nil
+}
+
+func (updateCommand *UpdateCommand) report(updates []packageUpdate) {
+	if updateCommand.dryRun {
+		fmt.Println("gobo update would make these changes, nothing was changed:")
+	}
+
+	for _, result := range updates {
+		switch {
+		case result.err != nil:
+			fmt.Printf("    %-50s failed: %s\n", result.path, result.err)
+		case result.from == result.to:
+			fmt.Printf("    %-50s %s (up to date)\n", result.path, result.from)
+		default:
+			fmt.Printf("    %-50s %s → %s\n", result.path, result.from, result.to)
+		}
+	}
+}
+
+// selectPackages returns the indexes of the packages named by paths, or of every package when paths is empty.
+func selectPackages(packages []models.Package, paths []string) ([]int, error) {
+	var indexes []int
+
+	if len(paths) == 0 {
+		for i := range packages {
+			indexes = append(indexes, i)
+		}
+
+		return indexes, nil
+	}
+
+	for _, path := range paths {
+		found := false
+		for i, pak := range packages {
+			if pak.Path == path {
+				indexes = append(indexes, i)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, errors.New(path + " is not in packages.lock, run gobo save first")
+		}
+	}
+
+	return indexes, nil
+}
+
+// lockedPackages returns the packages of lock with the requirements manifest places on them.
+func lockedPackages(manifest []models.Package, lock models.PackageLock) []models.Package {
+	requirements := map[string]models.Package{}
+	for _, pak := range manifest {
+		requirements[pak.Path] = pak
+	}
+
+	var packages []models.Package
+	for _, locked := range lock.Package {
+		packages = append(packages, models.Package{
+			Path:     locked.Path,
+			Origin:   locked.Origin,
+			Revision: locked.Revision,
+			Version:  requirements[locked.Path].Version,
+			Branch:   requirements[locked.Path].Branch,
+		})
+	}
+
+	return packages
+}
@@ -0,0 +1,205 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/camronlevanger/gobo/models"
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// addUpdatable locks a tagged and a branch package in packages.lock and scripts the git commands which move
+// them to v1.1.0 and def456.
+func (f *fixture) addUpdatable() {
+	lib := testGopath + "src/example.com/lib"
+	tool := testGopath + "src/example.com/tool"
+	f.mkdir(lib + "/.git")
+	f.mkdir(tool + "/.git")
+
+	err := f.configService.WritePackages(testGopath+"packages.toml", models.Dependencies{Package: []models.Package{
+		{Path: "example.com/lib"},
+		{Path: "example.com/tool"},
+	}})
+	if err != nil {
+		f.t.Fatalf("writing packages: %v", err)
+	}
+
+	err = f.configService.WriteLock(testGopath+"packages.lock", models.PackageLock{Package: []models.LockedPackage{
+		{Path: "example.com/lib", Origin: "example.com/lib", Revision: "v1.0.0"},
+		{Path: "example.com/tool", Origin: "example.com/tool", Revision: "abc123"},
+	}})
+	if err != nil {
+		f.t.Fatalf("writing lock: %v", err)
+	}
+
+	notATag := errors.New("exit status 128")
+
+	f.runner.Script(
+		utils.FakeCommand{Command: "git fetch --tags"},
+		utils.FakeCommand{Command: "git describe --exact-match", Dir: lib, Stdout: "v1.0.0\n", Once: true},
+		utils.FakeCommand{Command: "git describe --exact-match", Dir: lib, Stdout: "v1.1.0\n"},
+		utils.FakeCommand{Command: "git tag --list --sort=-v:refname", Dir: lib, Stdout: "v1.2.0-rc1\nv1.1.0\nv1.0.0\n"},
+		utils.FakeCommand{Command: "git describe --exact-match", Dir: tool, Err: notATag},
+		utils.FakeCommand{Command: "git rev-parse --abbrev-ref --symbolic-full-name @{u}", Dir: tool, Err: notATag},
+		utils.FakeCommand{Command: "git rev-parse --abbrev-ref origin/HEAD", Dir: tool, Stdout: "origin/master\n"},
+		utils.FakeCommand{Command: "git rev-parse origin/master", Dir: tool, Stdout: "def456\n"},
+		utils.FakeCommand{Command: "git rev-parse HEAD", Dir: tool, Stdout: "def456\n"},
+		utils.FakeCommand{Command: "git checkout v1.1.0", Dir: lib},
+		utils.FakeCommand{Command: "git checkout def456", Dir: tool},
+		utils.FakeCommand{Command: "go install example.com/lib"},
+		utils.FakeCommand{Command: "go install example.com/tool"},
+	)
+}
+
+func (f *fixture) update(to string, dryRun bool) *UpdateCommand {
+	return GetUpdateCommand(f.logger, f.configService, f.packageService, f.resolverService, to, dryRun, testGopath, "/")
+}
+
+func (f *fixture) revisions() map[string]string {
+	lock, err := f.configService.ReadLock(testGopath + "packages.lock")
+	if err != nil {
+		f.t.Fatalf("reading lock: %v", err)
+	}
+
+	revisions := map[string]string{}
+	for _, p := range lock.Package {
+		revisions[p.Path] = p.Revision
+	}
+
+	return revisions
+}
+
+func TestUpdateMovesToNewestTagAndBranchTip(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.addUpdatable()
+
+	if err := f.update("", false).Run(nil); err != nil {
+		t.Fatalf("update returned %v", err)
+	}
+
+	revisions := f.revisions()
+	if revisions["example.com/lib"] != "v1.1.0" {
+		t.Errorf("expected lib at the newest release v1.1.0, got %s", revisions["example.com/lib"])
+	}
+	if revisions["example.com/tool"] != "def456" {
+		t.Errorf("expected tool at the branch tip def456, got %s", revisions["example.com/tool"])
+	}
+
+	if !f.runner.Ran("go install example.com/lib") || !f.runner.Ran("go install example.com/tool") {
+		t.Error("update did not install the updated packages")
+	}
+}
+
+func TestUpdateDryRunChangesNothing(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.addUpdatable()
+
+	if err := f.update("", true).Run([]string{"example.com/lib"}); err != nil {
+		t.Fatalf("update returned %v", err)
+	}
+
+	if f.runner.Ran("git checkout v1.1.0") {
+		t.Error("a dry run checked out the new revision")
+	}
+	if f.revisions()["example.com/lib"] != "v1.0.0" {
+		t.Error("a dry run changed packages.lock")
+	}
+
+	if err := f.update("v1.1.0", false).Run(nil); err == nil {
+		t.Error("update accepted -to without a package")
+	}
+	if err := f.update("", false).Run([]string{"example.com/missing"}); err == nil {
+		t.Error("update accepted a package missing from packages.lock")
+	}
+}
+
+func TestUpdateStaysWithinVersionConstraint(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.addUpdatable()
+
+	pak, _ := f.configService.ReadPackages(testGopath + "packages.toml")
+	pak.Package[0].Version = "~1.0"
+	if err := f.configService.WritePackages(testGopath+"packages.toml", pak); err != nil {
+		t.Fatalf("writing packages: %v", err)
+	}
+
+	if err := f.update("", false).Run([]string{"example.com/lib"}); err != nil {
+		t.Fatalf("update returned %v", err)
+	}
+
+	if f.runner.Ran("git checkout v1.1.0") {
+		t.Error("update moved lib past its ~1.0 constraint")
+	}
+	if f.revisions()["example.com/lib"] != "v1.0.0" {
+		t.Errorf("expected lib to stay at v1.0.0, got %s", f.revisions()["example.com/lib"])
+	}
+}
+
+func TestUpdateIgnoresConflictsOfOtherPackages(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.addUpdatable()
+
+	// the tags of tool can't be listed, so its ^2 requirement can't be resolved
+	pak, _ := f.configService.ReadPackages(testGopath + "packages.toml")
+	pak.Package[1].Version = "^2"
+	if err := f.configService.WritePackages(testGopath+"packages.toml", pak); err != nil {
+		t.Fatalf("writing packages: %v", err)
+	}
+	f.runner.Script(utils.FakeCommand{
+		Command: "git tag --list --sort=-v:refname",
+		Dir:     testGopath + "src/example.com/tool",
+		Err:     errors.New("exit status 128"),
+	})
+
+	if err := f.update("", false).Run([]string{"example.com/lib"}); err != nil {
+		t.Fatalf("update returned %v", err)
+	}
+	if f.revisions()["example.com/lib"] != "v1.1.0" {
+		t.Errorf("expected lib at v1.1.0, got %s", f.revisions()["example.com/lib"])
+	}
+
+	if err := f.update("", false).Run([]string{"example.com/tool"}); err == nil {
+		t.Error("update moved tool without resolving its ^2 requirement")
+	}
+}
+
+func TestUpdateRefusesToOutsideVersionConstraint(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+	f.addUpdatable()
+
+	pak, _ := f.configService.ReadPackages(testGopath + "packages.toml")
+	pak.Package[0].Version = "~1.0"
+	if err := f.configService.WritePackages(testGopath+"packages.toml", pak); err != nil {
+		t.Fatalf("writing packages: %v", err)
+	}
+
+	if err := f.update("v1.1.0", false).Run([]string{"example.com/lib"}); err == nil {
+		t.Error("update accepted a -to revision outside the ~1.0 constraint")
+	}
+	if f.runner.Ran("git checkout v1.1.0") {
+		t.Error("update checked out a revision outside the ~1.0 constraint")
+	}
+	if f.revisions()["example.com/lib"] != "v1.0.0" {
+		t.Errorf("expected lib to stay at v1.0.0, got %s", f.revisions()["example.com/lib"])
+	}
+}
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// IVerifyCommand is the interface to implement for checking backups and snapshots against their manifests.
+type IVerifyCommand interface {
+	Run(name string, ref string) error
+}
+
+// VerifyCommand is the struct for this implementation of IVerifyCommand.
+type VerifyCommand struct {
+	logger          utils.ILogger
+	configService   utils.IConfigService
+	manifestService utils.IManifestService
+	fileSystem      utils.IFileSystem
+	gobopath        string
+}
+
+// GetVerifyCommand returns a pointer to an implementation of IVerifyCommand.
+func GetVerifyCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	manifestService utils.IManifestService,
+	fileSystem utils.IFileSystem,
+	gobopath string,
+) *VerifyCommand {
+	verify := VerifyCommand{
+		logger,
+		configService,
+		manifestService,
+		fileSystem,
+		gobopath,
+	}
+
+	return &verify
+}
+
+// Run verifies the initial backup, or the snapshot ref of the environment name when one is given, and
+// returns an error listing every problem found.
+func (verify *VerifyCommand) Run(name string, ref string) error {
+
+	root := verify.gobopath + "initial/"
+	what := "the initial backup"
+
+	if name != "" {
+		if ref == "" {
+			return errors.New("a snapshot is required to verify a snapshot of " + name)
+		}
+
+		snapshot, err := findSnapshot(verify.configService, verify.fileSystem, verify.gobopath, name, ref)
+		if err != nil {
+			return err
+		}
+
+		root = snapshotsPath(verify.gobopath, name) + snapshot.ID + "/"
+		what = "snapshot " + snapshot.ID + " of " + name
+	}
+
+	err := verifyManifest(verify.configService, verify.manifestService, root)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(what + " matches its manifest.")
+
+	return nil
+}
+
+// writeManifest records the contents of root in root/manifest.toml.
+func writeManifest(
+	configService utils.IConfigService,
+	manifestService utils.IManifestService,
+	root string,
+) error {
+	manifest, err := manifestService.Build(root)
+	if err != nil {
+		return errors.New("unable to build a manifest of " + root + ": " + err.Error())
+	}
+
+	return configService.WriteManifest(root+utils.MANIFESTFILE, manifest)
+}
+
+// verifyManifest checks root against root/manifest.toml and returns an error describing every difference.
+func verifyManifest(
+	configService utils.IConfigService,
+	manifestService utils.IManifestService,
+	root string,
+) error {
+	manifest, err := configService.ReadManifest(root + utils.MANIFESTFILE)
+	if utils.IsConfigNotFound(err) {
+		return errors.New(
+			root + " does not exist or has no manifest and can't be verified, backups made before gobo recorded manifests may have been cut short",
+		)
+	}
+	if err != nil {
+		return errors.New("unable to read the manifest of " + root + ": " + err.Error())
+	}
+
+	problems := manifestService.Verify(root, manifest)
+	if len(problems) == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("%s does not match its manifest, %d problems found:", root, len(problems))
+	for _, problem := range problems {
+		message += "\n    " + problem.String()
+	}
+
+	return errors.New(message)
+}
@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/camronlevanger/gobo/utils"
+)
+
+// IWhyCommand is the interface to implement for explaining why a package is in an environment.
+type IWhyCommand interface {
+	Run(path string) error
+}
+
+// WhyCommand is the struct for this implementation of IWhyCommand.
+type WhyCommand struct {
+	logger         utils.ILogger
+	configService  utils.IConfigService
+	packageService utils.IPackageService
+	importService  utils.IImportService
+	gopath         string
+}
+
+// GetWhyCommand returns a pointer to an implementation of IWhyCommand.
+func GetWhyCommand(
+	logger utils.ILogger,
+	configService utils.IConfigService,
+	packageService utils.IPackageService,
+	importService utils.IImportService,
+	gopath string,
+) *WhyCommand {
+	var why = WhyCommand{
+		logger,
+		configService,
+		packageService,
+		importService,
+		gopath,
+	}
+
+	return &why
+}
+
+// Run prints the shortest import chains from the active environment's own code to the package at path, or to
+// the packages of the repository at path, or says that nothing imports it. The own code is decided as for the
+// direct packages of packages.lock.
+func (why *WhyCommand) Run(path string) error {
+
+	path = strings.TrimSuffix(path, "/")
+	if path == "" {
+		return errors.New("name the package to explain, for example gobo why github.com/pkg/errors")
+	}
+
+	env, err := why.configService.ReadEnvironment(why.gopath + "gobo.toml")
+	if err != nil {
+		return err
+	}
+
+	repositories := why.packageService.Repositories()
+
+	graph, err := why.importService.Graph(repositories)
+	if err != nil {
+		return errors.New("unable to read the imports of the environment: " + err.Error())
+	}
+
+	found := false
+	for pkg := range graph.Imports {
+		found = found || utils.Containing(pkg, []string{path}) != ""
+	}
+	if !found {
+		return errors.New("no package in " + why.gopath + "src is at or below " + path)
+	}
+
+	owned := utils.OwnedNodes(utils.NodeGraph(graph), repositories, env.Projects)
+	from := map[string]bool{}
+	for pkg := range graph.Imports {
+		from[pkg] = owned[graph.Node(pkg)]
+	}
+
+	chains := utils.ImportChains(graph, from, path)
+	if len(chains) == 0 {
+		fmt.Println("Nothing in " + env.Name + " imports " + path + ".")
+		return nil
+	}
+
+	fmt.Println(path + " is imported by " + env.Name + " through:")
+	for _, chain := range chains {
+		fmt.Println("    " + strings.Join(chain, " → "))
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// why runs gobo why for path and returns what it printed.
+func (f *fixture) why(path string) (string, error) {
+	output, err := os.Create(filepath.Join(f.t.TempDir(), "output"))
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	defer output.Close()
+
+	stdout := os.Stdout
+	os.Stdout = output
+	defer func() { os.Stdout = stdout }()
+
+	err = GetWhyCommand(f.logger, f.configService, f.packageService, f.importService, testGopath).Run(path)
+
+	printed, _ := ioutil.ReadFile(output.Name())
+
+	return string(printed), err
+}
+
+func TestWhyPrintsImportChains(t *testing.T) {
+	f := newFixture(t)
+
+	if err := f.create("dev", true); err != nil {
+		t.Fatalf("create returned %v", err)
+	}
+
+	f.write(testGopath+"src/example.com/me/app/main.go",
+		"package main\n\nimport (\n\t\"example.com/used/lib\"\n\t\"example.com/deep\"\n)\n\nfunc main() { lib.Do(); deep.Do() }\n")
+	f.write(testGopath+"src/example.com/me/tool/main.go",
+		"package main\n\nimport \"example.com/used/lib\"\n\nfunc main() { lib.Do() }\n")
+	f.addRepo(testGopath, "example.com/used", "v1.0.0")
+	f.write(testGopath+"src/example.com/used/lib/lib.go", "package lib\n\nimport \"example.com/deep/inner\"\n\nfunc Do() { inner.Do() }\n")
+	f.addRepo(testGopath, "example.com/deep", "v1.0.0")
+	f.write(testGopath+"src/example.com/deep/deep.go", "package deep\n\nfunc Do() {}\n")
+	f.write(testGopath+"src/example.com/deep/inner/inner.go", "package inner\n\nfunc Do() {}\n")
+	f.addRepo(testGopath, "example.com/stale", "v0.1.0")
+
+	printed, err := f.why("example.com/deep/")
+	if err != nil {
+		t.Fatalf("why returned %v", err)
+	}
+
+	want := "example.com/deep is imported by dev through:\n" +
+		"    example.com/me/app → example.com/deep\n" +
+		"    example.com/me/tool → example.com/used/lib → example.com/deep/inner\n"
+	if printed != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, printed)
+	}
+
+	printed, err = f.why("example.com/stale")
+	if err != nil {
+		t.Fatalf("why returned %v", err)
+	}
+	if printed != "Nothing in dev imports example.com/stale.\n" {
+		t.Errorf("why did not say that nothing imports example.com/stale, got %q", printed)
+	}
+
+	if _, err := f.why("example.com/missing"); err == nil || !strings.Contains(err.Error(), "is at or below example.com/missing") {
+		t.Errorf("expected an error for a package missing from the GOPATH, got %v", err)
+	}
+	if _, err := f.why(""); err == nil {
+		t.Error("why accepted an empty path")
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/camronlevanger/go-homedir"
 	"github.com/camronlevanger/gobo/commands"
@@ -27,6 +29,28 @@ func main() {
 	var goboInitial string
 	var goboMaster string
 	var initial bool
+	var wait bool
+	var lockTimeout time.Duration
+	var logLevel string
+	var keep int
+	var force bool
+	var to string
+	var yes bool
+	var sortBy string
+	var asJSON bool
+	var top int
+	var goVersion string
+	var unset bool
+	var dryRun bool
+	var logFormat string
+	var roots string
+	var format string
+	var root string
+	var packages bool
+	var standard bool
+	var deny string
+	var database string
+	var binaries bool
 
 	separator = string(filepath.Separator)
 
@@ -40,14 +64,13 @@ func main() {
 	gobo = home + separator + ".gobo" + separator
 	goboInitial = gobo + "initial" + separator
 	goboMaster = gobo + "gobo_master.toml"
-	gopath = os.Getenv("GOPATH") + separator
-
-	// print a gobo logo
-	fmt.Println(models.GOBOSPEED + "\n")
 
 	flag.Usage = func() {
 		fmt.Printf("Usage of %s:\n", os.Args[0])
-		fmt.Printf("    gobo create|delete|activate|save|get|list|install|tools [name] [args] ...\n")
+		for _, command := range models.COMMANDS {
+			fmt.Printf("    gobo %s\n        %s\n", strings.TrimSpace(command.Name+" "+command.Synopsis()), command.Summary)
+		}
+		fmt.Printf("Flags:\n")
 		flag.PrintDefaults()
 	}
 
@@ -60,21 +83,159 @@ func main() {
 
 	flag.BoolVar(&verbose, "v", false, "Print debug info to the console as it happens.")
 
-	flag.StringVar(&file, "f", "gobo.toml", "The gobo toml file to install from.")
+	flag.StringVar(
+		&file,
+		"f",
+		"",
+		"The packages.lock, or packages.toml manifest, to install from. Defaults to the active environment's lock.",
+	)
+
+	flag.StringVar(&logLevel, "log-level", "", "Lowest level printed to the console: debug, info, warn or error.")
+
+	flag.StringVar(&logFormat, "log-format", "text", "Format of log messages: text or json.")
+
+	flag.IntVar(
+		&keep,
+		"keep",
+		models.DEFAULTSNAPSHOTRETENTION,
+		"Number of snapshots kept per environment, 0 keeps them all.",
+	)
+
+	flag.BoolVar(&force, "force", false, "Restore or roll back even when the backup fails verification.")
+
+	flag.StringVar(
+		&to,
+		"to",
+		"",
+		"Extract the initial backup into this new or empty directory instead of restoring it, or the revision to update a package to.",
+	)
+
+	flag.BoolVar(&yes, "yes", false, "Delete what gc or prune finds without asking first.")
+
+	flag.BoolVar(&binaries, "binaries", false, "Let gc delete the binaries no package in src is named after, which it only lists otherwise.")
+
+	flag.StringVar(
+		&roots,
+		"roots",
+		"",
+		"Comma separated import paths prune keeps with everything they import, defaults to the environment's projects.",
+	)
+
+	flag.StringVar(&goVersion, "go", "", "Pin the new environment to this Go version, for example 1.21.3.")
+
+	flag.BoolVar(&dryRun, "dry-run", false, "Show what update would change, or prune would delete, without changing anything.")
+
+	flag.BoolVar(&unset, "unset", false, "Remove the named variables with setenv instead of setting them.")
+
+	flag.StringVar(&format, "format", "dot", "Format graph prints the import graph in: dot, json or mermaid.")
+
+	flag.StringVar(&root, "root", "", "Limit graph to what the packages at or below this import path import.")
+
+	flag.BoolVar(&packages, "packages", false, "Show every package in the graph instead of collapsing them into repositories.")
+
+	flag.BoolVar(&standard, "std", false, "Include the standard library in the graph.")
+
+	flag.StringVar(&sortBy, "sort", "size", "Sort the du report by size or name.")
+
+	flag.BoolVar(&asJSON, "json", false, "Print the du, licenses or audit report as JSON.")
+
+	flag.StringVar(&database, "db", "", "OSV advisory file, or directory of them, audit checks the packages against.")
+
+	flag.StringVar(&deny, "deny", "", "Comma separated licenses, or families such as GPL, which make licenses fail.")
+
+	flag.IntVar(&top, "top", 5, "Number of the largest packages du shows per environment.")
+
+	flag.BoolVar(&wait, "wait", false, "Wait for another running gobo command to finish instead of failing.")
+
+	flag.DurationVar(
+		&lockTimeout,
+		"lock-timeout",
+		30*time.Second,
+		"How long -wait waits for another gobo command to finish, 0 waits forever.",
+	)
 
 	flag.Parse()
 
-	logger := utils.GetLogger(verbose)
+	args := parseArgs()
+
+	// print a gobo logo, unless the output is meant for another program
+	if spec, _ := models.FindCommand(arg(args, 0)); !asJSON && !spec.Quiet {
+		fmt.Print(models.GOBOSPEED + "\n\n")
+	}
+
+	level := utils.WARN
+	if verbose {
+		level = utils.INFO
+	}
+
+	if logLevel != "" {
+		level, err = utils.ParseLogLevel(logLevel)
+		if err != nil {
+			FailOnError(err, "Invalid -log-level")
+		}
+	}
+
+	logger, err := utils.GetConfiguredLogger(utils.LogConfig{
+		Level:     level,
+		Format:    logFormat,
+		Directory: gobo + "logs",
+		MaxSize:   utils.DefaultLogMaxSize,
+		MaxFiles:  utils.DefaultLogMaxFiles,
+	})
+	if logger == nil {
+		FailOnError(err, "Invalid -log-format")
+	}
+	if err != nil {
+		logger.Warn("Unable to open the gobo log file, logging to the console only: " + err.Error())
+	}
+	defer logger.Close()
+
+	fileSystem := utils.GetFileSystem()
+	runner := utils.GetCommandRunner()
+
+	gopath, err = utils.GetGopathService(logger, fileSystem, runner, home, separator).Resolve()
+	if err != nil {
+		FailOnError(err, "Unable to determine a safe GOPATH")
+	}
 
 	logger.Info(fmt.Sprintf("GOPATH interpreted as %s", gopath))
 	logger.Info(fmt.Sprintf("populate: %v", populate))
 	logger.Info(fmt.Sprintf("verbose: %v", verbose))
-	logger.Info(fmt.Sprintf("Running cmd: %s", flag.Arg(0)))
-	logger.Info(fmt.Sprintf("Virtual Environment Name: %s", flag.Arg(1)))
+	logger.Info(fmt.Sprintf("Running cmd: %s", arg(args, 0)))
+	logger.Info(fmt.Sprintf("Virtual Environment Name: %s", arg(args, 1)))
+
+	command := arg(args, 0)
+	name := arg(args, 1)
+
+	if isMutating(command) {
+		err = os.MkdirAll(gobo, models.FILEMODE)
+		if err != nil {
+			FailOnError(err, "Unable to create the gobo home directory")
+		}
+
+		lock := utils.GetLockService(logger, gobo+"gobo.lock", wait, lockTimeout)
+
+		err = lock.Acquire(strings.Join(args, " "))
+		if err != nil {
+			FailOnError(err, "Unable to lock the gobo home directory")
+		}
+		defer lock.Release()
+
+		// logger.Fatal exits without running the deferred release
+		logger.OnExit(func() { lock.Release() })
+	}
+
+	promptService := utils.GetPromptService(os.Stdin, os.Stdout)
+	manifestService := utils.GetManifestService(logger, fileSystem)
+	toolchainService := utils.GetToolchainService(logger, fileSystem, gobo+"toolchains"+separator)
+	importService := utils.GetImportService(logger, fileSystem, gopath, separator)
 
 	backup := commands.GetBackupCommand(
 		logger,
-		utils.GetCopyService(),
+		utils.GetCopyService(fileSystem),
+		utils.GetConfigService(logger, fileSystem),
+		manifestService,
+		fileSystem,
 		home,
 		gopath,
 		gobo,
@@ -87,20 +248,17 @@ func main() {
 		logger.Fatal(fmt.Sprintf("Error creating initial backup of GOPATH: %v\n", err))
 	}
 
-	command := flag.Arg(0)
-	name := flag.Arg(1)
-
 	logger.Info("Switching on: " + command)
 
 	switch command {
 	case "create":
 
-		logger.Info("Creating new virtual environment: " + flag.Arg(1))
+		logger.Info("Creating new virtual environment: " + name)
 
-		configService := utils.GetConfigService(logger)
-		copyService := utils.GetCopyService()
-		packageService := utils.GetPackageService(logger, getHostInfo(), gopath, separator)
-		moveService := utils.GetMoveService()
+		configService := utils.GetConfigService(logger, fileSystem)
+		copyService := utils.GetCopyService(fileSystem)
+		packageService := utils.GetPackageService(logger, fileSystem, runner, importService, getHostInfo(), gopath, separator)
+		moveService := utils.GetMoveService(fileSystem)
 
 		create := commands.GetCreateCommand(
 			logger,
@@ -108,7 +266,12 @@ func main() {
 			copyService,
 			moveService,
 			packageService,
+			fileSystem,
+			promptService,
+			toolchainService,
+			runner,
 			populate,
+			goVersion,
 			gopath,
 			gobo,
 			getHostInfo(),
@@ -123,15 +286,16 @@ func main() {
 		fmt.Println("Create command complete.")
 
 	case "save":
-		configService := utils.GetConfigService(logger)
-		copyService := utils.GetCopyService()
-		packageService := utils.GetPackageService(logger, getHostInfo(), gopath, separator)
+		configService := utils.GetConfigService(logger, fileSystem)
+		copyService := utils.GetCopyService(fileSystem)
+		packageService := utils.GetPackageService(logger, fileSystem, runner, importService, getHostInfo(), gopath, separator)
 
 		save := commands.GetSaveCommand(
 			logger,
 			configService,
 			packageService,
 			copyService,
+			promptService,
 			getHostInfo(),
 			gopath,
 			gobo,
@@ -145,10 +309,10 @@ func main() {
 		fmt.Println("Save command complete.")
 
 	case "activate":
-		configService := utils.GetConfigService(logger)
-		copyService := utils.GetCopyService()
-		packageService := utils.GetPackageService(logger, getHostInfo(), gopath, separator)
-		moveService := utils.GetMoveService()
+		configService := utils.GetConfigService(logger, fileSystem)
+		copyService := utils.GetCopyService(fileSystem)
+		packageService := utils.GetPackageService(logger, fileSystem, runner, importService, getHostInfo(), gopath, separator)
+		moveService := utils.GetMoveService(fileSystem)
 
 		activate := commands.GetActivateCommand(
 			logger,
@@ -156,6 +320,10 @@ func main() {
 			packageService,
 			copyService,
 			moveService,
+			promptService,
+			toolchainService,
+			fileSystem,
+			runner,
 			getHostInfo(),
 			gopath,
 			gobo,
@@ -169,16 +337,24 @@ func main() {
 		fmt.Println("Activate command complete.")
 
 	case "restore":
-		copyService := utils.GetCopyService()
+		configService := utils.GetConfigService(logger, fileSystem)
+		copyService := utils.GetCopyService(fileSystem)
 
 		restore := commands.GetRestoreCommand(
 			logger,
 			copyService,
+			configService,
+			manifestService,
+			utils.GetDiskUsageService(fileSystem),
+			fileSystem,
+			promptService,
+			force,
+			to,
 			gopath,
 			gobo,
 		)
 
-		err := restore.Run()
+		err := restore.Run(rest(args, 1))
 		if err != nil {
 			logger.Fatal("Error running gobo restore command: " + err.Error())
 		}
@@ -187,10 +363,10 @@ func main() {
 
 	case "list":
 
-		configService := utils.GetConfigService(logger)
-		copyService := utils.GetCopyService()
-		packageService := utils.GetPackageService(logger, getHostInfo(), gopath, separator)
-		moveService := utils.GetMoveService()
+		configService := utils.GetConfigService(logger, fileSystem)
+		copyService := utils.GetCopyService(fileSystem)
+		packageService := utils.GetPackageService(logger, fileSystem, runner, importService, getHostInfo(), gopath, separator)
+		moveService := utils.GetMoveService(fileSystem)
 
 		list := commands.GetListCommand(
 			logger,
@@ -198,6 +374,10 @@ func main() {
 			packageService,
 			copyService,
 			moveService,
+			fileSystem,
+			promptService,
+			toolchainService,
+			runner,
 			getHostInfo(),
 			gopath,
 			gobo,
@@ -205,8 +385,8 @@ func main() {
 		list.Run(gobo)
 
 	case "delete":
-		configService := utils.GetConfigService(logger)
-		moveService := utils.GetMoveService()
+		configService := utils.GetConfigService(logger, fileSystem)
+		moveService := utils.GetMoveService(fileSystem)
 
 		delete := commands.GetDeleteCommand(
 			logger,
@@ -228,15 +408,18 @@ func main() {
 		version.Run()
 
 	case "install":
-		configService := utils.GetConfigService(logger)
-		copyService := utils.GetCopyService()
-		packageService := utils.GetPackageService(logger, getHostInfo(), gopath, separator)
+		configService := utils.GetConfigService(logger, fileSystem)
+		copyService := utils.GetCopyService(fileSystem)
+		packageService := utils.GetPackageService(logger, fileSystem, runner, importService, getHostInfo(), gopath, separator)
 
 		install := commands.GetInstallCommand(
 			logger,
 			configService,
 			packageService,
+			utils.GetResolverService(logger, configService, packageService, gopath, separator),
+			importService,
 			copyService,
+			promptService,
 			getHostInfo(),
 			gopath,
 			gobo,
@@ -249,6 +432,313 @@ func main() {
 
 		fmt.Println("Install command complete.")
 
+	case "update":
+		configService := utils.GetConfigService(logger, fileSystem)
+		packageService := utils.GetPackageService(logger, fileSystem, runner, importService, getHostInfo(), gopath, separator)
+
+		update := commands.GetUpdateCommand(
+			logger,
+			configService,
+			packageService,
+			utils.GetResolverService(logger, configService, packageService, gopath, separator),
+			to,
+			dryRun,
+			gopath,
+			separator,
+		)
+
+		err := update.Run(rest(args, 1))
+		if err != nil {
+			logger.Fatal("Error running gobo update command: " + err.Error())
+		}
+
+	case "snapshot":
+		configService := utils.GetConfigService(logger, fileSystem)
+		copyService := utils.GetCopyService(fileSystem)
+
+		snapshot := commands.GetSnapshotCommand(
+			logger,
+			configService,
+			copyService,
+			manifestService,
+			fileSystem,
+			keep,
+			gopath,
+			gobo,
+		)
+
+		taken, err := snapshot.Run(name, arg(args, 2))
+		if err != nil {
+			logger.Fatal("Error running gobo snapshot command: " + err.Error())
+		}
+
+		fmt.Println("Snapshot " + taken.ID + " of " + name + " complete.")
+
+	case "gc":
+		configService := utils.GetConfigService(logger, fileSystem)
+
+		gc := commands.GetGcCommand(
+			logger,
+			configService,
+			utils.GetDiskUsageService(fileSystem),
+			fileSystem,
+			promptService,
+			yes,
+			binaries,
+			gopath,
+			gobo,
+		)
+
+		err := gc.Run()
+		if err != nil {
+			logger.Fatal("Error running gobo gc command: " + err.Error())
+		}
+
+	case "prune":
+		configService := utils.GetConfigService(logger, fileSystem)
+		packageService := utils.GetPackageService(logger, fileSystem, runner, importService, getHostInfo(), gopath, separator)
+
+		prune := commands.GetPruneCommand(
+			logger,
+			configService,
+			packageService,
+			importService,
+			utils.GetDiskUsageService(fileSystem),
+			fileSystem,
+			promptService,
+			splitList(roots),
+			yes,
+			dryRun,
+			gopath,
+		)
+
+		err := prune.Run()
+		if err != nil {
+			logger.Fatal("Error running gobo prune command: " + err.Error())
+		}
+
+	case "why":
+		configService := utils.GetConfigService(logger, fileSystem)
+		packageService := utils.GetPackageService(logger, fileSystem, runner, importService, getHostInfo(), gopath, separator)
+
+		why := commands.GetWhyCommand(
+			logger,
+			configService,
+			packageService,
+			importService,
+			gopath,
+		)
+
+		err := why.Run(name)
+		if err != nil {
+			logger.Fatal("Error running gobo why command: " + err.Error())
+		}
+
+	case "graph":
+		configService := utils.GetConfigService(logger, fileSystem)
+		packageService := utils.GetPackageService(logger, fileSystem, runner, importService, getHostInfo(), gopath, separator)
+
+		graph := commands.GetGraphCommand(
+			logger,
+			configService,
+			packageService,
+			importService,
+			format,
+			root,
+			packages,
+			standard,
+			gopath,
+		)
+
+		err := graph.Run()
+		if err != nil {
+			logger.Fatal("Error running gobo graph command: " + err.Error())
+		}
+
+	case "licenses":
+		configService := utils.GetConfigService(logger, fileSystem)
+
+		licenses := commands.GetLicensesCommand(
+			logger,
+			configService,
+			utils.GetLicenseService(fileSystem),
+			splitList(deny),
+			asJSON,
+			gopath,
+			gobo,
+		)
+
+		err := licenses.Run(name)
+		if err != nil {
+			logger.Fatal("Error running gobo licenses command: " + err.Error())
+		}
+
+	case "audit":
+		configService := utils.GetConfigService(logger, fileSystem)
+		packageService := utils.GetPackageService(logger, fileSystem, runner, importService, getHostInfo(), gopath, separator)
+
+		audit := commands.GetAuditCommand(
+			logger,
+			configService,
+			utils.GetAdvisoryService(logger, fileSystem, packageService),
+			database,
+			asJSON,
+			gopath,
+			gobo,
+		)
+
+		err := audit.Run(name)
+		if err != nil {
+			logger.Fatal("Error running gobo audit command: " + err.Error())
+		}
+
+	case "exec":
+		configService := utils.GetConfigService(logger, fileSystem)
+
+		execCommand := commands.GetExecCommand(
+			logger,
+			configService,
+			toolchainService,
+			runner,
+			gopath,
+			gobo,
+		)
+
+		code, err := execCommand.Run(name, rest(args, 2))
+		if err != nil {
+			logger.Fatal("Error running gobo exec command: " + err.Error())
+		}
+
+		logger.Close()
+		os.Exit(code)
+
+	case "shell":
+		configService := utils.GetConfigService(logger, fileSystem)
+
+		shell := commands.GetShellCommand(
+			logger,
+			configService,
+			toolchainService,
+			fileSystem,
+			runner,
+			os.Getenv("SHELL"),
+			gopath,
+			gobo,
+		)
+
+		code, err := shell.Run(name)
+		if err != nil {
+			logger.Fatal("Error running gobo shell command: " + err.Error())
+		}
+
+		logger.Close()
+		os.Exit(code)
+
+	case "completion":
+		completion := commands.GetCompletionCommand(logger, commandFlags())
+
+		err := completion.Run(name)
+		if err != nil {
+			logger.Fatal("Error running gobo completion command: " + err.Error())
+		}
+
+	case "setenv":
+		configService := utils.GetConfigService(logger, fileSystem)
+
+		setenv := commands.GetSetenvCommand(
+			logger,
+			configService,
+			toolchainService,
+			fileSystem,
+			unset,
+			gopath,
+			gobo,
+		)
+
+		err := setenv.Run(name, rest(args, 2))
+		if err != nil {
+			logger.Fatal("Error running gobo setenv command: " + err.Error())
+		}
+
+	case "du":
+		configService := utils.GetConfigService(logger, fileSystem)
+
+		du := commands.GetDuCommand(
+			logger,
+			configService,
+			utils.GetDiskUsageService(fileSystem),
+			fileSystem,
+			sortBy,
+			asJSON,
+			top,
+			gopath,
+			gobo,
+		)
+
+		err := du.Run(name)
+		if err != nil {
+			logger.Fatal("Error running gobo du command: " + err.Error())
+		}
+
+	case "snapshots":
+		configService := utils.GetConfigService(logger, fileSystem)
+
+		snapshots := commands.GetSnapshotsCommand(
+			logger,
+			configService,
+			fileSystem,
+			gobo,
+		)
+
+		err := snapshots.Run(name)
+		if err != nil {
+			logger.Fatal("Error running gobo snapshots command: " + err.Error())
+		}
+
+	case "rollback":
+		configService := utils.GetConfigService(logger, fileSystem)
+		copyService := utils.GetCopyService(fileSystem)
+
+		rollback := commands.GetRollbackCommand(
+			logger,
+			configService,
+			copyService,
+			manifestService,
+			fileSystem,
+			keep,
+			force,
+			gopath,
+			gobo,
+		)
+
+		err := rollback.Run(name, arg(args, 2))
+		if err != nil {
+			logger.Fatal("Error running gobo rollback command: " + err.Error())
+		}
+
+		fmt.Println("Rollback command complete.")
+
+	case "backup":
+		if name != "verify" {
+			fmt.Println("Usage: gobo backup verify [name snapshot]")
+			break
+		}
+
+		configService := utils.GetConfigService(logger, fileSystem)
+
+		verify := commands.GetVerifyCommand(
+			logger,
+			configService,
+			manifestService,
+			fileSystem,
+			gobo,
+		)
+
+		err := verify.Run(arg(args, 2), arg(args, 3))
+		if err != nil {
+			logger.Fatal("Backup verification failed: " + err.Error())
+		}
+
 	default:
 		fmt.Println(command + " is not a known gobo command: ")
 		flag.Usage()
@@ -264,6 +754,105 @@ func FailOnError(err error, msg string) {
 	}
 }
 
+// parseArgs parses flags wherever they appear on the command line, so `gobo activate dev -wait` behaves like
+// `gobo -wait activate dev`, and returns the remaining positional arguments. Everything after a bare "--",
+// or after the environment exec and shell run in, is passed through untouched.
+func parseArgs() []string {
+	var args []string
+
+	remaining := flag.Args()
+	for len(remaining) > 0 {
+		if remaining[0] == "--" {
+			args = append(args, remaining...)
+			break
+		}
+
+		// the command exec and shell run keeps its own flags, with or without a "--" before it
+		if len(args) == 2 && (args[0] == "exec" || args[0] == "shell") {
+			args = append(args, remaining...)
+			break
+		}
+
+		if len(remaining[0]) > 1 && strings.HasPrefix(remaining[0], "-") {
+			// the flag package would swallow the "--", so only the flags before it are parsed
+			end := len(remaining)
+			for i, argument := range remaining {
+				if argument == "--" {
+					end = i
+					break
+				}
+			}
+
+			flag.CommandLine.Parse(remaining[:end])
+			remaining = append(append([]string{}, flag.Args()...), remaining[end:]...)
+			continue
+		}
+
+		args = append(args, remaining[0])
+		remaining = remaining[1:]
+	}
+
+	return args
+}
+
+// arg returns the positional argument at index i, or an empty string when there are not enough arguments.
+func arg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+
+	return ""
+}
+
+// rest returns the arguments from position i on, or nil if there are none.
+func rest(args []string, i int) []string {
+	if i < len(args) {
+		return args[i:]
+	}
+
+	return nil
+}
+
+// splitList splits a comma separated flag value, dropping blanks.
+func splitList(value string) []string {
+	var list []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// isMutating reports whether command changes the GOPATH or the gobo home and so must hold the gobo lock.
+func isMutating(command string) bool {
+	spec, _ := models.FindCommand(command)
+
+	return spec.Mutating
+}
+
+// commandFlags describes the flags gobo accepts for shell completion.
+func commandFlags() []models.Flag {
+	var flags []models.Flag
+
+	flag.VisitAll(func(f *flag.Flag) {
+		boolean, ok := f.Value.(interface {
+			IsBoolFlag() bool
+		})
+
+		flags = append(flags, models.Flag{
+			Name:    f.Name,
+			Usage:   f.Usage,
+			Boolean: ok && boolean.IsBoolFlag(),
+			Value:   models.FLAGVALUES[f.Name],
+		})
+	})
+
+	return flags
+}
+
 func getHostInfo() models.Host {
 	host := models.Host{}
 	version := runtime.Version()
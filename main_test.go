@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	defer func(commandLine *flag.FlagSet) { flag.CommandLine = commandLine }(flag.CommandLine)
+
+	for line, expected := range map[string][]string{
+		"activate dev -v":                       {"activate", "dev"},
+		"-v activate dev":                       {"activate", "dev"},
+		"exec dev -- go test -count=1 ./...":    {"exec", "dev", "--", "go", "test", "-count=1", "./..."},
+		"exec dev -v -- go test -count=1 ./...": {"exec", "dev", "-v", "--", "go", "test", "-count=1", "./..."},
+		"-v exec dev go test -v ./...":          {"exec", "dev", "go", "test", "-v", "./..."},
+		"shell dev -v":                          {"shell", "dev", "-v"},
+		"why -v -- -strange/path":               {"why", "--", "-strange/path"},
+	} {
+		flag.CommandLine = flag.NewFlagSet("gobo", flag.ContinueOnError)
+		verbose := flag.Bool("v", false, "")
+		flag.CommandLine.Parse(strings.Fields(line))
+
+		args := parseArgs()
+		if !reflect.DeepEqual(args, expected) {
+			t.Errorf("gobo %s parsed as %q, want %q", line, args, expected)
+		}
+
+		// only the flags before exec's environment, or before a "--" elsewhere, are gobo's
+		if want := !strings.HasPrefix(line, "exec dev") && !strings.HasPrefix(line, "shell"); *verbose != want {
+			t.Errorf("gobo %s set -v to %v", line, *verbose)
+		}
+	}
+}
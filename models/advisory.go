@@ -0,0 +1,68 @@
+package models
+
+// Advisory is a struct holding the parts of an OSV (https://ossf.github.io/osv-schema/) vulnerability record
+// that gobo audit reads.
+type Advisory struct {
+	ID               string                   `json:"id"`
+	Summary          string                   `json:"summary"`
+	Details          string                   `json:"details"`
+	Aliases          []string                 `json:"aliases"`
+	Severity         []AdvisorySeverity       `json:"severity"`
+	Affected         []AdvisoryAffected       `json:"affected"`
+	DatabaseSpecific AdvisoryDatabaseSpecific `json:"database_specific"`
+}
+
+// AdvisorySeverity is a severity score of an OSV record, for example a CVSS_V3 vector.
+type AdvisorySeverity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// AdvisoryDatabaseSpecific holds the severity label, such as HIGH, databases like GitHub's add to OSV records.
+type AdvisoryDatabaseSpecific struct {
+	Severity string `json:"severity"`
+}
+
+// AdvisoryAffected is a package an OSV record affects, with the versions and commit ranges affected.
+type AdvisoryAffected struct {
+	Package          AdvisoryPackage          `json:"package"`
+	Ranges           []AdvisoryRange          `json:"ranges"`
+	Versions         []string                 `json:"versions"`
+	Severity         []AdvisorySeverity       `json:"severity"`
+	DatabaseSpecific AdvisoryDatabaseSpecific `json:"database_specific"`
+}
+
+// AdvisoryPackage names an affected package, for gobo its import path.
+type AdvisoryPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+// AdvisoryRange is a SEMVER, ECOSYSTEM or GIT range of an affected package, described by the events where the
+// vulnerability was introduced and fixed.
+type AdvisoryRange struct {
+	Type   string          `json:"type"`
+	Repo   string          `json:"repo,omitempty"`
+	Events []AdvisoryEvent `json:"events"`
+}
+
+// AdvisoryEvent is one event of an AdvisoryRange, only one of its fields is set.
+type AdvisoryEvent struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// AuditFinding is a struct describing a locked package affected by an advisory.
+type AuditFinding struct {
+	Path     string   `json:"path"`
+	Revision string   `json:"revision"`
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases,omitempty"`
+	Summary  string   `json:"summary,omitempty"`
+	Severity string   `json:"severity"`
+
+	// Fixed is the version or commit fixing the vulnerability, empty when there is no fix.
+	Fixed string `json:"fixed,omitempty"`
+}
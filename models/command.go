@@ -0,0 +1,241 @@
+package models
+
+import "strings"
+
+// The kinds of value a positional argument or flag takes, used to complete them in shells.
+const (
+	ARGTEXT        = ""
+	ARGENVIRONMENT = "environment"
+	ARGPACKAGE     = "package"
+	ARGCHOICE      = "choice"
+	ARGFILE        = "file"
+	ARGDIRECTORY   = "directory"
+)
+
+// Argument is a struct describing a positional argument of a command, or the value of a flag.
+type Argument struct {
+	Name string
+
+	// Kind is one of the ARG constants, Choices are offered as well whatever the kind.
+	Kind    string
+	Choices []string
+
+	Optional bool
+
+	// Repeated arguments take every remaining position, they must come last.
+	Repeated bool
+}
+
+// Command is a struct describing a gobo command, usage text, locking and shell completion are generated from it.
+type Command struct {
+	Name      string
+	Summary   string
+	Arguments []Argument
+
+	// Mutating commands change the gobo home or GOPATH and run under the gobo lock.
+	Mutating bool
+
+	// Quiet commands skip the logo because their output belongs to another program.
+	Quiet bool
+}
+
+// Flag is a struct describing a command line flag for shell completion.
+type Flag struct {
+	Name    string
+	Usage   string
+	Boolean bool
+	Value   Argument
+}
+
+// Synopsis returns the arguments of the command formatted for usage text, for example "<name> [label]".
+func (command Command) Synopsis() string {
+	var parts []string
+
+	for _, argument := range command.Arguments {
+		name := argument.Name
+		if argument.Kind == ARGCHOICE && len(argument.Choices) > 0 {
+			name = strings.Join(argument.Choices, "|")
+		}
+		if argument.Repeated {
+			name += " ..."
+		}
+
+		if argument.Optional {
+			parts = append(parts, "["+name+"]")
+		} else if argument.Kind == ARGCHOICE {
+			parts = append(parts, name)
+		} else {
+			parts = append(parts, "<"+name+">")
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// COMMANDS is an array of every gobo command in the order usage lists them.
+var COMMANDS = []Command{
+	{
+		Name:      "create",
+		Summary:   "Create a new environment and make it active, -p copies the current packages, -go pins a toolchain.",
+		Arguments: []Argument{{Name: "name"}},
+		Mutating:  true,
+	},
+	{
+		Name:      "activate",
+		Summary:   "Save the active environment and switch the GOPATH to another one.",
+		Arguments: []Argument{{Name: "name", Kind: ARGENVIRONMENT}},
+		Mutating:  true,
+	},
+	{
+		Name:     "list",
+		Summary:  "List the environments and pick one to activate.",
+		Mutating: true,
+	},
+	{
+		Name:     "save",
+		Summary:  "Record the packages installed in the active environment in packages.lock.",
+		Mutating: true,
+	},
+	{
+		Name:     "install",
+		Summary:  "Install the packages locked in packages.lock, or those of the -f lock or manifest.",
+		Mutating: true,
+	},
+	{
+		Name:    "update",
+		Summary: "Move packages to their newest tag, the tip of their branch or the -to revision, -dry-run only shows it.",
+		Arguments: []Argument{
+			{Name: "package", Kind: ARGPACKAGE, Optional: true, Repeated: true},
+		},
+		Mutating: true,
+	},
+	{
+		Name:      "delete",
+		Summary:   "Delete an inactive environment.",
+		Arguments: []Argument{{Name: "name", Kind: ARGENVIRONMENT}},
+		Mutating:  true,
+	},
+	{
+		Name:    "restore",
+		Summary: "Restore the GOPATH from the initial backup, or only the named directories and packages.",
+		Arguments: []Argument{
+			{Name: "target", Kind: ARGPACKAGE, Choices: []string{"src", "pkg", "bin"}, Optional: true, Repeated: true},
+		},
+		Mutating: true,
+	},
+	{
+		Name:    "backup",
+		Summary: "Check the initial backup, or a snapshot, against its manifest.",
+		Arguments: []Argument{
+			{Name: "verify", Kind: ARGCHOICE, Choices: []string{"verify"}},
+			{Name: "name", Kind: ARGENVIRONMENT, Optional: true},
+			{Name: "snapshot", Optional: true},
+		},
+	},
+	{
+		Name:      "snapshot",
+		Summary:   "Save a point in time copy of an environment, keeping the newest -keep.",
+		Arguments: []Argument{{Name: "name", Kind: ARGENVIRONMENT}, {Name: "label", Optional: true}},
+		Mutating:  true,
+	},
+	{
+		Name:      "snapshots",
+		Summary:   "List the snapshots of one or every environment.",
+		Arguments: []Argument{{Name: "name", Kind: ARGENVIRONMENT, Optional: true}},
+	},
+	{
+		Name:      "rollback",
+		Summary:   "Put an environment back the way a snapshot recorded it.",
+		Arguments: []Argument{{Name: "name", Kind: ARGENVIRONMENT}, {Name: "snapshot"}},
+		Mutating:  true,
+	},
+	{
+		Name:     "gc",
+		Summary:  "Delete stale environment data, -yes skips the confirmation, -binaries also deletes binaries without source.",
+		Mutating: true,
+	},
+	{
+		Name:     "prune",
+		Summary:  "Delete the repositories nothing under -roots imports, -yes skips the confirmation, -dry-run only lists them.",
+		Mutating: true,
+	},
+	{
+		Name:      "why",
+		Summary:   "Print the shortest import chains from your own code to a package.",
+		Arguments: []Argument{{Name: "package", Kind: ARGPACKAGE}},
+	},
+	{
+		Name:    "graph",
+		Summary: "Print the import graph of the repositories as -format dot, json or mermaid, -packages keeps every package.",
+		Quiet:   true,
+	},
+	{
+		Name:      "licenses",
+		Summary:   "Report the licenses of the packages of an environment, failing on any in -deny.",
+		Arguments: []Argument{{Name: "name", Kind: ARGENVIRONMENT, Optional: true}},
+	},
+	{
+		Name:      "audit",
+		Summary:   "Check the package revisions of an environment against the -db OSV advisories, failing on findings or on packages it cannot check.",
+		Arguments: []Argument{{Name: "name", Kind: ARGENVIRONMENT, Optional: true}},
+	},
+	{
+		Name:      "du",
+		Summary:   "Report the disk space used by one or every environment.",
+		Arguments: []Argument{{Name: "name", Kind: ARGENVIRONMENT, Optional: true}},
+	},
+	{
+		Name:    "setenv",
+		Summary: "Print, set or with -unset remove the variables of an environment.",
+		Arguments: []Argument{
+			{Name: "name", Kind: ARGENVIRONMENT},
+			{Name: "KEY=VALUE", Optional: true, Repeated: true},
+		},
+		Mutating: true,
+	},
+	{
+		Name:      "exec",
+		Summary:   "Run a command inside an environment without activating it, put the command after --.",
+		Arguments: []Argument{{Name: "name", Kind: ARGENVIRONMENT}, {Name: "command", Repeated: true}},
+		Quiet:     true,
+	},
+	{
+		Name:      "shell",
+		Summary:   "Start $SHELL inside an environment without activating it.",
+		Arguments: []Argument{{Name: "name", Kind: ARGENVIRONMENT}},
+		Quiet:     true,
+	},
+	{
+		Name:      "completion",
+		Summary:   "Print the completion script for a shell.",
+		Arguments: []Argument{{Name: "shell", Kind: ARGCHOICE, Choices: []string{"bash", "zsh", "fish"}}},
+		Quiet:     true,
+	},
+	{
+		Name:    "version",
+		Summary: "Print the gobo version.",
+	},
+}
+
+// FLAGVALUES maps the flags which take a value worth completing to a description of that value.
+var FLAGVALUES = map[string]Argument{
+	"f":          {Kind: ARGFILE},
+	"db":         {Kind: ARGFILE},
+	"to":         {Kind: ARGDIRECTORY},
+	"log-level":  {Kind: ARGCHOICE, Choices: []string{"debug", "info", "warn", "error"}},
+	"log-format": {Kind: ARGCHOICE, Choices: []string{"text", "json"}},
+	"sort":       {Kind: ARGCHOICE, Choices: []string{"size", "name"}},
+	"format":     {Kind: ARGCHOICE, Choices: []string{"dot", "json", "mermaid"}},
+	"root":       {Kind: ARGPACKAGE},
+}
+
+// FindCommand returns the description of the command name.
+func FindCommand(name string) (Command, bool) {
+	for _, command := range COMMANDS {
+		if command.Name == name {
+			return command, true
+		}
+	}
+
+	return Command{}, false
+}
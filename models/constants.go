@@ -6,6 +6,32 @@ var GOPATHDIRECTORIES = [...]string{"src", "pkg", "bin"}
 // GOPATHFILES is an array of files to operate on in the GOPATH.
 var GOPATHFILES = [...]string{"gobo.json"}
 
+// ENVIRONMENTFILES is an array of the gobo files describing an environment.
+var ENVIRONMENTFILES = [...]string{"gobo.toml", "packages.toml", "packages.lock"}
+
+// RESERVEDDIRECTORIES is an array of directories in the gobo home which are not environments.
+var RESERVEDDIRECTORIES = [...]string{"initial", "logs", "shell", "snapshots", "toolchains", "unmanaged"}
+
+// IsReserved reports whether name is a gobo home directory which can't be used as an environment name.
+func IsReserved(name string) bool {
+	for _, reserved := range RESERVEDDIRECTORIES {
+		if name == reserved {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MANAGEDVARIABLES is an array of environment variables gobo sets itself, so environments can't override them.
+var MANAGEDVARIABLES = [...]string{"GOPATH", "GOROOT", "PATH"}
+
+// HOOKS is an array of the hooks an environment can run when it is activated or deactivated.
+var HOOKS = [...]string{"pre-activate", "post-activate", "pre-deactivate", "post-deactivate"}
+
+// DEFAULTSNAPSHOTRETENTION is the number of snapshots kept per environment unless told otherwise.
+const DEFAULTSNAPSHOTRETENTION = 10
+
 // GOBOVERSION is the version of the app.
 const GOBOVERSION = "0.0.2"
 
@@ -8,9 +8,19 @@ import (
 // Environment is a struct representing a gobo TOML configuration file.
 type Environment struct {
 	Name         string    `toml:"name"`
+	GoVersion    string    `toml:"go,omitempty"`
 	DateCreated  time.Time `toml:"created"`
 	DateModified time.Time `toml:"modified"`
 	Host         Host      `toml:"host"`
+
+	// Env holds the variables set for package operations and exported by the activate script.
+	Env map[string]string `toml:"env,omitempty"`
+
+	// Hooks maps hook names such as pre-activate to scripts in the environment's hooks directory.
+	Hooks map[string]string `toml:"hooks,omitempty"`
+
+	// Projects are the import path prefixes of the environment's own code, which decide the direct packages.
+	Projects []string `toml:"projects,omitempty"`
 }
 
 // Host is a struct describing User and System information that acted on the Environment file.
@@ -51,6 +61,11 @@ type Package struct {
 	// Examples: "abc104...438ade0", "v1.3.5"
 	Revision string `json:"revision" toml:"revision"`
 
+	// Version is a semantic version constraint such as "^1.4" the revision is resolved from, among the tags
+	// of the repository. Branch instead follows the tip of a branch. At most one of them is set.
+	Version string `json:"version,omitempty" toml:"version,omitempty"`
+	Branch  string `json:"branch,omitempty" toml:"branch,omitempty"`
+
 	// RevisionTime is the time the revision was created. The time should be
 	// parsed and written in the "time.RFC3339" format.
 	RevisionTime string `json:"revisionTime" toml:"revisionTime"`
@@ -58,3 +73,28 @@ type Package struct {
 	// Comment is free text for human use.
 	Comment string `json:"comment,omitempty" toml:"comment,omitempty"`
 }
+
+// PackageLock is a struct representing packages.lock, the exact revisions of every package installed in an
+// environment. gobo generates it, packages.toml is the hand edited manifest of what was asked for.
+type PackageLock struct {
+	Package []LockedPackage `json:"package" toml:"package"`
+}
+
+// LockedPackage is a struct describing the revision one package is locked at.
+type LockedPackage struct {
+	Path     string `json:"path" toml:"path"`
+	Origin   string `json:"origin" toml:"origin"`
+	Revision string `json:"revision" toml:"revision"`
+
+	// RevisionTime is the commit time of the revision in the "time.RFC3339" format, in UTC.
+	RevisionTime string `json:"revisionTime" toml:"revisionTime"`
+
+	// Version and Branch repeat the requirement of the manifest the revision was resolved from, if any.
+	Version string `json:"version,omitempty" toml:"version,omitempty"`
+	Branch  string `json:"branch,omitempty" toml:"branch,omitempty"`
+
+	// Direct is set when the environment's own code imports the package, or the package is that code.
+	// ImportedBy lists the repositories, and the code outside any repository, which import it.
+	Direct     bool     `json:"direct" toml:"direct"`
+	ImportedBy []string `json:"importedBy,omitempty" toml:"importedBy,omitempty"`
+}
@@ -0,0 +1,44 @@
+package models
+
+// ImportGraph is a struct describing which packages in a GOPATH import which, and the repositories holding them.
+type ImportGraph struct {
+	// Imports maps the import path of every package found in the GOPATH to the sorted import paths it and its
+	// tests import, standard library packages included.
+	Imports map[string][]string
+
+	// Repositories maps the import path of every package inside a repository to the path of that repository.
+	Repositories map[string]string
+}
+
+// Node returns the repository holding the package at path, or the path itself for code outside any repository.
+func (graph ImportGraph) Node(path string) string {
+	if repository, ok := graph.Repositories[path]; ok {
+		return repository
+	}
+
+	return path
+}
+
+// DependencyGraph is a struct describing the import graph of an environment as gobo graph exports it.
+type DependencyGraph struct {
+	Environment string      `json:"environment"`
+	Nodes       []GraphNode `json:"nodes"`
+	Edges       []GraphEdge `json:"edges"`
+}
+
+// GraphNode is a repository, or a package when subpackages are not collapsed, of a DependencyGraph. The
+// revision and requirement are those locked for the repository holding it, if any.
+type GraphNode struct {
+	ID         string `json:"id"`
+	Repository string `json:"repository,omitempty"`
+	Revision   string `json:"revision,omitempty"`
+	Version    string `json:"version,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+	Standard   bool   `json:"standard,omitempty"`
+}
+
+// GraphEdge is an import of one node of a DependencyGraph by another.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
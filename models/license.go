@@ -0,0 +1,15 @@
+package models
+
+// PackageLicense is a struct describing the licenses found in the repository of a package of an environment.
+type PackageLicense struct {
+	Path     string `json:"path"`
+	Revision string `json:"revision,omitempty"`
+
+	// Licenses holds one identifier such as MIT or Apache-2.0 per license file, "unknown" for a file the
+	// classifier does not recognise and "none" when the repository has no license file.
+	Licenses []string `json:"licenses"`
+	Files    []string `json:"files,omitempty"`
+
+	// Denied is set when one of the licenses is disallowed by the -deny policy.
+	Denied bool `json:"denied,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Lock is a struct describing the gobo process currently holding the gobo lock file.
+type Lock struct {
+	PID      int       `toml:"pid"`
+	Command  string    `toml:"command"`
+	Hostname string    `toml:"host"`
+	Acquired time.Time `toml:"acquired"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Manifest is a struct listing every file in a backup or snapshot, so its integrity can be verified later.
+type Manifest struct {
+	Created time.Time      `toml:"created"`
+	File    []ManifestFile `toml:"file"`
+}
+
+// ManifestFile struct describes a single file in a Manifest.
+type ManifestFile struct {
+	// Path is slash separated and relative to the directory the manifest describes.
+	Path   string `toml:"path"`
+	Size   int64  `toml:"size"`
+	Mode   uint32 `toml:"mode"`
+	SHA256 string `toml:"sha256"`
+
+	// Link is the target of a symbolic link, which has no size, mode or hash.
+	Link string `toml:"link,omitempty"`
+}
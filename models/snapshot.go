@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Snapshot is a struct describing a point in time copy of an environment's GOPATH directories and toml files.
+type Snapshot struct {
+	ID          string    `toml:"id"`
+	Environment string    `toml:"environment"`
+	Label       string    `toml:"label,omitempty"`
+	Created     time.Time `toml:"created"`
+}
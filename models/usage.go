@@ -0,0 +1,18 @@
+package models
+
+// EnvironmentUsage is a struct describing the disk space used by an environment, in bytes.
+type EnvironmentUsage struct {
+	Name     string         `json:"name"`
+	Active   bool           `json:"active"`
+	Src      int64          `json:"src"`
+	Pkg      int64          `json:"pkg"`
+	Bin      int64          `json:"bin"`
+	Total    int64          `json:"total"`
+	Packages []PackageUsage `json:"packages,omitempty"`
+}
+
+// PackageUsage is a struct describing the disk space used by the source of a package of an environment, in bytes.
+type PackageUsage struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
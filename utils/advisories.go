@@ -0,0 +1,328 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+// IAdvisoryService is the interface to implement for checking packages against a local vulnerability database.
+type IAdvisoryService interface {
+	Load(path string) ([]models.Advisory, error)
+	Audit(advisories []models.Advisory, path string, revision string, dir string) ([]models.AuditFinding, error)
+}
+
+// AdvisoryService is the struct for this implementation of IAdvisoryService.
+type AdvisoryService struct {
+	logger         ILogger
+	fileSystem     IFileSystem
+	packageService IPackageService
+}
+
+// GetAdvisoryService returns a pointer to an implementation of IAdvisoryService.
+func GetAdvisoryService(logger ILogger, fileSystem IFileSystem, packageService IPackageService) *AdvisoryService {
+	var advisoryService = AdvisoryService{
+		logger,
+		fileSystem,
+		packageService,
+	}
+
+	return &advisoryService
+}
+
+// Load reads the OSV advisories at path, a JSON file holding one advisory or an array of them, or a directory
+// whose .json files, at any depth, hold them.
+func (advisoryService *AdvisoryService) Load(path string) ([]models.Advisory, error) {
+	info, err := advisoryService.fileSystem.Stat(path)
+	if err != nil {
+		return nil, errors.New("unable to read the advisory database " + path + ": " + err.Error())
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files = nil
+		err = advisoryService.fileSystem.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && strings.HasSuffix(file, ".json") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var advisories []models.Advisory
+	for _, file := range files {
+		data, err := advisoryService.fileSystem.ReadFile(file)
+		if err != nil {
+			return nil, errors.New("unable to read advisory file " + file + ": " + err.Error())
+		}
+
+		data = bytes.TrimSpace(data)
+		if bytes.HasPrefix(data, []byte("[")) {
+			var list []models.Advisory
+			err = json.Unmarshal(data, &list)
+			advisories = append(advisories, list...)
+		} else {
+			var advisory models.Advisory
+			err = json.Unmarshal(data, &advisory)
+			advisories = append(advisories, advisory)
+		}
+		if err != nil {
+			return nil, errors.New("unable to parse advisory file " + file + ": " + err.Error())
+		}
+	}
+
+	return advisories, nil
+}
+
+// Audit returns a finding for every advisory affecting the repository at path locked at revision, whose clone
+// is dir. An advisory names the repository or a package inside it, and affects the revision when it is one of
+// its versions, falls in one of its SEMVER ranges, or in one of its GIT ranges as git in dir decides. A revision
+// which is a commit is placed in a SEMVER range by which of the range's tags it descends from. The error names
+// the advisories the revision could not be checked against, the findings are still those of the others.
+func (advisoryService *AdvisoryService) Audit(advisories []models.Advisory, path string, revision string, dir string) ([]models.AuditFinding, error) {
+	var findings []models.AuditFinding
+	var unchecked []string
+
+	for _, advisory := range advisories {
+		for _, affected := range advisory.Affected {
+			name := affected.Package.Name
+			if name == "" || Containing(name, []string{path}) == "" && Containing(path, []string{name}) == "" {
+				continue
+			}
+
+			hit, fixed, err := advisoryService.affects(affected, revision, dir)
+			if err != nil {
+				unchecked = append(unchecked, advisory.ID+" ("+err.Error()+")")
+				continue
+			}
+			if !hit {
+				continue
+			}
+
+			findings = append(findings, models.AuditFinding{
+				Path:     path,
+				Revision: revision,
+				ID:       advisory.ID,
+				Aliases:  advisory.Aliases,
+				Summary:  advisory.Summary,
+				Severity: severity(advisory, affected),
+				Fixed:    fixed,
+			})
+			break
+		}
+	}
+
+	if len(unchecked) > 0 {
+		return findings, errors.New("unable to check " + path + " at " + revision + " against " + strings.Join(unchecked, ", "))
+	}
+
+	return findings, nil
+}
+
+// affects reports whether revision is affected, and the version or commit fixing it.
+func (advisoryService *AdvisoryService) affects(affected models.AdvisoryAffected, revision string, dir string) (bool, string, error) {
+	version, err := ParseVersion(revision)
+	isVersion := err == nil && strings.Contains(revision, ".")
+
+	hit := false
+	fixed := ""
+
+	for _, listed := range affected.Versions {
+		if strings.TrimPrefix(listed, "v") == strings.TrimPrefix(revision, "v") {
+			hit = true
+		}
+	}
+
+	for _, advisoryRange := range affected.Ranges {
+		var inRange bool
+		var fix string
+
+		switch advisoryRange.Type {
+		case "SEMVER", "ECOSYSTEM":
+			if isVersion {
+				inRange, fix = semverAffected(advisoryRange.Events, version)
+				break
+			}
+
+			inRange, fix, err = advisoryService.commitAffected(advisoryRange.Events, revision, dir)
+			if err != nil {
+				return false, "", err
+			}
+		case "GIT":
+			inRange, fix, err = advisoryService.gitAffected(advisoryRange.Events, revision, dir)
+			if err != nil {
+				return false, "", err
+			}
+		default:
+			continue
+		}
+
+		if inRange {
+			hit = true
+			if fixed == "" {
+				fixed = fix
+			}
+		}
+	}
+
+	return hit, fixed, nil
+}
+
+// semverAffected reports whether version falls in the range the events describe, and the version fixing it. A
+// version is affected from an introduced event up to, but not including, the next fixed event, or up to and
+// including a last_affected event.
+func semverAffected(events []models.AdvisoryEvent, version Version) (bool, string) {
+	affected, fixed, _ := rangeAffected(events, func(event models.AdvisoryEvent, point Version) (bool, error) {
+		if event.LastAffected != "" {
+			return point.Compare(version) < 0, nil
+		}
+		return point.Compare(version) <= 0, nil
+	})
+
+	return affected, fixed
+}
+
+// commitAffected is semverAffected for the commit revision in the repository dir, which has passed a version of
+// the events when it descends from the tag of that version. A version with no tag in dir is an error.
+func (advisoryService *AdvisoryService) commitAffected(events []models.AdvisoryEvent, revision string, dir string) (bool, string, error) {
+	return rangeAffected(events, func(event models.AdvisoryEvent, point Version) (bool, error) {
+		if event.Introduced == "0" {
+			return true, nil
+		}
+
+		tag, reached, err := advisoryService.descends(dir, point.Original, revision)
+		if err != nil || !reached || event.LastAffected == "" {
+			return reached, err
+		}
+
+		// past the last affected version, unless revision is the tag itself
+		same, err := advisoryService.packageService.IsAncestor(dir, revision, tag)
+		return !same, err
+	})
+}
+
+// descends reports whether revision descends from the tag of version in the repository dir, tried with and
+// without a leading v, and the tag it found.
+func (advisoryService *AdvisoryService) descends(dir string, version string, revision string) (string, bool, error) {
+	version = strings.TrimPrefix(version, "v")
+
+	for _, tag := range []string{"v" + version, version} {
+		reached, err := advisoryService.packageService.IsAncestor(dir, tag, revision)
+		if err == nil {
+			return tag, reached, nil
+		}
+	}
+
+	return "", false, errors.New("no tag in " + dir + " for version " + version)
+}
+
+// rangeAffected walks the events of a range in version order, passed reporting whether the revision is at or
+// beyond the version of an event, and returns whether the revision is affected and the version fixing it.
+func rangeAffected(events []models.AdvisoryEvent, passed func(event models.AdvisoryEvent, point Version) (bool, error)) (bool, string, error) {
+	type point struct {
+		version Version
+		event   models.AdvisoryEvent
+	}
+
+	var points []point
+	for _, event := range events {
+		text := event.Introduced + event.Fixed + event.LastAffected
+		if event.Limit != "" || text == "" {
+			continue
+		}
+
+		parsed, err := ParseVersion(text)
+		if err != nil {
+			continue
+		}
+		points = append(points, point{parsed, event})
+	}
+
+	sort.SliceStable(points, func(i int, j int) bool {
+		return points[i].version.Compare(points[j].version) < 0
+	})
+
+	affected := false
+	fixed := ""
+	for _, p := range points {
+		reached, err := passed(p.event, p.version)
+		if err != nil {
+			return false, "", err
+		}
+
+		switch {
+		case p.event.Introduced != "" && reached:
+			affected = true
+		case p.event.Fixed != "" && reached:
+			affected = false
+		case p.event.LastAffected != "" && reached:
+			affected = false
+		case p.event.Fixed != "" && affected && fixed == "":
+			fixed = "v" + strings.TrimPrefix(p.event.Fixed, "v")
+		}
+	}
+
+	if !affected {
+		fixed = ""
+	}
+
+	return affected, fixed, nil
+}
+
+// gitAffected reports whether the commit revision in the repository dir descends from an introduced commit of the
+// events without descending from a fixed one, and the first fixed commit.
+func (advisoryService *AdvisoryService) gitAffected(events []models.AdvisoryEvent, revision string, dir string) (bool, string, error) {
+	introduced := false
+	reachedFix := false
+	fixed := ""
+
+	for _, event := range events {
+		switch {
+		case event.Introduced == "0":
+			introduced = true
+		case event.Introduced != "":
+			ancestor, err := advisoryService.packageService.IsAncestor(dir, event.Introduced, revision)
+			if err != nil {
+				return false, "", err
+			}
+			introduced = introduced || ancestor
+		case event.Fixed != "":
+			if fixed == "" {
+				fixed = event.Fixed
+			}
+
+			ancestor, err := advisoryService.packageService.IsAncestor(dir, event.Fixed, revision)
+			if err != nil {
+				return false, "", err
+			}
+			reachedFix = reachedFix || ancestor
+		}
+	}
+
+	return introduced && !reachedFix, fixed, nil
+}
+
+// severity returns the severity label of the advisory, such as HIGH, or its first score when it has no label.
+func severity(advisory models.Advisory, affected models.AdvisoryAffected) string {
+	for _, label := range []string{affected.DatabaseSpecific.Severity, advisory.DatabaseSpecific.Severity} {
+		if label != "" {
+			return strings.ToUpper(label)
+		}
+	}
+
+	for _, scores := range [][]models.AdvisorySeverity{affected.Severity, advisory.Severity} {
+		if len(scores) > 0 {
+			return scores[0].Type + " " + scores[0].Score
+		}
+	}
+
+	return "UNKNOWN"
+}
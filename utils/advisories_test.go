@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+const testAdvisories = `[
+  {
+    "id": "GO-2023-0001",
+    "summary": "Panic on crafted input",
+    "affected": [{
+      "package": {"ecosystem": "Go", "name": "example.com/lib/parser"},
+      "ranges": [{"type": "SEMVER", "events": [{"introduced": "1.2.0"}, {"fixed": "1.4.1"}, {"introduced": "2.0.0"}, {"fixed": "2.0.3"}]}]
+    }],
+    "database_specific": {"severity": "high"}
+  },
+  {
+    "id": "GO-2023-0002",
+    "affected": [{
+      "package": {"name": "example.com/tool"},
+      "ranges": [{"type": "GIT", "repo": "https://example.com/tool", "events": [{"introduced": "0"}, {"fixed": "f1x"}]}]
+    }],
+    "severity": [{"type": "CVSS_V3", "score": "CVSS:3.1/AV:N/AC:L"}]
+  }
+]`
+
+func newTestAdvisoryService(runner *FakeRunner) (*AdvisoryService, IFileSystem) {
+	fileSystem := GetMemoryFileSystem()
+	logger, _ := GetConfiguredLogger(LogConfig{Level: PANIC})
+	packageService := GetPackageService(logger, fileSystem, runner, GetImportService(logger, fileSystem, "/go/", "/"), models.Host{}, "/go/", "/")
+
+	return GetAdvisoryService(logger, fileSystem, packageService), fileSystem
+}
+
+func TestAuditMatchesSemverRanges(t *testing.T) {
+	advisoryService, fileSystem := newTestAdvisoryService(GetFakeRunner())
+
+	fileSystem.MkdirAll("/db/go", 0755)
+	fileSystem.WriteFile("/db/go/all.json", []byte(testAdvisories), 0644)
+	fileSystem.WriteFile("/db/README.md", []byte("not an advisory"), 0644)
+
+	advisories, err := advisoryService.Load("/db")
+	if err != nil || len(advisories) != 2 {
+		t.Fatalf("expected 2 advisories, got %d, %v", len(advisories), err)
+	}
+
+	for revision, fixed := range map[string]string{"v1.2.0": "v1.4.1", "v1.4.0": "v1.4.1", "v2.0.2": "v2.0.3"} {
+		findings, err := advisoryService.Audit(advisories, "example.com/lib", revision, "/go/src/example.com/lib")
+		if err != nil || len(findings) != 1 || findings[0].Fixed != fixed || findings[0].Severity != "HIGH" {
+			t.Errorf("expected %s to be affected and fixed in %s, got %+v", revision, fixed, findings)
+		}
+	}
+
+	for _, revision := range []string{"v1.1.9", "v1.4.1", "v1.9.0", "v2.0.3"} {
+		if findings, err := advisoryService.Audit(advisories, "example.com/lib", revision, "/go/src/example.com/lib"); err != nil || len(findings) != 0 {
+			t.Errorf("expected %s not to be affected, got %+v", revision, findings)
+		}
+	}
+}
+
+func TestAuditMatchesGitRanges(t *testing.T) {
+	dir := "/go/src/example.com/tool"
+	runner := GetFakeRunner(
+		FakeCommand{Command: "git merge-base --is-ancestor f1x old", Dir: dir, Err: errors.New("exit status 1")},
+		FakeCommand{Command: "git merge-base --is-ancestor f1x new", Dir: dir},
+	)
+	advisoryService, fileSystem := newTestAdvisoryService(runner)
+
+	fileSystem.WriteFile("/advisories.json", []byte(testAdvisories), 0644)
+	advisories, err := advisoryService.Load("/advisories.json")
+	if err != nil {
+		t.Fatalf("Load returned %v", err)
+	}
+
+	findings, err := advisoryService.Audit(advisories, "example.com/tool", "old", dir)
+	if err != nil || len(findings) != 1 || findings[0].ID != "GO-2023-0002" || findings[0].Fixed != "f1x" || findings[0].Severity != "CVSS_V3 CVSS:3.1/AV:N/AC:L" {
+		t.Errorf("expected the commit before the fix to be affected, got %+v", findings)
+	}
+
+	if findings, err := advisoryService.Audit(advisories, "example.com/tool", "new", dir); err != nil || len(findings) != 0 {
+		t.Errorf("expected the commit after the fix not to be affected, got %+v", findings)
+	}
+}
+
+func TestAuditPlacesCommitsInSemverRangesByTag(t *testing.T) {
+	dir := "/go/src/example.com/lib"
+	notAncestor := errors.New("exit status 1")
+	unknown := errors.New("exit status 128")
+
+	// 0a1b2c3 is a commit between v2.0.0 and v2.0.3, f0f0f0f one after v2.0.3
+	var script []FakeCommand
+	for _, tag := range []string{"v1.2.0", "v1.4.1", "v2.0.0"} {
+		script = append(script,
+			FakeCommand{Command: "git merge-base --is-ancestor " + tag + " 0a1b2c3", Dir: dir},
+			FakeCommand{Command: "git merge-base --is-ancestor " + tag + " f0f0f0f", Dir: dir},
+		)
+	}
+	script = append(script,
+		FakeCommand{Command: "git merge-base --is-ancestor v2.0.3 0a1b2c3", Dir: dir, Err: notAncestor},
+		FakeCommand{Command: "git merge-base --is-ancestor v2.0.3 f0f0f0f", Dir: dir},
+		FakeCommand{Command: "git merge-base --is-ancestor v1.2.0 untagged", Dir: dir, Err: unknown, Stderr: "fatal: Not a valid object name v1.2.0"},
+		FakeCommand{Command: "git merge-base --is-ancestor 1.2.0 untagged", Dir: dir, Err: unknown, Stderr: "fatal: Not a valid object name 1.2.0"},
+	)
+	advisoryService, fileSystem := newTestAdvisoryService(GetFakeRunner(script...))
+
+	fileSystem.WriteFile("/advisories.json", []byte(testAdvisories), 0644)
+	advisories, err := advisoryService.Load("/advisories.json")
+	if err != nil {
+		t.Fatalf("Load returned %v", err)
+	}
+
+	findings, err := advisoryService.Audit(advisories, "example.com/lib", "0a1b2c3", dir)
+	if err != nil || len(findings) != 1 || findings[0].ID != "GO-2023-0001" || findings[0].Fixed != "v2.0.3" {
+		t.Errorf("expected the commit before v2.0.3 to be affected and fixed in v2.0.3, got %+v, %v", findings, err)
+	}
+
+	if findings, err := advisoryService.Audit(advisories, "example.com/lib", "f0f0f0f", dir); err != nil || len(findings) != 0 {
+		t.Errorf("expected the commit after v2.0.3 not to be affected, got %+v, %v", findings, err)
+	}
+
+	if _, err := advisoryService.Audit(advisories, "example.com/lib", "untagged", dir); err == nil || !strings.Contains(err.Error(), "GO-2023-0001") {
+		t.Errorf("expected a commit the tags do not place to be reported unchecked, got %v", err)
+	}
+}
@@ -1,11 +1,10 @@
 package utils
 
 import (
-	"bytes"
 	"errors"
-	"fmt"
-	"os/exec"
-	"runtime"
+	"io"
+	"os"
+	"path/filepath"
 )
 
 // ICopyService is the interface to implement for cross platform copy commands.
@@ -16,89 +15,117 @@ type ICopyService interface {
 
 // CopyService is the struct for this implementation of the ICopyService interface.
 type CopyService struct {
+	fileSystem IFileSystem
 }
 
 // GetCopyService returns a pointer to an implementation of the ICopyService interface.
-func GetCopyService() *CopyService {
-	var copy = CopyService{}
+func GetCopyService(fileSystem IFileSystem) *CopyService {
+	var copy = CopyService{
+		fileSystem,
+	}
 
 	return &copy
 }
 
-// CopyDir uses system copy commands to recursively copy directory structures.
+// CopyDir recursively copies the source directory the way `cp -r` does: when destination is an existing
+// directory the copy is created inside it, otherwise the copy is created as destination. Symbolic links are
+// copied as links to the same target, whether it is a directory, a file or missing.
 func (copy *CopyService) CopyDir(source string, destination string) error {
-	var cmd string
-	var err error
-	if runtime.GOOS == "windows" {
-		fmt.Println("Hello from Windows")
-		cmd, err := exec.LookPath("xcopy")
+
+	source = filepath.Clean(source)
+	target := copy.target(source, destination)
+
+	err := copy.fileSystem.Walk(source, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
-			return errors.New(fmt.Sprintf("Gobo for Windows requires xcopy: %s\n", err.Error()))
+			return err
 		}
 
-		cmdArgs := []string{"/E", source, destination}
-		if _, err = exec.Command(cmd, cmdArgs...).Output(); err != nil {
-			return errors.New(fmt.Sprintf(
-				"There was an error making a copy of the current GOPATH for backup: %s\n",
-				err,
-			),
-			)
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
 		}
-	} else {
-		cmd = "cp"
-		cmdArgs := []string{"-r", source, destination}
+		dest := filepath.Join(target, rel)
 
-		cmd := exec.Command(cmd, cmdArgs...)
-
-		var out bytes.Buffer
-		var stderr bytes.Buffer
-		cmd.Stdout = &out
-		cmd.Stderr = &stderr
-		err := cmd.Run()
+		if info.IsDir() {
+			return copy.fileSystem.MkdirAll(dest, info.Mode().Perm())
+		}
 
-		if err != nil {
-			return errors.New(
-				"There was an error making a copy of the current GOPATH for backup: " +
-					err.Error() + ": " +
-					stderr.String(),
-			)
+		if info.Mode()&os.ModeSymlink != 0 {
+			return copy.copyLink(path, dest)
 		}
-	}
 
-	return err
+		return copy.copyFile(path, dest, info.Mode().Perm())
+	})
 
+	if err != nil {
+		return errors.New(
+			"There was an error copying " + source + " to " + destination + ": " + err.Error(),
+		)
+	}
+
+	return nil
 }
 
-// CopyFile uses system copy commands to recursively copy directory structurescopy files.
+// CopyFile copies a single file, into destination when it is an existing directory.
 func (copy *CopyService) CopyFile(source string, destination string) error {
-	var cmd string
-	var err error
-	if runtime.GOOS == "windows" {
-		fmt.Println("Hello from Windows")
-		cmd, err := exec.LookPath("xcopy")
-		if err != nil {
-			return errors.New(fmt.Sprintf("Gobo for Windows requires xcopy: %s\n", err.Error()))
-		}
 
-		cmdArgs := []string{source, destination}
-		if _, err = exec.Command(cmd, cmdArgs...).Output(); err != nil {
-			return errors.New(fmt.Sprintf(
-				"There was an error making a copy of the current GOPATH for backup: %s\n",
-				err,
-			),
-			)
-		}
-	} else {
-		cmd = "cp"
-		cmdArgs := []string{source, destination}
-		if _, err = exec.Command(cmd, cmdArgs...).Output(); err != nil {
-			return errors.New(fmt.Sprintf(
-				"There was an error making a copy of the current GOPATH for backup: %s\n",
-				err,
-			))
+	info, err := copy.fileSystem.Stat(source)
+	if err != nil {
+		return errors.New("There was an error copying " + source + ": " + err.Error())
+	}
+
+	err = copy.copyFile(source, copy.target(filepath.Clean(source), destination), info.Mode().Perm())
+	if err != nil {
+		return errors.New(
+			"There was an error copying " + source + " to " + destination + ": " + err.Error(),
+		)
+	}
+
+	return nil
+}
+
+// target returns where source ends up when copied or moved to destination.
+func (copy *CopyService) target(source string, destination string) string {
+	if info, err := copy.fileSystem.Stat(destination); err == nil && info.IsDir() {
+		return filepath.Join(destination, filepath.Base(source))
+	}
+
+	return filepath.Clean(destination)
+}
+
+// copyLink recreates the symbolic link source as destination, replacing a file or link already there.
+func (copy *CopyService) copyLink(source string, destination string) error {
+	link, err := copy.fileSystem.Readlink(source)
+	if err != nil {
+		return err
+	}
+
+	if existing, err := copy.fileSystem.Lstat(destination); err == nil && !existing.IsDir() {
+		if err := copy.fileSystem.Remove(destination); err != nil {
+			return err
 		}
 	}
 
-	return err
+	return copy.fileSystem.Symlink(link, destination)
+}
+
+// copyFile streams source into destination with the permissions perm, so large files are never held in memory.
+func (copy *CopyService) copyFile(source string, destination string, perm os.FileMode) error {
+	reader, err := copy.fileSystem.Open(source)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	writer, err := copy.fileSystem.Create(destination, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(writer, reader); err != nil {
+		writer.Close()
+		return err
+	}
 
+	return writer.Close()
 }
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+)
+
+// IDiskUsageService is the interface to implement for measuring how much space a directory tree uses.
+type IDiskUsageService interface {
+	Usage(path string) (DiskUsage, error)
+}
+
+// DiskUsage is the number of files below a path and their total size in bytes.
+type DiskUsage struct {
+	Files int
+	Bytes int64
+}
+
+// DiskUsageService is the struct for this implementation of IDiskUsageService.
+type DiskUsageService struct {
+	fileSystem IFileSystem
+}
+
+// GetDiskUsageService returns a pointer to an implementation of IDiskUsageService.
+func GetDiskUsageService(fileSystem IFileSystem) *DiskUsageService {
+	var diskUsageService = DiskUsageService{
+		fileSystem,
+	}
+
+	return &diskUsageService
+}
+
+// Usage walks path and totals the regular files below it, a missing path uses no space.
+func (diskUsageService *DiskUsageService) Usage(path string) (DiskUsage, error) {
+	var usage DiskUsage
+
+	if _, err := diskUsageService.fileSystem.Stat(path); os.IsNotExist(err) {
+		return usage, nil
+	}
+
+	err := diskUsageService.fileSystem.Walk(path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.Mode().IsRegular() {
+			usage.Files++
+			usage.Bytes += info.Size()
+		}
+
+		return nil
+	})
+
+	return usage, err
+}
+
+// String formats the usage as a file count and human readable size.
+func (usage DiskUsage) String() string {
+	return fmt.Sprintf("%d files, %s", usage.Files, FormatBytes(usage.Bytes))
+}
+
+// FormatBytes formats a size in bytes using binary units, for example 1.5 MiB.
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// FakeCommand is a scripted response for FakeRunner.
+type FakeCommand struct {
+	// Command is the command line to answer, for example "git checkout v1.0.0".
+	Command string
+
+	// Dir restricts the response to commands run in that directory, empty matches any directory.
+	Dir string
+
+	Stdout string
+	Stderr string
+	Err    error
+
+	// ExitCode is returned by Exec.
+	ExitCode int
+
+	// Once responses answer a single call, later calls fall through to the next match.
+	Once bool
+	used bool
+
+	// Do is an optional side effect, for example creating a repository on a MemoryFileSystem for "go get".
+	Do func(dir string, env []string)
+}
+
+// FakeCall records a command run through a FakeRunner.
+type FakeCall struct {
+	Dir     string
+	Env     []string
+	Command string
+}
+
+// FakeRunner is a scripted implementation of ICommandRunner for tests. Commands are answered by the first
+// matching FakeCommand, anything unscripted fails.
+type FakeRunner struct {
+	mutex    sync.Mutex
+	commands []FakeCommand
+	calls    []FakeCall
+}
+
+// GetFakeRunner returns a pointer to a FakeRunner answering the given commands.
+func GetFakeRunner(commands ...FakeCommand) *FakeRunner {
+	var runner = FakeRunner{
+		commands: commands,
+	}
+
+	return &runner
+}
+
+// Script adds more scripted responses, checked after the existing ones.
+func (runner *FakeRunner) Script(commands ...FakeCommand) {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+
+	runner.commands = append(runner.commands, commands...)
+}
+
+// Run records the call and answers it from the script.
+func (runner *FakeRunner) Run(dir string, env []string, name string, args ...string) (string, string, error) {
+	command, match := runner.answer(dir, env, name, args)
+	if match == nil {
+		return "", "unscripted command: " + command, errors.New("unscripted command: " + command)
+	}
+
+	return match.Stdout, match.Stderr, match.Err
+}
+
+// Exec records the call and answers it from the script like Run, returning the scripted exit code.
+func (runner *FakeRunner) Exec(dir string, env []string, name string, args ...string) (int, error) {
+	command, match := runner.answer(dir, env, name, args)
+	if match == nil {
+		return 127, errors.New("unscripted command: " + command)
+	}
+
+	return match.ExitCode, match.Err
+}
+
+// answer records the call, runs the side effect of the first matching scripted command and returns it.
+func (runner *FakeRunner) answer(dir string, env []string, name string, args []string) (string, *FakeCommand) {
+	command := strings.Join(append([]string{name}, args...), " ")
+
+	runner.mutex.Lock()
+	runner.calls = append(runner.calls, FakeCall{dir, env, command})
+
+	var match *FakeCommand
+	for i := range runner.commands {
+		scripted := runner.commands[i]
+		if scripted.Command == command && (scripted.Dir == "" || scripted.Dir == dir) && !scripted.used {
+			runner.commands[i].used = scripted.Once
+			match = &scripted
+			break
+		}
+	}
+	runner.mutex.Unlock()
+
+	if match != nil && match.Do != nil {
+		match.Do(dir, env)
+	}
+
+	return command, match
+}
+
+// Calls returns every command run so far.
+func (runner *FakeRunner) Calls() []FakeCall {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+
+	return append([]FakeCall{}, runner.calls...)
+}
+
+// Ran reports whether command was run, in any directory.
+func (runner *FakeRunner) Ran(command string) bool {
+	for _, call := range runner.Calls() {
+		if call.Command == command {
+			return true
+		}
+	}
+
+	return false
+}
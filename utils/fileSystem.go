@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// IFileSystem is the interface to implement for filesystem access, so gobo can run against an in-memory tree.
+type IFileSystem interface {
+	Stat(path string) (os.FileInfo, error)
+	Lstat(path string) (os.FileInfo, error)
+	Readlink(path string) (string, error)
+	Symlink(target string, path string) error
+	Mkdir(path string, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+	ReadDir(path string) ([]os.FileInfo, error)
+	ReadFile(path string) ([]byte, error)
+	WriteFile(path string, data []byte, perm os.FileMode) error
+	Open(path string) (io.ReadCloser, error)
+	Create(path string, perm os.FileMode) (io.WriteCloser, error)
+	Remove(path string) error
+	RemoveAll(path string) error
+	Rename(source string, destination string) error
+	Walk(root string, walkFn filepath.WalkFunc) error
+}
+
+// FileSystem is the struct for the implementation of IFileSystem backed by the operating system.
+type FileSystem struct {
+}
+
+// GetFileSystem returns a pointer to the operating system implementation of IFileSystem.
+func GetFileSystem() *FileSystem {
+	var fileSystem = FileSystem{}
+
+	return &fileSystem
+}
+
+// Stat wraps os.Stat.
+func (fileSystem *FileSystem) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// Lstat wraps os.Lstat.
+func (fileSystem *FileSystem) Lstat(path string) (os.FileInfo, error) {
+	return os.Lstat(path)
+}
+
+// Readlink wraps os.Readlink.
+func (fileSystem *FileSystem) Readlink(path string) (string, error) {
+	return os.Readlink(path)
+}
+
+// Symlink wraps os.Symlink.
+func (fileSystem *FileSystem) Symlink(target string, path string) error {
+	return os.Symlink(target, path)
+}
+
+// Mkdir wraps os.Mkdir.
+func (fileSystem *FileSystem) Mkdir(path string, perm os.FileMode) error {
+	return os.Mkdir(path, perm)
+}
+
+// MkdirAll wraps os.MkdirAll.
+func (fileSystem *FileSystem) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+// ReadDir wraps ioutil.ReadDir.
+func (fileSystem *FileSystem) ReadDir(path string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(path)
+}
+
+// ReadFile wraps ioutil.ReadFile.
+func (fileSystem *FileSystem) ReadFile(path string) ([]byte, error) {
+	return ioutil.ReadFile(path)
+}
+
+// WriteFile wraps ioutil.WriteFile.
+func (fileSystem *FileSystem) WriteFile(path string, data []byte, perm os.FileMode) error {
+	return ioutil.WriteFile(path, data, perm)
+}
+
+// Open wraps os.Open.
+func (fileSystem *FileSystem) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// Create creates or truncates the file at path for writing and gives it the permissions perm, whether it existed
+// or not.
+func (fileSystem *FileSystem) Create(path string, perm os.FileMode) (io.WriteCloser, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := file.Chmod(perm); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// Remove wraps os.Remove.
+func (fileSystem *FileSystem) Remove(path string) error {
+	return os.Remove(path)
+}
+
+// RemoveAll wraps os.RemoveAll.
+func (fileSystem *FileSystem) RemoveAll(path string) error {
+	return os.RemoveAll(path)
+}
+
+// Rename wraps os.Rename.
+func (fileSystem *FileSystem) Rename(source string, destination string) error {
+	return os.Rename(source, destination)
+}
+
+// Walk wraps filepath.Walk.
+func (fileSystem *FileSystem) Walk(root string, walkFn filepath.WalkFunc) error {
+	return filepath.Walk(root, walkFn)
+}
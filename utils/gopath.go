@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+// IGopathService is the interface to implement for locating the GOPATH gobo operates on.
+type IGopathService interface {
+	Resolve() (string, error)
+	Validate(path string) error
+}
+
+// GopathService is the struct for this implementation of IGopathService.
+type GopathService struct {
+	logger     ILogger
+	fileSystem IFileSystem
+	runner     ICommandRunner
+	home       string
+	separator  string
+}
+
+// GetGopathService returns a pointer to an implementation of IGopathService.
+func GetGopathService(
+	logger ILogger,
+	fileSystem IFileSystem,
+	runner ICommandRunner,
+	home string,
+	separator string,
+) *GopathService {
+	var gopathService = GopathService{
+		logger,
+		fileSystem,
+		runner,
+		home,
+		separator,
+	}
+
+	return &gopathService
+}
+
+// Resolve determines the GOPATH the same way the go tool does and returns it with a trailing separator.
+// The GOPATH environment variable wins, then `go env GOPATH`, then the $HOME/go default. When the GOPATH
+// has several entries the first one is used, as that is where `go get` installs packages.
+func (gopathService *GopathService) Resolve() (string, error) {
+
+	gopath := os.Getenv("GOPATH")
+
+	if gopath == "" {
+		gopathService.logger.Info("GOPATH is not set, asking the go tool for it...")
+
+		out, _, err := gopathService.runner.Run("", nil, "go", "env", "GOPATH")
+		if err != nil {
+			gopathService.logger.Warn("Unable to run go env GOPATH: " + err.Error())
+		} else {
+			gopath = strings.TrimSpace(out)
+		}
+	}
+
+	if gopath == "" {
+		if gopathService.home == "" {
+			return "", errors.New("GOPATH is not set and no home directory is available to default it")
+		}
+
+		gopath = filepath.Join(gopathService.home, "go")
+		gopathService.logger.Info("Defaulting GOPATH to " + gopath)
+	}
+
+	entries := filepath.SplitList(gopath)
+	var paths []string
+	for _, entry := range entries {
+		if entry != "" {
+			paths = append(paths, entry)
+		}
+	}
+
+	if len(paths) == 0 {
+		return "", fmt.Errorf("GOPATH %q does not contain any usable entries", gopath)
+	}
+
+	if len(paths) > 1 {
+		gopathService.logger.Warn(fmt.Sprintf(
+			"GOPATH has %d entries, gobo only manages the first one: %s",
+			len(paths),
+			paths[0],
+		))
+	}
+
+	path := filepath.Clean(paths[0])
+
+	if err := gopathService.Validate(path); err != nil {
+		return "", err
+	}
+
+	return path + gopathService.separator, nil
+}
+
+// Validate refuses paths that gobo must never move or delete directories in, such as the filesystem root,
+// the home directory, or an existing directory that does not look like a GOPATH.
+func (gopathService *GopathService) Validate(path string) error {
+
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("GOPATH entry %q is not an absolute path", path)
+	}
+
+	path = filepath.Clean(path)
+
+	if path == filepath.Dir(path) {
+		return fmt.Errorf("refusing to use the filesystem root %q as GOPATH", path)
+	}
+
+	if gopathService.home != "" && path == filepath.Clean(gopathService.home) {
+		return fmt.Errorf("refusing to use the home directory %q as GOPATH", path)
+	}
+
+	info, err := gopathService.fileSystem.Stat(path)
+	if os.IsNotExist(err) {
+		// a fresh GOPATH, gobo will create the directories it needs
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("unable to inspect GOPATH %q: %s", path, err.Error())
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("GOPATH %q is not a directory", path)
+	}
+
+	entries, err := gopathService.fileSystem.ReadDir(path)
+	if err != nil {
+		return fmt.Errorf("unable to read GOPATH %q: %s", path, err.Error())
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	markers := append(models.GOPATHDIRECTORIES[:], "gobo.toml")
+
+	for _, entry := range entries {
+		for _, marker := range markers {
+			if entry.Name() == marker {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf(
+		"refusing to use %q as GOPATH, it contains none of %s",
+		path,
+		strings.Join(markers, ", "),
+	)
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGopathResolve(t *testing.T) {
+	list := string(os.PathListSeparator)
+
+	tests := []struct {
+		name   string
+		gopath string
+		goEnv  FakeCommand
+		files  []string
+		want   string
+		err    string
+	}{
+		{
+			name:   "GOPATH environment variable",
+			gopath: "/work/go",
+			want:   "/work/go/",
+		},
+		{
+			name:   "first of several GOPATH entries",
+			gopath: list + "/work/go" + list + "/other/go",
+			want:   "/work/go/",
+		},
+		{
+			name:  "go env GOPATH when the variable is not set",
+			goEnv: FakeCommand{Command: "go env GOPATH", Stdout: "/opt/go\n"},
+			want:  "/opt/go/",
+		},
+		{
+			name:  "$HOME/go when go env fails",
+			goEnv: FakeCommand{Command: "go env GOPATH", Err: errors.New("executable file not found")},
+			want:  "/home/gopher/go/",
+		},
+		{
+			name:   "existing GOPATH",
+			gopath: "/work/go",
+			files:  []string{"/work/go/src/example.com/a/a.go"},
+			want:   "/work/go/",
+		},
+		{
+			name:   "filesystem root",
+			gopath: "/",
+			err:    "refusing to use the filesystem root",
+		},
+		{
+			name:   "home directory",
+			gopath: "/home/gopher" + list + "/work/go",
+			err:    "refusing to use the home directory",
+		},
+		{
+			name:   "directory which is not a GOPATH",
+			gopath: "/work/docs",
+			files:  []string{"/work/docs/notes.txt"},
+			err:    "contains none of src, pkg, bin, gobo.toml",
+		},
+		{
+			name:   "file",
+			gopath: "/work/go",
+			files:  []string{"/work/go"},
+			err:    "is not a directory",
+		},
+		{
+			name:   "relative path",
+			gopath: "go",
+			err:    "is not an absolute path",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("GOPATH", test.gopath)
+
+			fileSystem := GetMemoryFileSystem()
+			for _, file := range test.files {
+				fileSystem.MkdirAll(file[:strings.LastIndex(file, "/")], 0755)
+				fileSystem.WriteFile(file, []byte("package a"), 0644)
+			}
+
+			runner := GetFakeRunner(test.goEnv)
+			logger, _ := GetConfiguredLogger(LogConfig{Level: PANIC})
+
+			gopath, err := GetGopathService(logger, fileSystem, runner, "/home/gopher", "/").Resolve()
+
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected an error containing %q, got %q, %v", test.err, gopath, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Resolve returned %v", err)
+			}
+			if gopath != test.want {
+				t.Errorf("expected %s, got %s", test.want, gopath)
+			}
+			if test.gopath != "" && runner.Ran("go env GOPATH") {
+				t.Error("asked the go tool although GOPATH is set")
+			}
+		})
+	}
+}
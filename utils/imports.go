@@ -0,0 +1,461 @@
+package utils
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+// IImportService is the interface to implement for working out which packages in the GOPATH import which.
+type IImportService interface {
+	Graph(repositories []string) (models.ImportGraph, error)
+	GraphOf(repositories []string, scope []string) (models.ImportGraph, error)
+}
+
+// ImportService is the struct for this implementation of IImportService.
+type ImportService struct {
+	logger     ILogger
+	fileSystem IFileSystem
+	gopath     string
+	separator  string
+}
+
+// GetImportService returns a pointer to an implementation of IImportService.
+func GetImportService(logger ILogger, fileSystem IFileSystem, gopath string, separator string) *ImportService {
+	var importService = ImportService{
+		logger,
+		fileSystem,
+		gopath,
+		separator,
+	}
+
+	return &importService
+}
+
+// Graph parses the Go sources under the GOPATH's src directory and returns the import graph of the packages
+// found, assigning them to the longest of the repositories containing them. Build constraints are ignored, so
+// the imports of every platform are in the graph, only files tagged ignore are skipped. vendor and testdata
+// directories, and the directories and files go ignores because they start with . or _, are skipped as well.
+func (importService *ImportService) Graph(repositories []string) (models.ImportGraph, error) {
+	return importService.walk(repositories, []string{importService.gopath + "src"})
+}
+
+// GraphOf is Graph limited to the packages at or below the import paths of scope, which is much quicker than
+// parsing all of a large GOPATH when only some of it matters. Missing paths are skipped.
+func (importService *ImportService) GraphOf(repositories []string, scope []string) (models.ImportGraph, error) {
+	var dirs []string
+	var walked []string
+
+	sorted := append([]string{}, scope...)
+	sort.Strings(sorted)
+	for _, path := range sorted {
+		// a path below one already walked is walked with it
+		if Containing(path, walked) != "" {
+			continue
+		}
+		walked = append(walked, path)
+
+		dir := importService.gopath + "src" + importService.separator + filepath.FromSlash(path)
+		if _, err := importService.fileSystem.Stat(dir); err == nil {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	return importService.walk(repositories, dirs)
+}
+
+// walk parses the packages below each of dirs into an import graph.
+func (importService *ImportService) walk(repositories []string, dirs []string) (models.ImportGraph, error) {
+	graph := models.ImportGraph{Imports: map[string][]string{}, Repositories: map[string]string{}}
+
+	src := importService.gopath + "src"
+
+	for _, dir := range dirs {
+		err := importService.fileSystem.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info == nil || !info.IsDir() {
+				return nil
+			}
+
+			name := info.Name()
+			if path != src && (name == "vendor" || name == "testdata" || ignored(name)) {
+				return filepath.SkipDir
+			}
+
+			imported, found := importService.imports(path)
+			if !found {
+				return nil
+			}
+
+			importPath := filepath.ToSlash(strings.TrimPrefix(path, src+importService.separator))
+			graph.Imports[importPath] = imported
+
+			if repository := Containing(importPath, repositories); repository != "" {
+				graph.Repositories[importPath] = repository
+			}
+
+			return nil
+		})
+		if err != nil {
+			return graph, err
+		}
+	}
+
+	return graph, nil
+}
+
+// imports returns the sorted imports, without duplicates, of the Go files in dir and its tests, whatever their
+// build constraints, and whether dir holds any Go file at all.
+func (importService *ImportService) imports(dir string) ([]string, bool) {
+	entries, err := importService.fileSystem.ReadDir(dir)
+	if err != nil {
+		return nil, false
+	}
+
+	found := false
+	seen := map[string]bool{}
+	var list []string
+	fileSet := token.NewFileSet()
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || ignored(name) {
+			continue
+		}
+
+		path := dir + importService.separator + name
+		data, err := importService.fileSystem.ReadFile(path)
+		if err != nil {
+			importService.logger.Warn("Unable to read " + path + ": " + err.Error())
+			continue
+		}
+
+		file, err := parser.ParseFile(fileSet, path, data, parser.ImportsOnly|parser.ParseComments)
+		if err != nil {
+			importService.logger.Warn("Reading the imports of " + path + " failed: " + err.Error())
+			continue
+		}
+		if taggedIgnore(file) {
+			continue
+		}
+		found = true
+
+		for _, spec := range file.Imports {
+			imported, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || imported == "C" || seen[imported] {
+				continue
+			}
+
+			seen[imported] = true
+			list = append(list, imported)
+		}
+	}
+
+	sort.Strings(list)
+
+	return list, found
+}
+
+// ignored reports whether go skips the file or directory name because it starts with . or _.
+func ignored(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+// taggedIgnore reports whether the build constraint of file is ignore, which no platform builds, as is usual
+// for generators kept next to a package.
+func taggedIgnore(file *ast.File) bool {
+	for _, group := range file.Comments {
+		if group.Pos() >= file.Package {
+			break
+		}
+
+		for _, comment := range group.List {
+			text := strings.TrimSpace(comment.Text)
+			if text == "//go:build ignore" || text == "// +build ignore" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Containing returns the longest of repositories which is path or a parent of it, or "" if there is none.
+func Containing(path string, repositories []string) string {
+	found := ""
+
+	for _, repository := range repositories {
+		if (path == repository || strings.HasPrefix(path, repository+"/")) && len(repository) > len(found) {
+			found = repository
+		}
+	}
+
+	return found
+}
+
+// IsStandard reports whether the import path belongs to the standard library, whose first element has no dot.
+func IsStandard(path string) bool {
+	first := strings.SplitN(path, "/", 2)[0]
+
+	return !strings.Contains(first, ".")
+}
+
+// NodeGraph collapses graph to the repositories and the code outside them, mapping each node to the sorted nodes
+// it imports. Imports of packages which are not in the GOPATH are left out, as are imports within a node.
+func NodeGraph(graph models.ImportGraph) map[string][]string {
+	edges := map[string]map[string]bool{}
+
+	for pkg, imported := range graph.Imports {
+		from := graph.Node(pkg)
+		if edges[from] == nil {
+			edges[from] = map[string]bool{}
+		}
+
+		for _, path := range imported {
+			if _, found := graph.Imports[path]; !found {
+				continue
+			}
+
+			if to := graph.Node(path); to != from {
+				edges[from][to] = true
+			}
+		}
+	}
+
+	nodes := map[string][]string{}
+	for from, targets := range edges {
+		nodes[from] = sortedKeys(targets)
+	}
+
+	return nodes
+}
+
+// Importers inverts a node graph, mapping each node to the sorted nodes importing it.
+func Importers(nodes map[string][]string) map[string][]string {
+	inverted := map[string]map[string]bool{}
+
+	for from, targets := range nodes {
+		for _, to := range targets {
+			if inverted[to] == nil {
+				inverted[to] = map[string]bool{}
+			}
+			inverted[to][from] = true
+		}
+	}
+
+	importers := map[string][]string{}
+	for to, sources := range inverted {
+		importers[to] = sortedKeys(sources)
+	}
+
+	return importers
+}
+
+// TopologicalOrder returns the nodes ordered so that every node comes after the nodes it imports, an import
+// cycle is broken at the import which closes it. Ties are ordered by path so the order is stable.
+func TopologicalOrder(nodes map[string][]string) []string {
+	var order []string
+	state := map[string]int{}
+
+	var visit func(node string)
+	visit = func(node string) {
+		// 1 is in progress, so an edge back to it is a cycle and is ignored, 2 is done
+		if state[node] != 0 {
+			return
+		}
+		state[node] = 1
+
+		for _, imported := range nodes[node] {
+			visit(imported)
+		}
+
+		state[node] = 2
+		order = append(order, node)
+	}
+
+	var all []string
+	for node := range nodes {
+		all = append(all, node)
+	}
+	sort.Strings(all)
+
+	for _, node := range all {
+		visit(node)
+	}
+
+	return order
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// OwnedNodes returns the nodes holding the environment's own code: those under one of projects, or when there
+// are no projects the code outside repositories and the repositories nothing else in the GOPATH imports.
+func OwnedNodes(nodes map[string][]string, repositories []string, projects []string) map[string]bool {
+	all := map[string]bool{}
+	for node := range nodes {
+		all[node] = true
+	}
+	for _, repository := range repositories {
+		all[repository] = true
+	}
+
+	isRepository := map[string]bool{}
+	for _, repository := range repositories {
+		isRepository[repository] = true
+	}
+
+	importers := Importers(nodes)
+	owned := map[string]bool{}
+
+	for node := range all {
+		switch {
+		case len(projects) > 0:
+			owned[node] = Containing(node, projects) != ""
+		default:
+			owned[node] = !isRepository[node] || len(importers[node]) == 0
+		}
+	}
+
+	return owned
+}
+
+// AnnotateLock records in lock which nodes of graph import each locked repository, and marks as direct those
+// imported by the environment's own code, or which are its own code, as OwnedNodes decides from projects.
+func AnnotateLock(lock *models.PackageLock, graph models.ImportGraph, projects []string) {
+	var repositories []string
+	for _, locked := range lock.Package {
+		repositories = append(repositories, locked.Path)
+	}
+
+	nodes := NodeGraph(graph)
+	importers := Importers(nodes)
+	owned := OwnedNodes(nodes, repositories, projects)
+
+	for i := range lock.Package {
+		locked := &lock.Package[i]
+		locked.ImportedBy = importers[locked.Path]
+		locked.Direct = owned[locked.Path]
+
+		for _, importer := range locked.ImportedBy {
+			if owned[importer] {
+				locked.Direct = true
+			}
+		}
+	}
+}
+
+// Reachable returns the packages of graph which are under one of roots, and every package of the GOPATH they
+// import directly or indirectly.
+func Reachable(graph models.ImportGraph, roots []string) map[string]bool {
+	reached := map[string]bool{}
+	var queue []string
+
+	for pkg := range graph.Imports {
+		if Containing(pkg, roots) != "" {
+			reached[pkg] = true
+			queue = append(queue, pkg)
+		}
+	}
+
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+
+		for _, imported := range graph.Imports[pkg] {
+			if _, found := graph.Imports[imported]; found && !reached[imported] {
+				reached[imported] = true
+				queue = append(queue, imported)
+			}
+		}
+	}
+
+	return reached
+}
+
+// ImportChains returns, for each of the packages in from which imports target or a package below it, directly or
+// not, a shortest chain of imports from that package to the target, sorted shortest first. Chains passing through
+// another package of from are left out, since that package's own chain covers them.
+func ImportChains(graph models.ImportGraph, from map[string]bool, target string) [][]string {
+	importers := map[string][]string{}
+	for pkg, imported := range graph.Imports {
+		for _, path := range imported {
+			importers[path] = append(importers[path], pkg)
+		}
+	}
+
+	// next leads each package one import closer to the target, ties go to the lowest path
+	next := map[string]string{}
+	reached := map[string]bool{}
+	var queue []string
+
+	for _, pkg := range sortedKeys(packageSet(graph)) {
+		if Containing(pkg, []string{target}) != "" {
+			reached[pkg] = true
+			queue = append(queue, pkg)
+		}
+	}
+
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+
+		sort.Strings(importers[pkg])
+		for _, importer := range importers[pkg] {
+			if !reached[importer] {
+				reached[importer] = true
+				next[importer] = pkg
+				queue = append(queue, importer)
+			}
+		}
+	}
+
+	var chains [][]string
+	for _, start := range sortedKeys(from) {
+		if !from[start] || !reached[start] || Containing(start, []string{target}) != "" {
+			continue
+		}
+
+		chain := []string{start}
+		for pkg, ok := next[start]; ok; pkg, ok = next[pkg] {
+			chain = append(chain, pkg)
+		}
+
+		covered := false
+		for _, pkg := range chain[1 : len(chain)-1] {
+			covered = covered || from[pkg]
+		}
+
+		if !covered {
+			chains = append(chains, chain)
+		}
+	}
+
+	sort.SliceStable(chains, func(i int, j int) bool {
+		return len(chains[i]) < len(chains[j])
+	})
+
+	return chains
+}
+
+func packageSet(graph models.ImportGraph) map[string]bool {
+	set := map[string]bool{}
+	for pkg := range graph.Imports {
+		set[pkg] = true
+	}
+
+	return set
+}
@@ -0,0 +1,143 @@
+package utils
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+func newTestImportService() (*ImportService, IFileSystem) {
+	fileSystem := GetMemoryFileSystem()
+	logger, _ := GetConfiguredLogger(LogConfig{Level: PANIC})
+
+	return GetImportService(logger, fileSystem, "/go/", "/"), fileSystem
+}
+
+func writeGoFile(fileSystem IFileSystem, dir string, name string, source string) {
+	fileSystem.MkdirAll("/go/src/"+dir, 0755)
+	fileSystem.WriteFile("/go/src/"+dir+"/"+name, []byte(source), 0644)
+}
+
+func TestImportGraphAndLockAnnotations(t *testing.T) {
+	importService, fileSystem := newTestImportService()
+
+	writeGoFile(fileSystem, "example.com/me/app", "main.go",
+		"package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/lib/sub\"\n)\n\nfunc main() { fmt.Println(sub.X) }\n")
+	writeGoFile(fileSystem, "example.com/lib/sub", "sub.go",
+		"package sub\n\nimport \"example.com/base\"\n\nvar X = base.Y\n")
+	writeGoFile(fileSystem, "example.com/lib/sub", "sub_test.go",
+		"package sub\n\nimport \"example.com/testonly\"\n\nvar _ = testonly.Z\n")
+	writeGoFile(fileSystem, "example.com/base", "base.go", "package base\n\nvar Y = 1\n")
+	writeGoFile(fileSystem, "example.com/testonly", "t.go", "package testonly\n\nvar Z = 1\n")
+	writeGoFile(fileSystem, "example.com/lib/vendor/example.com/hidden", "h.go", "package hidden\n")
+	writeGoFile(fileSystem, "example.com/me/app", "main_plan9.go",
+		"//go:build plan9\n\npackage main\n\nimport \"example.com/plan9only\"\n\nvar _ = plan9only.P\n")
+	writeGoFile(fileSystem, "example.com/me/app", "gen.go",
+		"//go:build ignore\n\npackage main\n\nimport \"example.com/generator\"\n")
+
+	repositories := []string{"example.com/lib", "example.com/base", "example.com/testonly"}
+	graph, err := importService.Graph(repositories)
+	if err != nil {
+		t.Fatalf("Graph returned %v", err)
+	}
+
+	if got := graph.Imports["example.com/me/app"]; !reflect.DeepEqual(got, []string{"example.com/lib/sub", "example.com/plan9only", "fmt"}) {
+		t.Errorf("unexpected imports of the app: %v", got)
+	}
+	if graph.Node("example.com/lib/sub") != "example.com/lib" {
+		t.Errorf("expected lib/sub to belong to example.com/lib, got %s", graph.Node("example.com/lib/sub"))
+	}
+	if _, ok := graph.Imports["example.com/lib/vendor/example.com/hidden"]; ok {
+		t.Error("the graph includes a vendored package")
+	}
+
+	order := TopologicalOrder(NodeGraph(graph))
+	position := map[string]int{}
+	for i, node := range order {
+		position[node] = i
+	}
+	if position["example.com/base"] > position["example.com/lib"] || position["example.com/lib"] > position["example.com/me/app"] {
+		t.Errorf("expected dependencies before their importers, got %v", order)
+	}
+
+	lock := models.PackageLock{Package: []models.LockedPackage{
+		{Path: "example.com/base"}, {Path: "example.com/lib"}, {Path: "example.com/testonly"},
+	}}
+	AnnotateLock(&lock, graph, []string{"example.com/me"})
+
+	if lock.Package[0].Direct || !lock.Package[1].Direct || lock.Package[2].Direct {
+		t.Errorf("expected only lib to be direct, got %+v", lock.Package)
+	}
+	if !reflect.DeepEqual(lock.Package[0].ImportedBy, []string{"example.com/lib"}) {
+		t.Errorf("expected base to be imported by lib, got %v", lock.Package[0].ImportedBy)
+	}
+	if !reflect.DeepEqual(lock.Package[1].ImportedBy, []string{"example.com/me/app"}) {
+		t.Errorf("expected lib to be imported by the app, got %v", lock.Package[1].ImportedBy)
+	}
+}
+
+func TestTopologicalOrderBreaksCycles(t *testing.T) {
+	order := TopologicalOrder(map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"a"},
+		"d": nil,
+	})
+
+	if !reflect.DeepEqual(order, []string{"c", "b", "a", "d"}) {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+func TestImportChainsFindShortestPaths(t *testing.T) {
+	graph := models.ImportGraph{Imports: map[string][]string{
+		"example.com/me/app":      {"example.com/me/internal", "example.com/x/long"},
+		"example.com/me/internal": {"example.com/y"},
+		"example.com/me/tool":     {"example.com/x/long"},
+		"example.com/x/long":      {"example.com/x/longer"},
+		"example.com/x/longer":    {"example.com/y/sub"},
+		"example.com/y":           nil,
+		"example.com/y/sub":       nil,
+	}}
+	from := map[string]bool{"example.com/me/app": true, "example.com/me/internal": true, "example.com/me/tool": true}
+
+	chains := ImportChains(graph, from, "example.com/y")
+
+	expected := [][]string{
+		{"example.com/me/internal", "example.com/y"},
+		{"example.com/me/tool", "example.com/x/long", "example.com/x/longer", "example.com/y/sub"},
+	}
+	if !reflect.DeepEqual(chains, expected) {
+		t.Errorf("expected %v, got %v", expected, chains)
+	}
+
+	if chains := ImportChains(graph, from, "example.com/me/tool"); len(chains) != 0 {
+		t.Errorf("expected nothing to import the tool, got %v", chains)
+	}
+}
+
+func TestGraphOfParsesOnlyTheScope(t *testing.T) {
+	importService, fileSystem := newTestImportService()
+
+	writeGoFile(fileSystem, "example.com/me/app", "main.go", "package main\n\nimport \"example.com/lib\"\n")
+	writeGoFile(fileSystem, "example.com/lib", "lib.go", "package lib\n")
+	writeGoFile(fileSystem, "example.com/lib/sub", "sub.go", "package sub\n")
+	writeGoFile(fileSystem, "example.com/other", "other.go", "package other\n")
+
+	graph, err := importService.GraphOf([]string{"example.com/lib"}, []string{"example.com/lib", "example.com/lib/sub", "example.com/me", "example.com/gone"})
+	if err != nil {
+		t.Fatalf("GraphOf returned %v", err)
+	}
+
+	var packages []string
+	for pkg := range graph.Imports {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+
+	if !reflect.DeepEqual(packages, []string{"example.com/lib", "example.com/lib/sub", "example.com/me/app"}) {
+		t.Errorf("expected only the packages in scope, got %v", packages)
+	}
+}
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// The identifiers the license classifier reports besides those of the licenses it recognises.
+const (
+	LICENSEUNKNOWN = "unknown"
+	LICENSENONE    = "none"
+)
+
+// ILicenseService is the interface to implement for finding and classifying the licenses of a repository.
+type ILicenseService interface {
+	Detect(dir string) ([]string, []string, error)
+}
+
+// LicenseService is the struct for this implementation of ILicenseService.
+type LicenseService struct {
+	fileSystem IFileSystem
+}
+
+// GetLicenseService returns a pointer to an implementation of ILicenseService.
+func GetLicenseService(fileSystem IFileSystem) *LicenseService {
+	var licenseService = LicenseService{
+		fileSystem,
+	}
+
+	return &licenseService
+}
+
+// Detect returns the licenses of the LICENSE, LICENCE, COPYING and UNLICENSE files at the top of dir, one per
+// file, and the names of those files. A repository without any has the license "none".
+func (licenseService *LicenseService) Detect(dir string) ([]string, []string, error) {
+	entries, err := licenseService.fileSystem.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []string{LICENSENONE}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var licenses []string
+	var files []string
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isLicenseFile(entry.Name()) {
+			continue
+		}
+
+		text, err := licenseService.fileSystem.ReadFile(dir + "/" + entry.Name())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		files = append(files, entry.Name())
+		licenses = append(licenses, ClassifyLicense(string(text)))
+	}
+
+	if len(files) == 0 {
+		return []string{LICENSENONE}, nil, nil
+	}
+
+	return licenses, files, nil
+}
+
+// isLicenseFile reports whether name is a license file, such as LICENSE, LICENSE.md, LICENSE-MIT or COPYING.LESSER.
+func isLicenseFile(name string) bool {
+	upper := strings.ToUpper(name)
+
+	for _, prefix := range []string{"LICENSE", "LICENCE", "COPYING", "UNLICENSE"} {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var licenseNoise = regexp.MustCompile(`[^a-z0-9.]+`)
+
+// licenseTitle matches the title of the GNU licenses and the Apache license, such as "GNU GENERAL PUBLIC LICENSE
+// Version 2", once normalized. The full texts quote each other, so only a title names the license.
+var licenseTitle = regexp.MustCompile(
+	`^(gnu (affero |lesser |library )?general public license|apache license) version (\d+(\.\d+)?)\b`,
+)
+
+// gnuNotice matches the notice applying a GNU license to a program, for license files which only hold that.
+var gnuNotice = regexp.MustCompile(
+	`terms of the (gnu (affero |lesser |library )?general public license) as published by the free software foundation either version (\d+(\.\d+)?)\b`,
+)
+
+// licenseTitleLines is how many of the first non blank lines of a license file are looked at for its title, a
+// copyright line or a project name may come first.
+const licenseTitleLines = 5
+
+// licenseRule recognises a license without a title by phrases its text must contain and phrases it must not.
+type licenseRule struct {
+	license  string
+	requires []string
+	excludes []string
+}
+
+// licenseRules are checked in order, once no title or GNU notice has named the license.
+var licenseRules = []licenseRule{
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "neither the name"}, nil},
+	{"BSD-3-Clause", []string{"redistribution and use in source and binary forms", "names of its contributors"}, nil},
+	{"BSD-2-Clause", []string{"redistribution and use in source and binary forms"}, []string{"advertising materials"}},
+	{"MIT", []string{"permission is hereby granted free of charge", "the above copyright notice and this permission notice shall be included"}, nil},
+}
+
+// ClassifyLicense returns the identifier of the license text, MIT, BSD-2-Clause, BSD-3-Clause, Apache-2.0 or one
+// of the GPL family, or "unknown". The GNU and Apache licenses are told apart by their title line, or a GNU
+// notice, the others by their wording. Case, punctuation and line breaks are ignored.
+func ClassifyLicense(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = normalizeLicense(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	for i := 0; i < len(lines) && i < licenseTitleLines; i++ {
+		// the version is usually on the line below the title
+		title := lines[i]
+		if i+1 < len(lines) {
+			title += " " + lines[i+1]
+		}
+
+		if match := licenseTitle.FindStringSubmatch(title); match != nil {
+			return titledLicense(match[1], match[3])
+		}
+	}
+
+	normalized := " " + strings.Join(lines, " ") + " "
+
+	if match := gnuNotice.FindStringSubmatch(normalized); match != nil {
+		return titledLicense(match[1], match[3])
+	}
+
+	for _, rule := range licenseRules {
+		if containsAll(normalized, rule.requires) && !containsAny(normalized, rule.excludes) {
+			return rule.license
+		}
+	}
+
+	return LICENSEUNKNOWN
+}
+
+// normalizeLicense lower cases text and turns everything but letters, digits and dots into single spaces.
+func normalizeLicense(text string) string {
+	return strings.TrimSpace(licenseNoise.ReplaceAllString(strings.ToLower(text), " "))
+}
+
+// titledLicense returns the identifier of the license named by title and version, for example GPL-2.0 for the
+// GNU General Public License version 2 or Apache-2.0.
+func titledLicense(title string, version string) string {
+	if !strings.Contains(version, ".") {
+		version += ".0"
+	}
+
+	switch {
+	case strings.HasPrefix(title, "apache"):
+		return "Apache-" + version
+	case strings.Contains(title, "affero"):
+		return "AGPL-" + version
+	case strings.Contains(title, "lesser"), strings.Contains(title, "library"):
+		return "LGPL-" + version
+	default:
+		return "GPL-" + version
+	}
+}
+
+// licenseFamilies lists the licenses named otherwise that a family takes in, the AGPL is the GPL with an added
+// network clause. Keys and values are lower case.
+var licenseFamilies = map[string][]string{
+	"gpl": {"agpl"},
+}
+
+// LicenseDenied reports whether license is one of denied, or of a family among them: GPL denies GPL-2.0, GPL-3.0
+// and AGPL-3.0 but not LGPL-2.1. The comparison ignores case.
+func LicenseDenied(license string, denied []string) bool {
+	license = strings.ToLower(license)
+
+	for _, deny := range denied {
+		deny = strings.ToLower(deny)
+
+		for _, family := range append([]string{deny}, licenseFamilies[deny]...) {
+			if license == family || strings.HasPrefix(license, family+"-") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func containsAll(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if !strings.Contains(text, phrase) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsAny(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestClassifyLicense(t *testing.T) {
+	// the full texts, the GNU ones quote the names of each other
+	for file, expected := range map[string]string{
+		"MIT":          "MIT",
+		"BSD-2-Clause": "BSD-2-Clause",
+		"BSD-3-Clause": "BSD-3-Clause",
+		"Apache-2.0":   "Apache-2.0",
+		"GPL-2.0":      "GPL-2.0",
+		"GPL-3.0":      "GPL-3.0",
+		"LGPL-2.1":     "LGPL-2.1",
+		"LGPL-3.0":     "LGPL-3.0",
+	} {
+		text, err := ioutil.ReadFile("testdata/licenses/" + file)
+		if err != nil {
+			t.Fatalf("reading %s: %v", file, err)
+		}
+
+		if license := ClassifyLicense(string(text)); license != expected {
+			t.Errorf("expected %s, got %s for testdata/licenses/%s", expected, license, file)
+		}
+	}
+
+	gpl, err := ioutil.ReadFile("testdata/licenses/GPL-3.0")
+	if err != nil {
+		t.Fatalf("reading GPL-3.0: %v", err)
+	}
+
+	for text, expected := range map[string]string{
+		// the Affero title over a body which quotes the GPL
+		"                    GNU AFFERO GENERAL PUBLIC LICENSE\n                       Version 3, 19 November 2007\n\n" +
+			string(gpl): "AGPL-3.0",
+		"Copyright (C) 2016 Gopher\n\nThis program is free software: you can redistribute it and/or modify it under the\n" +
+			"terms of the GNU General Public License as published by the Free Software Foundation, either version 3\n" +
+			"of the License, or (at your option) any later version.\n": "GPL-3.0",
+		"All rights reserved. Do not copy.": "unknown",
+	} {
+		if license := ClassifyLicense(text); license != expected {
+			t.Errorf("expected %s, got %s for:\n%.200s", expected, license, text)
+		}
+	}
+}
+
+func TestGPLTextsAreDenied(t *testing.T) {
+	for _, file := range []string{"GPL-2.0", "GPL-3.0"} {
+		text, err := ioutil.ReadFile("testdata/licenses/" + file)
+		if err != nil {
+			t.Fatalf("reading %s: %v", file, err)
+		}
+
+		if license := ClassifyLicense(string(text)); !LicenseDenied(license, []string{"GPL"}) {
+			t.Errorf("denying GPL let %s through as %s", file, license)
+		}
+	}
+}
+
+func TestLicenseDeniedMatchesFamilies(t *testing.T) {
+	deny := []string{"GPL", "unknown"}
+
+	for license, expected := range map[string]bool{
+		"GPL-3.0":  true,
+		"gpl-2.0":  true,
+		"AGPL-3.0": true,
+		"LGPL-2.1": false,
+		"LGPL-3.0": false,
+		"MIT":      false,
+		"unknown":  true,
+	} {
+		if LicenseDenied(license, deny) != expected {
+			t.Errorf("expected LicenseDenied(%s) to be %v", license, expected)
+		}
+	}
+
+	if LicenseDenied("GPL-3.0", []string{"AGPL"}) {
+		t.Error("denying AGPL denied GPL-3.0")
+	}
+	if !LicenseDenied("AGPL-3.0", []string{"agpl-3.0"}) {
+		t.Error("denying agpl-3.0 let AGPL-3.0 through")
+	}
+}
@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/camronlevanger/gobo/models"
+)
+
+// lockPollInterval is how often a waiting gobo process checks whether the lock has been released.
+const lockPollInterval = 250 * time.Millisecond
+
+// lockBreakTimeout is how old the file guarding the removal of a stale lock must be before it is itself considered
+// left behind, removing a lock takes far less.
+const lockBreakTimeout = 10 * time.Second
+
+// ILockService is the interface to implement for keeping gobo processes from running concurrently.
+type ILockService interface {
+	Acquire(command string) error
+	Release() error
+	Holder() (models.Lock, error)
+}
+
+// LockService is the struct for this implementation of ILockService. The lock is advisory, it is a file
+// created exclusively in the gobo home which records the holder's PID and command.
+type LockService struct {
+	logger  ILogger
+	path    string
+	wait    bool
+	timeout time.Duration
+	held    bool
+}
+
+// LockedError is returned when the lock is held by another gobo process.
+type LockedError struct {
+	Path   string
+	Holder models.Lock
+}
+
+func (err *LockedError) Error() string {
+	return fmt.Sprintf(
+		"gobo is already running as pid %d (%s) on %s since %s, remove %s if that process is gone",
+		err.Holder.PID,
+		err.Holder.Command,
+		err.Holder.Hostname,
+		err.Holder.Acquired.Format(time.RFC3339),
+		err.Path,
+	)
+}
+
+// GetLockService returns a pointer to an implementation of ILockService. When wait is true Acquire
+// retries until the lock is free or timeout elapses, a timeout of 0 waits forever.
+func GetLockService(logger ILogger, path string, wait bool, timeout time.Duration) *LockService {
+	var lockService = LockService{
+		logger,
+		path,
+		wait,
+		timeout,
+		false,
+	}
+
+	return &lockService
+}
+
+// Acquire takes the lock on behalf of command, clearing locks left behind by processes that no longer exist.
+func (lockService *LockService) Acquire(command string) error {
+
+	deadline := time.Now().Add(lockService.timeout)
+
+	for {
+		err := lockService.create(command)
+		if err == nil {
+			lockService.held = true
+			lockService.logger.Info("Acquired gobo lock at " + lockService.path)
+			return nil
+		}
+
+		if !os.IsExist(err) {
+			return fmt.Errorf("unable to create lock file %s: %s", lockService.path, err.Error())
+		}
+
+		holder, readErr := lockService.Holder()
+		if readErr == nil && lockService.isStale(holder) {
+			lockService.logger.WithFields(
+				"pid", holder.PID,
+				"command", holder.Command,
+			).Warn("Removing stale gobo lock left by a process that no longer exists")
+
+			removed, err := lockService.removeStale(holder)
+			if err != nil {
+				return fmt.Errorf("unable to remove stale lock file %s: %s", lockService.path, err.Error())
+			}
+			if !removed {
+				// another process is removing it
+				time.Sleep(lockPollInterval)
+			}
+			continue
+		}
+
+		if !lockService.wait || (lockService.timeout > 0 && time.Now().After(deadline)) {
+			return &LockedError{lockService.path, holder}
+		}
+
+		lockService.logger.Info(fmt.Sprintf("Waiting for gobo lock held by pid %d (%s)...", holder.PID, holder.Command))
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// Release removes the lock file if this process holds it.
+func (lockService *LockService) Release() error {
+	if !lockService.held {
+		return nil
+	}
+
+	lockService.held = false
+
+	err := os.Remove(lockService.path)
+	if os.IsNotExist(err) {
+		// restore removes the gobo home along with the lock
+		return nil
+	}
+
+	return err
+}
+
+// Holder reads the lock file and returns the process that holds it.
+func (lockService *LockService) Holder() (models.Lock, error) {
+	var holder models.Lock
+
+	data, err := ioutil.ReadFile(lockService.path)
+	if err != nil {
+		return holder, err
+	}
+
+	_, err = toml.Decode(string(data), &holder)
+
+	return holder, err
+}
+
+func (lockService *LockService) create(command string) error {
+	file, err := os.OpenFile(lockService.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hostname, _ := os.Hostname()
+
+	holder := models.Lock{
+		PID:      os.Getpid(),
+		Command:  command,
+		Hostname: hostname,
+		Acquired: time.Now(),
+	}
+
+	buf := new(bytes.Buffer)
+	if err = toml.NewEncoder(buf).Encode(holder); err != nil {
+		return err
+	}
+
+	_, err = file.Write(buf.Bytes())
+
+	return err
+}
+
+// removeStale removes the lock file if it still records holder, and reports false when another process is busy
+// removing it. Processes finding the same stale lock take turns through a second file, so one of them can't
+// remove the lock another has just taken in its place.
+func (lockService *LockService) removeStale(holder models.Lock) (bool, error) {
+	guard := lockService.path + ".break"
+
+	file, err := os.OpenFile(guard, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if os.IsExist(err) {
+		if info, statErr := os.Stat(guard); statErr == nil && time.Since(info.ModTime()) > lockBreakTimeout {
+			os.Remove(guard)
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	file.Close()
+	defer os.Remove(guard)
+
+	current, err := lockService.Holder()
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if current.PID != holder.PID || current.Hostname != holder.Hostname || !current.Acquired.Equal(holder.Acquired) {
+		return true, nil
+	}
+
+	err = os.Remove(lockService.path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+
+	return true, err
+}
+
+// isStale reports whether the holder was a process on this host that has since exited.
+func (lockService *LockService) isStale(holder models.Lock) bool {
+	hostname, _ := os.Hostname()
+	if holder.PID <= 0 || holder.Hostname != hostname {
+		return false
+	}
+
+	process, err := os.FindProcess(holder.PID)
+	if err != nil {
+		return true
+	}
+
+	if runtime.GOOS == "windows" {
+		// FindProcess only succeeds on windows when the process exists
+		return false
+	}
+
+	err = process.Signal(syscall.Signal(0))
+
+	return err != nil && err != syscall.EPERM
+}
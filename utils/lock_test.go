@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/camronlevanger/gobo/models"
+)
+
+func TestStaleLockIsOnlyRemovedOnce(t *testing.T) {
+	// the pid of a process which has exited
+	gone := exec.Command("true")
+	if err := gone.Run(); err != nil {
+		t.Skipf("unable to run true: %v", err)
+	}
+
+	hostname, _ := os.Hostname()
+	stale := models.Lock{PID: gone.Process.Pid, Command: "save", Hostname: hostname, Acquired: time.Now().Round(time.Second)}
+
+	buf := new(bytes.Buffer)
+	toml.NewEncoder(buf).Encode(stale)
+
+	path := filepath.Join(t.TempDir(), "gobo.lock")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("writing lock: %v", err)
+	}
+
+	logger, _ := GetConfiguredLogger(LogConfig{Level: PANIC})
+	first := GetLockService(logger, path, false, 0)
+	second := GetLockService(logger, path, false, 0)
+
+	holder, err := second.Holder()
+	if err != nil || !second.isStale(holder) {
+		t.Fatalf("expected a stale holder, got %+v, %v", holder, err)
+	}
+
+	if err := first.Acquire("activate"); err != nil {
+		t.Fatalf("Acquire returned %v", err)
+	}
+
+	// second read the stale holder before first replaced it
+	if _, err := second.removeStale(holder); err != nil {
+		t.Fatalf("removeStale returned %v", err)
+	}
+
+	current, err := first.Holder()
+	if err != nil || current.PID != os.Getpid() || current.Command != "activate" {
+		t.Errorf("the lock taken over from the stale holder was removed, it holds %+v, %v", current, err)
+	}
+
+	if err := second.Acquire("save"); err == nil {
+		t.Error("a second process acquired the lock")
+	}
+
+	first.Release()
+	if _, err := os.Stat(path + ".break"); err == nil {
+		t.Error("the file guarding the removal was left behind")
+	}
+}
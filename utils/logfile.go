@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+// DefaultLogMaxSize is the size at which the gobo log file is rotated when no size is configured.
+const DefaultLogMaxSize = 5 * 1024 * 1024
+
+// DefaultLogMaxFiles is the number of rotated log files kept when no count is configured.
+const DefaultLogMaxFiles = 3
+
+// RotatingFile is an io.WriteCloser which renames the file to path.1, path.2, ... as it grows past maxSize.
+type RotatingFile struct {
+	mutex    sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// GetRotatingFile opens, creating if needed, the log file at path for appending.
+func GetRotatingFile(path string, maxSize int64, maxFiles int) (*RotatingFile, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultLogMaxSize
+	}
+
+	if maxFiles <= 0 {
+		maxFiles = DefaultLogMaxFiles
+	}
+
+	rotating := RotatingFile{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), models.FILEMODE); err != nil {
+		return nil, err
+	}
+
+	if err := rotating.open(); err != nil {
+		return nil, err
+	}
+
+	return &rotating, nil
+}
+
+// Write appends p to the log file, rotating first when p would take the file past its maximum size.
+func (rotating *RotatingFile) Write(p []byte) (int, error) {
+	rotating.mutex.Lock()
+	defer rotating.mutex.Unlock()
+
+	if rotating.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if rotating.size > 0 && rotating.size+int64(len(p)) > rotating.maxSize {
+		if err := rotating.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rotating.file.Write(p)
+	rotating.size += int64(n)
+
+	return n, err
+}
+
+// Close closes the current log file.
+func (rotating *RotatingFile) Close() error {
+	rotating.mutex.Lock()
+	defer rotating.mutex.Unlock()
+
+	if rotating.file == nil {
+		return nil
+	}
+
+	err := rotating.file.Close()
+	rotating.file = nil
+
+	return err
+}
+
+func (rotating *RotatingFile) open() error {
+	file, err := os.OpenFile(rotating.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	rotating.file = file
+	rotating.size = info.Size()
+
+	return nil
+}
+
+func (rotating *RotatingFile) rotate() error {
+	rotating.file.Close()
+	rotating.file = nil
+
+	os.Remove(fmt.Sprintf("%s.%d", rotating.path, rotating.maxFiles))
+	for i := rotating.maxFiles - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", rotating.path, i), fmt.Sprintf("%s.%d", rotating.path, i+1))
+	}
+
+	if err := os.Rename(rotating.path, rotating.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return rotating.open()
+}
@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFileRotatesPastMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "gobo.log")
+
+	rotating, err := GetRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("GetRotatingFile returned %v", err)
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := rotating.Write([]byte(line)); err != nil {
+			t.Fatalf("Write returned %v", err)
+		}
+	}
+	rotating.Close()
+
+	want := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for file, content := range want {
+		data, err := ioutil.ReadFile(file)
+		if err != nil || string(data) != content {
+			t.Errorf("expected %s to hold %q, got %q, %v", file, content, data, err)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("kept more rotated files than the maximum of 2")
+	}
+
+	if _, err := rotating.Write([]byte("closed\n")); err == nil {
+		t.Error("Write succeeded after Close")
+	}
+}
+
+func TestRotatingFileAppendsBelowMaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gobo.log")
+
+	for _, line := range []string{"one\n", "two\n"} {
+		rotating, err := GetRotatingFile(path, 100, 2)
+		if err != nil {
+			t.Fatalf("GetRotatingFile returned %v", err)
+		}
+		rotating.Write([]byte(line))
+		rotating.Close()
+	}
+
+	data, _ := ioutil.ReadFile(path)
+	if string(data) != "one\ntwo\n" {
+		t.Errorf("expected both lines appended to gobo.log, got %q", data)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Error("rotated a file below its maximum size")
+	}
+}
@@ -1,58 +1,318 @@
 package utils
 
 import (
-	"log"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
+// LogLevel is the severity of a log message.
+type LogLevel int
+
+// The log levels in increasing order of severity.
+const (
+	DEBUG LogLevel = iota
+	INFO
+	WARN
+	ERROR
+	FATAL
+	PANIC
+)
+
+var levelNames = map[LogLevel]string{
+	DEBUG: "DEBUG",
+	INFO:  "INFO",
+	WARN:  "WARN",
+	ERROR: "ERROR",
+	FATAL: "FATAL",
+	PANIC: "PANIC",
+}
+
+func (level LogLevel) String() string {
+	if name, ok := levelNames[level]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("LEVEL(%d)", int(level))
+}
+
+// ParseLogLevel turns a level name such as "warn" into a LogLevel.
+func ParseLogLevel(name string) (LogLevel, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	if strings.EqualFold(name, "warning") {
+		return WARN, nil
+	}
+
+	return INFO, errors.New("unknown log level " + name + ", expected debug, info, warn or error")
+}
+
 // ILogger is the interface to implement for simple console logging.
 type ILogger interface {
+	Debug(message string)
 	Info(message string)
 	Warn(message string)
 	Error(message string)
 	Fatal(message string)
 	Panic(message string)
+	WithFields(fields ...interface{}) ILogger
+	OnExit(hook func())
+	Close() error
+}
+
+// LogConfig describes where a Logger writes and what it keeps.
+type LogConfig struct {
+	// Level is the lowest level printed to the console.
+	Level LogLevel
+
+	// Format is either "text" or "json".
+	Format string
+
+	// Directory receives the rotating gobo.log file, an empty Directory disables the file sink.
+	Directory string
+
+	// MaxSize is the size in bytes at which gobo.log is rotated.
+	MaxSize int64
+
+	// MaxFiles is the number of rotated log files kept next to gobo.log.
+	MaxFiles int
+}
+
+// logSinks holds the writers shared by a Logger and every Logger derived from it with WithFields.
+type logSinks struct {
+	mutex   sync.Mutex
+	console io.Writer
+	file    *RotatingFile
+
+	// exitHooks run before Fatal exits, which skips deferred calls.
+	exitHooks []func()
 }
 
 // Logger is the struct for this implementation of the ILogger interface.
 type Logger struct {
-	noisy bool
+	level     LogLevel
+	fileLevel LogLevel
+	format    string
+	fields    []interface{}
+	sinks     *logSinks
 }
 
-// GetLogger returns a pointer to an implementation of the ILogger interface.
+// GetLogger returns a pointer to an implementation of the ILogger interface which only writes to the console.
+// Warnings and errors are always printed, verbose adds info messages.
 func GetLogger(verbose bool) ILogger {
 
+	level := WARN
+	if verbose {
+		level = INFO
+	}
+
+	logger, _ := GetConfiguredLogger(LogConfig{Level: level, Format: "text"})
+
+	return logger
+}
+
+// GetConfiguredLogger returns a pointer to an implementation of the ILogger interface built from config.
+// The file sink records info messages and above whatever the console level is, so there is always a
+// persistent record of what gobo did.
+func GetConfiguredLogger(config LogConfig) (ILogger, error) {
+
+	format := strings.ToLower(config.Format)
+	if format == "" {
+		format = "text"
+	}
+
+	if format != "text" && format != "json" {
+		return nil, errors.New("unknown log format " + config.Format + ", expected text or json")
+	}
+
+	fileLevel := INFO
+	if config.Level < fileLevel {
+		fileLevel = config.Level
+	}
+
 	var logger = Logger{
-		verbose,
+		config.Level,
+		fileLevel,
+		format,
+		nil,
+		&logSinks{console: os.Stderr},
+	}
+
+	if config.Directory != "" {
+		file, err := GetRotatingFile(
+			filepath.Join(config.Directory, "gobo.log"),
+			config.MaxSize,
+			config.MaxFiles,
+		)
+		if err != nil {
+			return &logger, err
+		}
+
+		logger.sinks.file = file
 	}
 
-	return &logger
+	return &logger, nil
+}
+
+// Debug logs a message useful when working on gobo itself.
+func (logger *Logger) Debug(message string) {
+	logger.write(DEBUG, message)
 }
 
-// Info calls log.Println if verbose == true.
+// Info logs a message about normal progress, printed to the console with -v.
 func (logger *Logger) Info(message string) {
-	if logger.noisy {
-		log.Println(message)
-	}
+	logger.write(INFO, message)
 }
 
-// Warn calls log.Println if verbose == true.
+// Warn logs a message about something the user should know about.
 func (logger *Logger) Warn(message string) {
-	if logger.noisy {
-		log.Println(message)
-	}
+	logger.write(WARN, message)
 }
 
-// Error calls log.Println.
+// Error logs a message about a failed operation.
 func (logger *Logger) Error(message string) {
-	log.Println(message)
+	logger.write(ERROR, message)
 }
 
-// Fatal calls log.Fatal.
+// Fatal logs the message, runs the exit hooks and exits the app.
 func (logger *Logger) Fatal(message string) {
-	log.Fatalln(message)
+	logger.write(FATAL, message)
+
+	logger.sinks.mutex.Lock()
+	hooks := logger.sinks.exitHooks
+	logger.sinks.exitHooks = nil
+	logger.sinks.mutex.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i]()
+	}
+
+	logger.Close()
+	os.Exit(1)
 }
 
-// Panic calls log.Panic.
+// OnExit registers hook to run when Fatal exits the app, such as the release of a lock a deferred call would
+// have done. Hooks run in the reverse order of registration, like deferred calls.
+func (logger *Logger) OnExit(hook func()) {
+	logger.sinks.mutex.Lock()
+	defer logger.sinks.mutex.Unlock()
+
+	logger.sinks.exitHooks = append(logger.sinks.exitHooks, hook)
+}
+
+// Panic logs the message and panics.
 func (logger *Logger) Panic(message string) {
-	log.Panicln(message)
+	logger.write(PANIC, message)
+	panic(message)
+}
+
+// WithFields returns a logger which adds the given key/value pairs to every message it logs.
+func (logger *Logger) WithFields(fields ...interface{}) ILogger {
+	if len(fields)%2 != 0 {
+		fields = append(fields, "(missing)")
+	}
+
+	child := *logger
+	child.fields = append(append([]interface{}{}, logger.fields...), fields...)
+
+	return &child
+}
+
+// Close flushes and closes the log file.
+func (logger *Logger) Close() error {
+	logger.sinks.mutex.Lock()
+	defer logger.sinks.mutex.Unlock()
+
+	if logger.sinks.file == nil {
+		return nil
+	}
+
+	err := logger.sinks.file.Close()
+	logger.sinks.file = nil
+
+	return err
+}
+
+func (logger *Logger) write(level LogLevel, message string) {
+	toConsole := level >= logger.level
+	toFile := level >= logger.fileLevel && logger.sinks.file != nil
+
+	if !toConsole && !toFile {
+		return
+	}
+
+	line := logger.render(time.Now(), level, message)
+
+	logger.sinks.mutex.Lock()
+	defer logger.sinks.mutex.Unlock()
+
+	if toConsole {
+		logger.sinks.console.Write(line)
+	}
+
+	if toFile && logger.sinks.file != nil {
+		if _, err := logger.sinks.file.Write(line); err != nil {
+			fmt.Fprintln(logger.sinks.console, "Unable to write to the gobo log file: "+err.Error())
+		}
+	}
+}
+
+func (logger *Logger) render(now time.Time, level LogLevel, message string) []byte {
+	buf := new(bytes.Buffer)
+
+	if logger.format == "json" {
+		buf.WriteString(`{"time":`)
+		writeJSON(buf, now.Format(time.RFC3339))
+		buf.WriteString(`,"level":`)
+		writeJSON(buf, strings.ToLower(level.String()))
+		buf.WriteString(`,"msg":`)
+		writeJSON(buf, strings.TrimRight(message, "\n"))
+		for i := 0; i < len(logger.fields); i += 2 {
+			buf.WriteString(",")
+			writeJSON(buf, fmt.Sprint(logger.fields[i]))
+			buf.WriteString(":")
+			writeJSON(buf, logger.fields[i+1])
+		}
+		buf.WriteString("}\n")
+
+		return buf.Bytes()
+	}
+
+	buf.WriteString(now.Format("2006/01/02 15:04:05 "))
+	buf.WriteString(fmt.Sprintf("%-5s ", level.String()))
+	buf.WriteString(strings.TrimRight(message, "\n"))
+	for i := 0; i < len(logger.fields); i += 2 {
+		value := fmt.Sprint(logger.fields[i+1])
+		if strings.ContainsAny(value, " \t\n\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		buf.WriteString(fmt.Sprintf(" %v=%s", logger.fields[i], value))
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes()
+}
+
+func writeJSON(buf *bytes.Buffer, value interface{}) {
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+
+	buf.Write(encoded)
 }
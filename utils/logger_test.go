@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureConsole points the console sink of logger at a buffer.
+func captureConsole(logger ILogger) *bytes.Buffer {
+	console := new(bytes.Buffer)
+	logger.(*Logger).sinks.console = console
+
+	return console
+}
+
+func TestLoggerFiltersLevels(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := GetConfiguredLogger(LogConfig{Level: WARN, Directory: dir})
+	if err != nil {
+		t.Fatalf("GetConfiguredLogger returned %v", err)
+	}
+	console := captureConsole(logger)
+
+	logger.Debug("debugging")
+	logger.Info("informing")
+	logger.Warn("warning")
+	logger.Error("failing")
+	logger.Close()
+
+	for _, want := range []string{"WARN  warning", "ERROR failing"} {
+		if !strings.Contains(console.String(), want) {
+			t.Errorf("console is missing %q:\n%s", want, console)
+		}
+	}
+	for _, unwanted := range []string{"debugging", "informing"} {
+		if strings.Contains(console.String(), unwanted) {
+			t.Errorf("console printed %q below its WARN level", unwanted)
+		}
+	}
+
+	file, err := ioutil.ReadFile(filepath.Join(dir, "gobo.log"))
+	if err != nil {
+		t.Fatalf("reading gobo.log: %v", err)
+	}
+	for _, want := range []string{"INFO  informing", "WARN  warning", "ERROR failing"} {
+		if !strings.Contains(string(file), want) {
+			t.Errorf("gobo.log is missing %q, the file records info and above:\n%s", want, file)
+		}
+	}
+	if strings.Contains(string(file), "debugging") {
+		t.Error("gobo.log recorded a debug message without -log-level debug")
+	}
+}
+
+func TestLoggerFileFollowsLowerConsoleLevel(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, _ := GetConfiguredLogger(LogConfig{Level: DEBUG, Directory: dir})
+	captureConsole(logger)
+
+	logger.Debug("debugging")
+	logger.Close()
+
+	file, _ := ioutil.ReadFile(filepath.Join(dir, "gobo.log"))
+	if !strings.Contains(string(file), "DEBUG debugging") {
+		t.Errorf("gobo.log is missing the debug message:\n%s", file)
+	}
+}
+
+func TestLoggerRendersJSONFields(t *testing.T) {
+	logger, err := GetConfiguredLogger(LogConfig{Level: INFO, Format: "JSON"})
+	if err != nil {
+		t.Fatalf("GetConfiguredLogger returned %v", err)
+	}
+	console := captureConsole(logger)
+
+	logger.WithFields("env", "dev", "err", errors.New("exit status 1"), "count", 2, "odd").Warn("say \"hi\"\n")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(console.Bytes(), &entry); err != nil {
+		t.Fatalf("logged invalid JSON %q: %v", console, err)
+	}
+
+	want := map[string]interface{}{
+		"level": "warn",
+		"msg":   "say \"hi\"",
+		"env":   "dev",
+		"err":   "exit status 1",
+		"count": float64(2),
+		"odd":   "(missing)",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("expected %s to be %v, got %v", key, value, entry[key])
+		}
+	}
+	if _, ok := entry["time"]; !ok {
+		t.Error("the entry has no time")
+	}
+
+	if _, err := GetConfiguredLogger(LogConfig{Format: "xml"}); err == nil {
+		t.Error("GetConfiguredLogger accepted an unknown format")
+	}
+}
+
+func TestLoggerTextQuotesFieldValues(t *testing.T) {
+	logger, _ := GetConfiguredLogger(LogConfig{Level: INFO})
+	console := captureConsole(logger)
+
+	logger.WithFields("env", "dev", "path", "my project").Info("activated")
+
+	if !strings.HasSuffix(console.String(), "INFO  activated env=dev path=\"my project\"\n") {
+		t.Errorf("unexpected text line %q", console)
+	}
+}
+
+func TestParseLogLevel(t *testing.T) {
+	for name, want := range map[string]LogLevel{"debug": DEBUG, "INFO": INFO, "warning": WARN, "Error": ERROR} {
+		level, err := ParseLogLevel(name)
+		if err != nil || level != want {
+			t.Errorf("ParseLogLevel(%q) returned %v, %v", name, level, err)
+		}
+	}
+
+	if _, err := ParseLogLevel("loud"); err == nil {
+		t.Error("ParseLogLevel accepted an unknown level")
+	}
+}
@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+// MANIFESTFILE is the name of the manifest written at the root of every backup and snapshot.
+const MANIFESTFILE = "manifest.toml"
+
+// IManifestService is the interface to implement for recording and checking the contents of a directory.
+type IManifestService interface {
+	Build(root string) (models.Manifest, error)
+	Verify(root string, manifest models.Manifest) []ManifestProblem
+}
+
+// ManifestService is the struct for this implementation of IManifestService.
+type ManifestService struct {
+	logger     ILogger
+	fileSystem IFileSystem
+}
+
+// ManifestProblem describes a difference between a directory and its manifest.
+type ManifestProblem struct {
+	Path    string
+	Problem string
+}
+
+func (problem ManifestProblem) String() string {
+	return problem.Path + ": " + problem.Problem
+}
+
+// GetManifestService returns a pointer to an implementation of IManifestService.
+func GetManifestService(logger ILogger, fileSystem IFileSystem) *ManifestService {
+	var manifestService = ManifestService{
+		logger,
+		fileSystem,
+	}
+
+	return &manifestService
+}
+
+// Build walks root and records the path, size, mode and SHA-256 hash of every file below it, and the target of
+// every symbolic link, except the manifest itself.
+func (manifestService *ManifestService) Build(root string) (models.Manifest, error) {
+
+	manifest := models.Manifest{Created: time.Now()}
+
+	files, err := manifestService.scan(root)
+	if err != nil {
+		return manifest, err
+	}
+
+	for _, file := range files {
+		manifest.File = append(manifest.File, file)
+	}
+
+	sort.Slice(manifest.File, func(i, j int) bool {
+		return manifest.File[i].Path < manifest.File[j].Path
+	})
+
+	manifestService.logger.Info(fmt.Sprintf("Built manifest of %d files for %s", len(manifest.File), root))
+
+	return manifest, nil
+}
+
+// Verify compares root with manifest and returns every missing, changed or unexpected file or link.
+func (manifestService *ManifestService) Verify(root string, manifest models.Manifest) []ManifestProblem {
+
+	var problems []ManifestProblem
+
+	files, err := manifestService.scan(root)
+	if err != nil {
+		return []ManifestProblem{{root, err.Error()}}
+	}
+
+	for _, expected := range manifest.File {
+		actual, ok := files[expected.Path]
+		if !ok {
+			problems = append(problems, ManifestProblem{expected.Path, "missing"})
+			continue
+		}
+		delete(files, expected.Path)
+
+		if actual.Link != expected.Link {
+			problems = append(problems, ManifestProblem{
+				expected.Path,
+				fmt.Sprintf("links to %q, expected %q", actual.Link, expected.Link),
+			})
+			continue
+		}
+
+		if actual.Size != expected.Size {
+			problems = append(problems, ManifestProblem{
+				expected.Path,
+				fmt.Sprintf("size is %d, expected %d", actual.Size, expected.Size),
+			})
+		} else if actual.SHA256 != expected.SHA256 {
+			problems = append(problems, ManifestProblem{expected.Path, "contents do not match the recorded hash"})
+		}
+
+		if actual.Mode != expected.Mode {
+			problems = append(problems, ManifestProblem{
+				expected.Path,
+				fmt.Sprintf("mode is %v, expected %v", os.FileMode(actual.Mode), os.FileMode(expected.Mode)),
+			})
+		}
+	}
+
+	var unexpected []string
+	for path := range files {
+		unexpected = append(unexpected, path)
+	}
+	sort.Strings(unexpected)
+
+	for _, path := range unexpected {
+		problems = append(problems, ManifestProblem{path, "not in the manifest"})
+	}
+
+	return problems
+}
+
+// scan hashes every file and reads every symbolic link below root, keyed by slash separated relative path.
+func (manifestService *ManifestService) scan(root string) (map[string]models.ManifestFile, error) {
+
+	files := map[string]models.ManifestFile{}
+
+	err := manifestService.fileSystem.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		link := info.Mode()&os.ModeSymlink != 0
+		if info.IsDir() || info.Mode()&os.ModeType != 0 && !link {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if rel == MANIFESTFILE {
+			return nil
+		}
+
+		if link {
+			target, err := manifestService.fileSystem.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			files[rel] = models.ManifestFile{Path: rel, Link: target}
+			return nil
+		}
+
+		data, err := manifestService.fileSystem.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+
+		files[rel] = models.ManifestFile{
+			Path:   rel,
+			Size:   int64(len(data)),
+			Mode:   uint32(info.Mode().Perm()),
+			SHA256: hex.EncodeToString(sum[:]),
+		}
+
+		return nil
+	})
+
+	return files, err
+}
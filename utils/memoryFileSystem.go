@@ -0,0 +1,497 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// MemoryFileSystem is an in-memory implementation of IFileSystem for tests. Paths are cleaned with
+// filepath.Clean, and the root directory always exists. Only Stat and ReadFile follow symbolic links.
+type MemoryFileSystem struct {
+	mutex sync.Mutex
+	nodes map[string]*memoryNode
+}
+
+type memoryNode struct {
+	dir     bool
+	link    string
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+}
+
+// memoryFileInfo is the os.FileInfo returned by MemoryFileSystem.
+type memoryFileInfo struct {
+	name string
+	node memoryNode
+}
+
+func (info *memoryFileInfo) Name() string { return info.name }
+
+func (info *memoryFileInfo) Size() int64 { return int64(len(info.node.data)) }
+
+func (info *memoryFileInfo) ModTime() time.Time { return info.node.modTime }
+
+func (info *memoryFileInfo) IsDir() bool { return info.node.dir }
+
+func (info *memoryFileInfo) Sys() interface{} { return nil }
+
+func (info *memoryFileInfo) Mode() os.FileMode {
+	if info.node.dir {
+		return info.node.mode | os.ModeDir
+	}
+	if info.node.link != "" {
+		return info.node.mode | os.ModeSymlink
+	}
+
+	return info.node.mode
+}
+
+// GetMemoryFileSystem returns a pointer to an empty MemoryFileSystem.
+func GetMemoryFileSystem() *MemoryFileSystem {
+	var fileSystem = MemoryFileSystem{
+		nodes: map[string]*memoryNode{
+			string(filepath.Separator): {dir: true, mode: 0755, modTime: time.Now()},
+		},
+	}
+
+	return &fileSystem
+}
+
+// Stat returns the os.FileInfo for path, following a symbolic link.
+func (fileSystem *MemoryFileSystem) Stat(path string) (os.FileInfo, error) {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	path = filepath.Clean(path)
+	node, err := fileSystem.resolve("stat", path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memoryFileInfo{filepath.Base(path), *node}, nil
+}
+
+// Lstat returns the os.FileInfo for path, describing a symbolic link rather than what it points to.
+func (fileSystem *MemoryFileSystem) Lstat(path string) (os.FileInfo, error) {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	path = filepath.Clean(path)
+	node, ok := fileSystem.nodes[path]
+	if !ok {
+		return nil, memoryError("lstat", path, syscall.ENOENT)
+	}
+
+	return &memoryFileInfo{filepath.Base(path), *node}, nil
+}
+
+// Readlink returns the target of the symbolic link at path.
+func (fileSystem *MemoryFileSystem) Readlink(path string) (string, error) {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	path = filepath.Clean(path)
+	node, ok := fileSystem.nodes[path]
+	if !ok {
+		return "", memoryError("readlink", path, syscall.ENOENT)
+	}
+	if node.link == "" {
+		return "", memoryError("readlink", path, syscall.EINVAL)
+	}
+
+	return node.link, nil
+}
+
+// Symlink creates path as a symbolic link to target, which need not exist. The parent of path must exist.
+func (fileSystem *MemoryFileSystem) Symlink(target string, path string) error {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	path = filepath.Clean(path)
+	if _, ok := fileSystem.nodes[path]; ok {
+		return &os.LinkError{Op: "symlink", Old: target, New: path, Err: syscall.EEXIST}
+	}
+
+	if err := fileSystem.checkParent("symlink", path); err != nil {
+		return &os.LinkError{Op: "symlink", Old: target, New: path, Err: syscall.ENOENT}
+	}
+
+	fileSystem.nodes[path] = &memoryNode{link: target, mode: 0777, modTime: time.Now()}
+
+	return nil
+}
+
+// Mkdir creates the directory path, whose parent must already exist.
+func (fileSystem *MemoryFileSystem) Mkdir(path string, perm os.FileMode) error {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	path = filepath.Clean(path)
+	if _, ok := fileSystem.nodes[path]; ok {
+		return memoryError("mkdir", path, syscall.EEXIST)
+	}
+
+	if err := fileSystem.checkParent("mkdir", path); err != nil {
+		return err
+	}
+
+	fileSystem.nodes[path] = &memoryNode{dir: true, mode: perm.Perm(), modTime: time.Now()}
+
+	return nil
+}
+
+// MkdirAll creates the directory path along with any missing parents.
+func (fileSystem *MemoryFileSystem) MkdirAll(path string, perm os.FileMode) error {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	return fileSystem.mkdirAll(filepath.Clean(path), perm)
+}
+
+// ReadDir returns the entries of the directory path sorted by name.
+func (fileSystem *MemoryFileSystem) ReadDir(path string) ([]os.FileInfo, error) {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	path = filepath.Clean(path)
+	node, ok := fileSystem.nodes[path]
+	if !ok {
+		return nil, memoryError("open", path, syscall.ENOENT)
+	}
+	if !node.dir {
+		return nil, memoryError("readdirent", path, syscall.ENOTDIR)
+	}
+
+	var infos []os.FileInfo
+	for _, child := range fileSystem.children(path) {
+		infos = append(infos, &memoryFileInfo{filepath.Base(child), *fileSystem.nodes[child]})
+	}
+
+	return infos, nil
+}
+
+// ReadFile returns the contents of the file at path.
+func (fileSystem *MemoryFileSystem) ReadFile(path string) ([]byte, error) {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	path = filepath.Clean(path)
+	node, err := fileSystem.resolve("open", path)
+	if err != nil {
+		return nil, err
+	}
+	if node.dir {
+		return nil, memoryError("read", path, syscall.EISDIR)
+	}
+
+	return append([]byte{}, node.data...), nil
+}
+
+// WriteFile creates or truncates the file at path, whose parent directory must already exist.
+func (fileSystem *MemoryFileSystem) WriteFile(path string, data []byte, perm os.FileMode) error {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	path = filepath.Clean(path)
+	if node, ok := fileSystem.nodes[path]; ok && node.dir {
+		return memoryError("open", path, syscall.EISDIR)
+	}
+
+	if err := fileSystem.checkParent("open", path); err != nil {
+		return err
+	}
+
+	fileSystem.nodes[path] = &memoryNode{data: append([]byte{}, data...), mode: perm.Perm(), modTime: time.Now()}
+
+	return nil
+}
+
+// Open returns a reader of the file at path, following a symbolic link. Like an *os.File it is also an
+// io.ReaderAt. Later writes to the file don't change what the reader returns.
+func (fileSystem *MemoryFileSystem) Open(path string) (io.ReadCloser, error) {
+	data, err := fileSystem.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memoryReader{bytes.NewReader(data)}, nil
+}
+
+// Create creates or truncates the file at path, whose parent directory must already exist, and returns a writer
+// appending to it.
+func (fileSystem *MemoryFileSystem) Create(path string, perm os.FileMode) (io.WriteCloser, error) {
+	if err := fileSystem.WriteFile(path, nil, perm); err != nil {
+		return nil, err
+	}
+
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	return &memoryWriter{fileSystem, fileSystem.nodes[filepath.Clean(path)]}, nil
+}
+
+// Remove deletes the file or empty directory at path.
+func (fileSystem *MemoryFileSystem) Remove(path string) error {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	path = filepath.Clean(path)
+	if _, ok := fileSystem.nodes[path]; !ok {
+		return memoryError("remove", path, syscall.ENOENT)
+	}
+
+	if len(fileSystem.children(path)) > 0 {
+		return memoryError("remove", path, syscall.ENOTEMPTY)
+	}
+
+	delete(fileSystem.nodes, path)
+
+	return nil
+}
+
+// RemoveAll deletes path and everything below it, a missing path is not an error.
+func (fileSystem *MemoryFileSystem) RemoveAll(path string) error {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	path = filepath.Clean(path)
+	for existing := range fileSystem.nodes {
+		if existing == path || isBelow(existing, path) {
+			delete(fileSystem.nodes, existing)
+		}
+	}
+
+	return nil
+}
+
+// Rename moves source to destination the way os.Rename does on unix systems: a directory may only replace
+// an empty directory, and the destination's parent must exist.
+func (fileSystem *MemoryFileSystem) Rename(source string, destination string) error {
+	fileSystem.mutex.Lock()
+	defer fileSystem.mutex.Unlock()
+
+	source = filepath.Clean(source)
+	destination = filepath.Clean(destination)
+
+	node, ok := fileSystem.nodes[source]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.ENOENT}
+	}
+
+	if source == destination {
+		return nil
+	}
+
+	if isBelow(destination, source) {
+		return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.EINVAL}
+	}
+
+	if err := fileSystem.checkParent("rename", destination); err != nil {
+		return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.ENOENT}
+	}
+
+	if existing, ok := fileSystem.nodes[destination]; ok {
+		if existing.dir != node.dir {
+			return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.EEXIST}
+		}
+		if existing.dir && len(fileSystem.children(destination)) > 0 {
+			return &os.LinkError{Op: "rename", Old: source, New: destination, Err: syscall.ENOTEMPTY}
+		}
+	}
+
+	moved := map[string]*memoryNode{}
+	for existing, existingNode := range fileSystem.nodes {
+		if existing == source || isBelow(existing, source) {
+			moved[destination+strings.TrimPrefix(existing, source)] = existingNode
+			delete(fileSystem.nodes, existing)
+		}
+	}
+
+	for path, movedNode := range moved {
+		fileSystem.nodes[path] = movedNode
+	}
+
+	return nil
+}
+
+// Walk walks the tree rooted at root in lexical order without following symbolic links, like filepath.Walk.
+func (fileSystem *MemoryFileSystem) Walk(root string, walkFn filepath.WalkFunc) error {
+	info, err := fileSystem.Lstat(root)
+	if err != nil {
+		err = walkFn(root, nil, err)
+	} else {
+		err = fileSystem.walk(root, info, walkFn)
+	}
+
+	if err == filepath.SkipDir {
+		return nil
+	}
+
+	return err
+}
+
+func (fileSystem *MemoryFileSystem) walk(path string, info os.FileInfo, walkFn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return walkFn(path, info, nil)
+	}
+
+	infos, err := fileSystem.ReadDir(path)
+	err1 := walkFn(path, info, err)
+	if err != nil || err1 != nil {
+		return err1
+	}
+
+	for _, child := range infos {
+		err = fileSystem.walk(filepath.Join(path, child.Name()), child, walkFn)
+		if err != nil {
+			if !child.IsDir() || err != filepath.SkipDir {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// memoryReader is the io.ReadCloser returned by MemoryFileSystem.Open.
+type memoryReader struct {
+	*bytes.Reader
+}
+
+func (reader *memoryReader) Close() error {
+	return nil
+}
+
+// memoryWriter is the io.WriteCloser returned by MemoryFileSystem.Create.
+type memoryWriter struct {
+	fileSystem *MemoryFileSystem
+	node       *memoryNode
+}
+
+func (writer *memoryWriter) Write(p []byte) (int, error) {
+	writer.fileSystem.mutex.Lock()
+	defer writer.fileSystem.mutex.Unlock()
+
+	writer.node.data = append(writer.node.data, p...)
+	writer.node.modTime = time.Now()
+
+	return len(p), nil
+}
+
+func (writer *memoryWriter) Close() error {
+	return nil
+}
+
+func (fileSystem *MemoryFileSystem) mkdirAll(path string, perm os.FileMode) error {
+	if node, ok := fileSystem.nodes[path]; ok {
+		if node.dir {
+			return nil
+		}
+
+		return memoryError("mkdir", path, syscall.ENOTDIR)
+	}
+
+	if err := fileSystem.mkdirAll(filepath.Dir(path), perm); err != nil {
+		return err
+	}
+
+	fileSystem.nodes[path] = &memoryNode{dir: true, mode: perm.Perm(), modTime: time.Now()}
+
+	return nil
+}
+
+// resolve returns the node at path, following symbolic links anywhere in it the way the operating system does,
+// giving up on loops.
+func (fileSystem *MemoryFileSystem) resolve(op string, path string) (*memoryNode, error) {
+	for hops := 0; hops <= 40; hops++ {
+		node, redirected, err := fileSystem.lookup(op, path)
+		if err != nil || redirected == "" {
+			return node, err
+		}
+
+		path = redirected
+	}
+
+	return nil, memoryError(op, path, syscall.ELOOP)
+}
+
+// lookup returns the node at path, or the path to look up instead when a directory on the way or path itself is
+// a symbolic link.
+func (fileSystem *MemoryFileSystem) lookup(op string, path string) (*memoryNode, string, error) {
+	current := string(filepath.Separator)
+	node := fileSystem.nodes[current]
+	parts := strings.Split(strings.TrimPrefix(path, current), string(filepath.Separator))
+
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		next := filepath.Join(current, part)
+		found, ok := fileSystem.nodes[next]
+		if !ok {
+			return nil, "", memoryError(op, path, syscall.ENOENT)
+		}
+
+		if found.link != "" {
+			target := found.link
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(current, target)
+			}
+
+			return nil, filepath.Join(append([]string{target}, parts[i+1:]...)...), nil
+		}
+
+		current = next
+		node = found
+	}
+
+	return node, "", nil
+}
+
+func (fileSystem *MemoryFileSystem) checkParent(op string, path string) error {
+	parent, ok := fileSystem.nodes[filepath.Dir(path)]
+	if !ok {
+		return memoryError(op, path, syscall.ENOENT)
+	}
+	if !parent.dir {
+		return memoryError(op, path, syscall.ENOTDIR)
+	}
+
+	return nil
+}
+
+// children returns the sorted paths directly inside the directory path.
+func (fileSystem *MemoryFileSystem) children(path string) []string {
+	var children []string
+	for existing := range fileSystem.nodes {
+		if existing != path && filepath.Dir(existing) == path {
+			children = append(children, existing)
+		}
+	}
+
+	sort.Strings(children)
+
+	return children
+}
+
+// isBelow reports whether path is inside the directory parent.
+func isBelow(path string, parent string) bool {
+	if parent == string(filepath.Separator) {
+		return path != parent
+	}
+
+	return strings.HasPrefix(path, parent+string(filepath.Separator))
+}
+
+func memoryError(op string, path string, errno syscall.Errno) error {
+	return &os.PathError{Op: op, Path: path, Err: errno}
+}
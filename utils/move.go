@@ -1,11 +1,9 @@
 package utils
 
 import (
-	"bytes"
 	"errors"
-	"fmt"
-	"os/exec"
-	"runtime"
+	"os"
+	"path/filepath"
 )
 
 // IMoveService is the interface tha exposes execs to system mv cmd.
@@ -16,83 +14,71 @@ type IMoveService interface {
 
 // MoveService is the struct for this instance of IMoveService.
 type MoveService struct {
+	fileSystem IFileSystem
+	copy       *CopyService
 }
 
 // GetMoveService returns a pointer to an implementation of IMoveService.
-func GetMoveService() *MoveService {
-	move := MoveService{}
+func GetMoveService(fileSystem IFileSystem) *MoveService {
+	move := MoveService{
+		fileSystem,
+		GetCopyService(fileSystem),
+	}
 
 	return &move
 }
 
-// Move moves the source path to the dest path.
+// Move moves the source path to the dest path the way `mv -f` does: when dest is an existing directory
+// source is moved inside it. Moves across filesystems fall back to copying and removing the source.
 func (move *MoveService) Move(source string, dest string) error {
-	var app string
-	var err error
-	if runtime.GOOS == "windows" {
-		app = "move"
-		cmdArgs := []string{"-f", source, dest}
-		if _, err = exec.Command(app, cmdArgs...).Output(); err != nil {
-			return errors.New(fmt.Sprintf("There was an error moving the current environment: %s\n",
-				err,
-			),
-			)
-		}
-	} else {
-		cmdArgs := []string{"-f", source, dest}
-		app = "mv"
 
-		cmd := exec.Command(app, cmdArgs...)
+	source = filepath.Clean(source)
 
-		var out bytes.Buffer
-		var stderr bytes.Buffer
-		cmd.Stdout = &out
-		cmd.Stderr = &stderr
-		err = cmd.Run()
+	info, err := move.fileSystem.Stat(source)
+	if err != nil {
+		return errors.New("There was an error moving " + source + ": " + err.Error())
+	}
 
-		if err != nil {
-			fmt.Println("Error running mv: " + err.Error() + ": " + stderr.String())
-			return err
-		}
+	target := move.copy.target(source, dest)
 
-		return err
+	if existing, statErr := move.fileSystem.Stat(target); statErr == nil && !existing.IsDir() && !info.IsDir() {
+		move.fileSystem.Remove(target)
 	}
 
-	return err
-}
+	err = move.fileSystem.Rename(source, target)
+	if err == nil {
+		return nil
+	}
 
-// RemoveDirectory moves a directory to nowhere.
-func (move *MoveService) RemoveDirectory(source string) error {
-	var app string
-	var err error
-	if runtime.GOOS == "windows" {
-		app = "rmdir"
-		cmdArgs := []string{"/s", source}
-		if _, err = exec.Command(app, cmdArgs...).Output(); err != nil {
-			return errors.New(fmt.Sprintf("There was an error moving the current environment: %s\n",
-				err,
-			),
-			)
-		}
+	if _, statErr := move.fileSystem.Stat(target); statErr == nil {
+		return errors.New("There was an error moving " + source + " to " + target + ": " + err.Error())
+	}
+
+	if info.IsDir() {
+		err = move.copy.CopyDir(source, target)
 	} else {
-		cmdArgs := []string{"-rf", source}
-		app = "rm"
+		err = move.copy.CopyFile(source, target)
+	}
 
-		cmd := exec.Command(app, cmdArgs...)
+	if err != nil {
+		move.fileSystem.RemoveAll(target)
+		return errors.New("There was an error moving " + source + " to " + target + ": " + err.Error())
+	}
 
-		var out bytes.Buffer
-		var stderr bytes.Buffer
-		cmd.Stdout = &out
-		cmd.Stderr = &stderr
-		err = cmd.Run()
+	return move.fileSystem.RemoveAll(source)
+}
 
-		if err != nil {
-			fmt.Println("Error running rm -rf: " + err.Error() + ": " + stderr.String())
-			return err
-		}
+// RemoveDirectory deletes a directory and everything in it.
+func (move *MoveService) RemoveDirectory(source string) error {
+
+	if _, err := move.fileSystem.Stat(source); os.IsNotExist(err) {
+		return errors.New("There was an error removing " + source + ": it does not exist")
+	}
 
-		return err
+	err := move.fileSystem.RemoveAll(source)
+	if err != nil {
+		return errors.New("There was an error removing " + source + ": " + err.Error())
 	}
 
-	return err
+	return nil
 }
@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMoveIntoExistingDirectory(t *testing.T) {
+	fileSystem := GetMemoryFileSystem()
+	fileSystem.MkdirAll("/go/src/example.com/a", 0755)
+	fileSystem.WriteFile("/go/src/example.com/a/a.go", []byte("package a"), 0644)
+	fileSystem.MkdirAll("/gobo/dev", 0755)
+
+	move := GetMoveService(fileSystem)
+	if err := move.Move("/go/src", "/gobo/dev"); err != nil {
+		t.Fatalf("Move returned %v", err)
+	}
+
+	data, err := fileSystem.ReadFile("/gobo/dev/src/example.com/a/a.go")
+	if err != nil || string(data) != "package a" {
+		t.Errorf("moved file reads %q, %v", data, err)
+	}
+
+	if _, err := fileSystem.Stat("/go/src"); err == nil {
+		t.Error("source still exists after Move")
+	}
+}
+
+func TestMoveRenamesToMissingDestination(t *testing.T) {
+	fileSystem := GetMemoryFileSystem()
+	fileSystem.MkdirAll("/gobo", 0755)
+	fileSystem.WriteFile("/gobo/gobo.toml", []byte("name = \"dev\""), 0644)
+
+	move := GetMoveService(fileSystem)
+	if err := move.Move("/gobo/gobo.toml", "/gobo/old.toml"); err != nil {
+		t.Fatalf("Move returned %v", err)
+	}
+
+	if _, err := fileSystem.Stat("/gobo/old.toml"); err != nil {
+		t.Errorf("destination missing after Move: %v", err)
+	}
+}
+
+func TestMoveRefusesNonEmptyTarget(t *testing.T) {
+	fileSystem := GetMemoryFileSystem()
+	fileSystem.MkdirAll("/go/src/a", 0755)
+	fileSystem.MkdirAll("/gobo/dev/src/b", 0755)
+
+	move := GetMoveService(fileSystem)
+	if err := move.Move("/go/src", "/gobo/dev"); err == nil {
+		t.Fatal("Move replaced a non-empty directory")
+	}
+
+	if _, err := fileSystem.Stat("/gobo/dev/src/b"); err != nil {
+		t.Error("the existing target was damaged")
+	}
+}
+
+func TestCopyDirCopiesTree(t *testing.T) {
+	fileSystem := GetMemoryFileSystem()
+	fileSystem.MkdirAll("/go/bin", 0755)
+	fileSystem.WriteFile("/go/bin/tool", []byte("tool"), 0755)
+	fileSystem.MkdirAll("/backup", 0755)
+
+	copy := GetCopyService(fileSystem)
+	if err := copy.CopyDir("/go/bin", "/backup"); err != nil {
+		t.Fatalf("CopyDir returned %v", err)
+	}
+
+	info, err := fileSystem.Stat("/backup/bin/tool")
+	if err != nil {
+		t.Fatalf("copied file missing: %v", err)
+	}
+
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("copied file mode is %v, want 0755", info.Mode().Perm())
+	}
+
+	if _, err := fileSystem.Stat("/go/bin/tool"); err != nil {
+		t.Error("CopyDir removed the source")
+	}
+}
+
+func TestCopyFileStreamsAndKeepsMode(t *testing.T) {
+	memory := GetMemoryFileSystem()
+	memory.MkdirAll("/root", 0755)
+
+	// larger than the buffer io.Copy streams through
+	data := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+
+	for name, fileSystem := range map[string]IFileSystem{"memory": memory, "os": GetFileSystem()} {
+		root := "/root"
+		if name == "os" {
+			root = t.TempDir()
+		}
+
+		source := filepath.Join(root, "tool")
+		destination := filepath.Join(root, "backup")
+		fileSystem.WriteFile(source, data, 0750)
+		fileSystem.WriteFile(destination, []byte("a longer file which was there before"), 0644)
+
+		if err := GetCopyService(fileSystem).CopyFile(source, destination); err != nil {
+			t.Fatalf("%s: CopyFile returned %v", name, err)
+		}
+
+		copied, err := fileSystem.ReadFile(destination)
+		if err != nil || !bytes.Equal(copied, data) {
+			t.Errorf("%s: the copy holds %d bytes, %v, want %d", name, len(copied), err, len(data))
+		}
+
+		info, err := fileSystem.Stat(destination)
+		if err != nil {
+			t.Fatalf("%s: the copy is missing: %v", name, err)
+		}
+		if info.Mode().Perm() != 0750 {
+			t.Errorf("%s: the copy has mode %v, want 0750", name, info.Mode().Perm())
+		}
+	}
+}
+
+func TestCopyDirKeepsSymlinks(t *testing.T) {
+	memory := GetMemoryFileSystem()
+	memory.MkdirAll("/root", 0755)
+
+	for name, fileSystem := range map[string]IFileSystem{"memory": memory, "os": GetFileSystem()} {
+		root := "/root"
+		if name == "os" {
+			root = t.TempDir()
+		}
+
+		source := filepath.Join(root, "go", "src")
+		fileSystem.MkdirAll(filepath.Join(source, "example.com", "lib"), 0755)
+		fileSystem.WriteFile(filepath.Join(source, "example.com", "lib", "lib.go"), []byte("package lib"), 0644)
+		fileSystem.Symlink("lib", filepath.Join(source, "example.com", "dir"))
+		fileSystem.Symlink("lib/lib.go", filepath.Join(source, "example.com", "file.go"))
+		fileSystem.Symlink("missing", filepath.Join(source, "example.com", "dangling"))
+
+		destination := filepath.Join(root, "backup")
+		if err := GetCopyService(fileSystem).CopyDir(source, destination); err != nil {
+			t.Fatalf("%s: CopyDir returned %v", name, err)
+		}
+
+		for link, target := range map[string]string{"dir": "lib", "file.go": "lib/lib.go", "dangling": "missing"} {
+			path := filepath.Join(destination, "example.com", link)
+
+			info, err := fileSystem.Lstat(path)
+			if err != nil || info.Mode()&os.ModeSymlink == 0 {
+				t.Errorf("%s: %s was not copied as a symlink: %v", name, link, err)
+				continue
+			}
+
+			if copied, err := fileSystem.Readlink(path); err != nil || copied != target {
+				t.Errorf("%s: %s links to %q, %v, want %q", name, link, copied, err, target)
+			}
+		}
+
+		data, err := fileSystem.ReadFile(filepath.Join(destination, "example.com", "dir", "lib.go"))
+		if err != nil || string(data) != "package lib" {
+			t.Errorf("%s: reading through the copied directory link gave %q, %v", name, data, err)
+		}
+	}
+}
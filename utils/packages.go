@@ -1,12 +1,11 @@
 package utils
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -18,59 +17,73 @@ type IPackageService interface {
 	Install(url string) error
 	Checkout(url string, bookmark string) error
 	Get(url string, bookmark string) error
+	Download(url string) error
+	Fetch(url string) error
+	Tags(url string) ([]string, error)
+	BranchTip(url string, branch string) (string, error)
+	LatestRevision(url string, tagged bool) (string, error)
 	DetermineBookmark(path string) string
 	IsATag(path string) (bool, string)
+	IsAncestor(path string, ancestor string, revision string) (bool, error)
 	PathVisited(path string, f os.FileInfo, err error) error
 	GetInstalledPackages() []models.Package
+	Repositories() []string
+	RevisionTime(path string) string
+	GenerateLock(manifest []models.Package, projects []string) models.PackageLock
 	DiffAndUpdatePackages(currentPackages []models.Package) (bool, []models.Package)
+	CheckGoVersion(version string)
+	SetEnvironment(env []string)
 }
 
 // PackageService is the struct for this implementation of IPackageService.
 type PackageService struct {
-	logger    ILogger
-	host      models.Host
-	gopath    string
-	separator string
+	logger            ILogger
+	fileSystem        IFileSystem
+	runner            ICommandRunner
+	importService     IImportService
+	host              models.Host
+	gopath            string
+	separator         string
+	installedPackages []models.Package
+	checkedGoVersion  bool
+	environment       []string
 }
 
 // GetPackageService returns a pointer to an implementation of IPackageService.
 func GetPackageService(
 	logger ILogger,
+	fileSystem IFileSystem,
+	runner ICommandRunner,
+	importService IImportService,
 	host models.Host,
 	gopath string,
 	separator string,
 ) *PackageService {
 	var packageService = PackageService{
 		logger,
+		fileSystem,
+		runner,
+		importService,
 		host,
 		gopath,
 		separator,
+		nil,
+		false,
+		nil,
 	}
 
 	return &packageService
 }
 
-var installedPackages []models.Package
-
 // Install is a wrapper for the `go install` command.
 func (packageService *PackageService) Install(path string) error {
 
 	packageService.logger.Info("Running go install " + path + "...")
 
-	app := "go"
-	cmdArgs := []string{"install", path}
-
-	cmd := exec.Command(app, cmdArgs...)
-
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-	err := cmd.Run()
-
+	_, stderr, err := packageService.runner.Run("", packageService.environment, "go", "install", path)
 	if err != nil {
-		packageService.logger.Info("Error running go install: " + err.Error() + ": " + stderr.String())
-		return errors.New("Error running go install: " + err.Error() + ": " + stderr.String())
+		packageService.logger.Info("Error running go install: " + err.Error() + ": " + stderr)
+		return errors.New("Error running go install: " + err.Error() + ": " + stderr)
 	}
 
 	return nil
@@ -81,53 +94,139 @@ func (packageService *PackageService) Checkout(path string, revision string) err
 
 	packageService.logger.Info("Running git checkout to: " + revision + " on: " + path + "...")
 
-	app := "git"
-	cmdArgs := []string{"checkout", revision}
+	dir := packageService.gopath + "src" + packageService.separator + path
 
-	os.Chdir(packageService.gopath + path)
+	_, stderr, err := packageService.runner.Run(dir, packageService.environment, "git", "checkout", revision)
+	if err != nil {
+		packageService.logger.Info("Error running git checkout: " + err.Error() + ": " + stderr)
+		return errors.New("Error running git checkout: " + err.Error() + ": " + stderr)
+	}
 
-	cmd := exec.Command(app, cmdArgs...)
+	return nil
+}
 
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-	err := cmd.Run()
+// Get is a wrapper for 'go get' which also subsequently checks out the project at specified revision and installs it.
+func (packageService *PackageService) Get(path string, revision string) error {
 
+	err := packageService.Download(path)
 	if err != nil {
-		packageService.logger.Info("Error running git checkout: " + err.Error() + ": " + stderr.String())
-		return errors.New("Error running git checkout: " + err.Error() + ": " + stderr.String())
+		return err
 	}
 
-	return nil
+	err = packageService.Checkout(path, revision)
+	if err != nil {
+		return err
+	}
+
+	return packageService.Install(path)
 }
 
-// Get is a wrapper for 'go install' which also subsequently checks out the project at specified revision.
-func (packageService *PackageService) Get(path string, revision string) error {
+// Download is a wrapper for 'go get', which fetches the package into the GOPATH.
+func (packageService *PackageService) Download(path string) error {
 
 	packageService.logger.Info("Running go get " + path + "...")
 
-	app := "go"
-	cmdArgs := []string{"get", path}
+	_, stderr, err := packageService.runner.Run("", packageService.environment, "go", "get", path)
+	if err != nil {
+		packageService.logger.Info("Error running go get: " + err.Error() + ": " + stderr)
+		return errors.New("Error running go get: " + err.Error() + ": " + stderr)
+	}
 
-	cmd := exec.Command(app, cmdArgs...)
+	return nil
+}
 
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-	err := cmd.Run()
+// Fetch is a wrapper for the `git fetch --tags` command.
+func (packageService *PackageService) Fetch(path string) error {
 
+	packageService.logger.Info("Running git fetch on: " + path + "...")
+
+	dir := packageService.gopath + "src" + packageService.separator + path
+
+	_, stderr, err := packageService.runner.Run(dir, packageService.environment, "git", "fetch", "--tags")
 	if err != nil {
-		packageService.logger.Info("Error running go get: " + err.Error() + ": " + stderr.String())
-		return errors.New("Error running go get: " + err.Error() + ": " + stderr.String())
+		packageService.logger.Info("Error running git fetch: " + err.Error() + ": " + stderr)
+		return errors.New("Error running git fetch: " + err.Error() + ": " + stderr)
 	}
 
-	err = packageService.Checkout(path, revision)
+	return nil
+}
 
-	err = packageService.Install(path)
+// Tags returns the tags of the package's repository, newest version first.
+func (packageService *PackageService) Tags(path string) ([]string, error) {
 
-	return nil
+	dir := packageService.gopath + "src" + packageService.separator + path
+
+	out, stderr, err := packageService.runner.Run(
+		dir, packageService.environment, "git", "tag", "--list", "--sort=-v:refname",
+	)
+	if err != nil {
+		return nil, errors.New("Error running git tag: " + err.Error() + ": " + stderr)
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(out, "\n") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags, nil
+}
+
+// BranchTip returns the hash at the tip of the fetched remote branch of the package.
+func (packageService *PackageService) BranchTip(path string, branch string) (string, error) {
+	return packageService.revParse(path, "origin/"+branch)
+}
+
+// LatestRevision returns the newest fetched release tag of the package when tagged is set and it has one, and
+// otherwise the hash at the tip of its tracked branch, falling back to the remote's default branch.
+func (packageService *PackageService) LatestRevision(path string, tagged bool) (string, error) {
+
+	dir := packageService.gopath + "src" + packageService.separator + path
+
+	if tagged {
+		tags, err := packageService.Tags(path)
+		if err != nil {
+			return "", err
+		}
+
+		for _, tag := range tags {
+			// pre-releases such as v1.2.0-rc1 are never picked over a release
+			if !strings.Contains(tag, "-") {
+				return tag, nil
+			}
+		}
+
+		packageService.logger.Info(path + " has no release tags, using the tip of its branch.")
+	}
+
+	branch, _, err := packageService.runner.Run(
+		dir, packageService.environment, "git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{u}",
+	)
+	if err != nil {
+		// a checked out tag or hash tracks nothing, follow the branch the remote points HEAD at
+		branch, _, err = packageService.runner.Run(
+			dir, packageService.environment, "git", "rev-parse", "--abbrev-ref", "origin/HEAD",
+		)
+	}
+	if err != nil {
+		return "", errors.New("unable to find the branch " + path + " tracks")
+	}
+
+	return packageService.revParse(path, strings.TrimSpace(branch))
+}
+
+// revParse returns the hash ref points at in the package's repository.
+func (packageService *PackageService) revParse(path string, ref string) (string, error) {
+
+	dir := packageService.gopath + "src" + packageService.separator + path
+
+	hash, stderr, err := packageService.runner.Run(dir, packageService.environment, "git", "rev-parse", ref)
+	if err != nil {
+		return "", errors.New("Error running git rev-parse " + ref + ": " + err.Error() + ": " + stderr)
+	}
+
+	return strings.TrimSpace(hash), nil
 }
 
 // DetermineBookmark takes the path of a git repo and returns the tag or hash that it is currently checked out at.
@@ -140,56 +239,65 @@ func (packageService *PackageService) DetermineBookmark(path string) string {
 		return version
 	}
 
-	cmd := "git"
-	cmdArgs := []string{"rev-parse", "HEAD"}
-
-	os.Chdir(path)
-	hash, err := exec.Command(cmd, cmdArgs...).Output()
+	hash, _, err := packageService.runner.Run(path, packageService.environment, "git", "rev-parse", "HEAD")
 	if err != nil {
 		packageService.logger.Info("Error running git rev-parse HEAD: " + err.Error())
 	}
 
-	packageService.logger.Info("Bookmark, HEAD is at: " + string(hash))
+	hash = strings.TrimSpace(hash)
+
+	packageService.logger.Info("Bookmark, HEAD is at: " + hash)
 
-	return string(hash)
+	return hash
 }
 
 // IsATag takes the path of a git repository and returns whether or not the repo is checked out at a tag, and the tag.
 func (packageService *PackageService) IsATag(path string) (bool, string) {
 
-	app := "git"
-	cmdArgs := []string{"describe", "--exact-match"}
+	out, stderr, err := packageService.runner.Run(path, packageService.environment, "git", "describe", "--exact-match")
+	if err != nil {
+		packageService.logger.Info("Error running git describe --exact-match: " + err.Error() + ": " + stderr)
+		packageService.logger.Info(fmt.Sprintf("Package %s is not checked out at a tag, using HEAD for bookmark.", path))
+		return false, strings.TrimSpace(out)
+	}
 
-	os.Chdir(path)
-	cmd := exec.Command(app, cmdArgs...)
+	return true, strings.TrimSpace(out)
+}
 
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-	err := cmd.Run()
+// IsAncestor takes the path of a git repository and reports whether the commit ancestor is revision or one of
+// its ancestors. Commits git does not know are an error.
+func (packageService *PackageService) IsAncestor(path string, ancestor string, revision string) (bool, error) {
 
-	if err != nil {
-		packageService.logger.Info("Error running git describe --exact-match: " + err.Error() + ": " + stderr.String())
-		packageService.logger.Info(fmt.Sprintf("Package %s is not checked out at a tag, using HEAD for bookmark.", path))
-		return false, out.String()
+	_, stderr, err := packageService.runner.Run(
+		path, packageService.environment, "git", "merge-base", "--is-ancestor", ancestor, revision,
+	)
+	if err == nil {
+		return true, nil
 	}
 
-	return true, out.String()
+	// merge-base exits with 1 and says nothing when ancestor is simply not an ancestor
+	if strings.TrimSpace(stderr) == "" {
+		return false, nil
+	}
+
+	return false, errors.New("Error running git merge-base: " + err.Error() + ": " + stderr)
 }
 
 // PathVisited determines whether or not the visited path is a Golang package, and if so creates a models.Package object.
 func (packageService *PackageService) PathVisited(path string, f os.FileInfo, err error) error {
-	//packageService.logger.Info(fmt.Sprintf("Visited: %s\n", path))
+	if err != nil || f == nil {
+		return nil
+	}
+
 	newPackage := models.Package{}
 	if f.IsDir() {
-		_, existsErr := os.Stat(path + packageService.separator + ".git")
+		_, existsErr := packageService.fileSystem.Stat(path + packageService.separator + ".git")
 		if existsErr == nil {
 			newPackage.Path = packageService.GetURLFromPath(path)
 			newPackage.Origin = packageService.GetURLFromPath(path)
 			newPackage.Revision = packageService.DetermineBookmark(path)
-			newPackage.RevisionTime = time.Now().String()
-			installedPackages = append(installedPackages, newPackage)
+			newPackage.RevisionTime = packageService.RevisionTime(path)
+			packageService.installedPackages = append(packageService.installedPackages, newPackage)
 		}
 	}
 	return nil
@@ -198,10 +306,98 @@ func (packageService *PackageService) PathVisited(path string, f os.FileInfo, er
 // GetInstalledPackages returns an array of models.Package that exist in the current directory.
 func (packageService *PackageService) GetInstalledPackages() []models.Package {
 	packageService.logger.Info("Walking source directory to find installed packages.")
-	err := filepath.Walk(packageService.gopath+"src", packageService.PathVisited)
+
+	packageService.installedPackages = nil
+	err := packageService.fileSystem.Walk(packageService.gopath+"src", packageService.PathVisited)
 	packageService.logger.Info(fmt.Sprintf("filepath.Walk() returned %v\n", err))
 
-	return installedPackages
+	return packageService.installedPackages
+}
+
+// Repositories returns the import paths of the git repositories in the GOPATH's src. Unlike GetInstalledPackages
+// it runs no git command, so it stays quick when only the paths are needed.
+func (packageService *PackageService) Repositories() []string {
+	var repositories []string
+
+	src := packageService.gopath + "src"
+	packageService.fileSystem.Walk(src, func(path string, f os.FileInfo, err error) error {
+		if err != nil || f == nil || !f.IsDir() {
+			return nil
+		}
+		if f.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		if _, err := packageService.fileSystem.Stat(path + packageService.separator + ".git"); err == nil {
+			repositories = append(repositories, packageService.GetURLFromPath(path))
+		}
+
+		return nil
+	})
+
+	return repositories
+}
+
+// RevisionTime takes the path of a git repository and returns the commit time of its HEAD in UTC in the
+// time.RFC3339 format, or an empty string when git can't tell.
+func (packageService *PackageService) RevisionTime(path string) string {
+
+	out, stderr, err := packageService.runner.Run(path, packageService.environment, "git", "log", "-1", "--format=%cI", "HEAD")
+	if err != nil {
+		packageService.logger.Info("Error running git log: " + err.Error() + ": " + stderr)
+		return ""
+	}
+
+	committed, err := time.Parse(time.RFC3339, strings.TrimSpace(out))
+	if err != nil {
+		packageService.logger.Info("Unable to parse the commit time of " + path + ": " + err.Error())
+		return ""
+	}
+
+	return committed.UTC().Format(time.RFC3339)
+}
+
+// GenerateLock returns the lock of every package installed in the GOPATH, sorted by path so the same packages
+// always give the same lock. The requirements of manifest are copied onto the packages they apply to, and the
+// import graph of the locked repositories and projects marks which are direct and which repositories import
+// them, see AnnotateLock. Without projects no code outside the repositories is read, so the repositories no
+// other repository imports count as direct.
+func (packageService *PackageService) GenerateLock(manifest []models.Package, projects []string) models.PackageLock {
+	requirements := map[string]models.Package{}
+	for _, pak := range manifest {
+		requirements[pak.Path] = pak
+	}
+
+	var lock models.PackageLock
+	for _, pak := range packageService.GetInstalledPackages() {
+		lock.Package = append(lock.Package, models.LockedPackage{
+			Path:         pak.Path,
+			Origin:       pak.Origin,
+			Revision:     pak.Revision,
+			RevisionTime: pak.RevisionTime,
+			Version:      requirements[pak.Path].Version,
+			Branch:       requirements[pak.Path].Branch,
+		})
+	}
+
+	sort.Slice(lock.Package, func(i int, j int) bool {
+		return lock.Package[i].Path < lock.Package[j].Path
+	})
+
+	var repositories []string
+	for _, locked := range lock.Package {
+		repositories = append(repositories, locked.Path)
+	}
+
+	// only the locked repositories and the environment's own code decide the annotations, parsing the rest of
+	// a large GOPATH would slow every save down
+	graph, err := packageService.importService.GraphOf(repositories, append(append([]string{}, repositories...), projects...))
+	if err != nil {
+		packageService.logger.Warn("Unable to read the imports of the GOPATH: " + err.Error())
+	}
+	AnnotateLock(&lock, graph, projects)
+
+	return lock
 }
 
 // GetURLFromPath takes the system path of the package and tries to figure out the http address of the repo.
@@ -230,13 +426,13 @@ func (packageService *PackageService) DiffAndUpdatePackages(currentPackages []mo
 	// check if current package bookmarks have changed
 	for i := 0; i < len(currentPackages); i++ {
 		var sysbook string
-		sysbook = packageService.DetermineBookmark(packageService.gopath + currentPackages[i].Path)
+		sysbook = packageService.DetermineBookmark(packageService.gopath + "src" + packageService.separator + currentPackages[i].Path)
 		if sysbook != currentPackages[i].Revision {
-			packageService.logger.Info(sysbook + " does not match " + currentPackages[i].Revision + " at path " + packageService.gopath + currentPackages[i].Path)
+			packageService.logger.Info(sysbook + " does not match " + currentPackages[i].Revision + " at path " + packageService.gopath + "src" + packageService.separator + currentPackages[i].Path)
 			changesDetected = true
 			packageService.logger.Info(currentPackages[i].Path + " has been updated on the filesystem.")
 			currentPackages[i].Revision = sysbook
-			currentPackages[i].RevisionTime = time.Now().String()
+			currentPackages[i].RevisionTime = packageService.RevisionTime(packageService.gopath + "src" + packageService.separator + currentPackages[i].Path)
 		}
 		updatedPackages = append(updatedPackages, currentPackages[i])
 	}
@@ -256,6 +452,7 @@ func (packageService *PackageService) DiffAndUpdatePackages(currentPackages []mo
 		if !match {
 			updatedPackages = append(updatedPackages, systemPackages[sys])
 			addedPackages = append(addedPackages, systemPackages[sys])
+			changesDetected = true
 			packageService.logger.Info("New package " + systemPackages[sys].Path + " has been identified.")
 		}
 
@@ -266,3 +463,37 @@ func (packageService *PackageService) DiffAndUpdatePackages(currentPackages []mo
 	return changesDetected, updatedPackages
 
 }
+
+// SetEnvironment sets the KEY=VALUE pairs added to the environment of the go and git commands the service runs.
+func (packageService *PackageService) SetEnvironment(env []string) {
+	packageService.environment = env
+}
+
+// CheckGoVersion warns, once, when the go command packages are built with is not the version an environment
+// is pinned to. An empty version pins nothing.
+func (packageService *PackageService) CheckGoVersion(version string) {
+	version = NormalizeGoVersion(version)
+	if version == "" || packageService.checkedGoVersion {
+		return
+	}
+	packageService.checkedGoVersion = true
+
+	out, stderr, err := packageService.runner.Run("", packageService.environment, "go", "version")
+	if err != nil {
+		packageService.logger.Warn("Unable to check the go version, the environment needs " + version + ": " + stderr)
+		return
+	}
+
+	current, err := ParseGoVersion(out)
+	if err != nil {
+		packageService.logger.Warn(err.Error())
+		return
+	}
+
+	if current != version {
+		packageService.logger.Warn(
+			"The go on your PATH is " + current + " but the environment needs " + version +
+				", run . ~/.gobo/activate.sh to use it",
+		)
+	}
+}
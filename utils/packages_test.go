@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+func TestRepositoriesRunsNoGit(t *testing.T) {
+	fileSystem := GetMemoryFileSystem()
+	runner := GetFakeRunner()
+	logger, _ := GetConfiguredLogger(LogConfig{Level: PANIC})
+	packageService := GetPackageService(logger, fileSystem, runner, GetImportService(logger, fileSystem, "/go/", "/"), models.Host{}, "/go/", "/")
+
+	fileSystem.MkdirAll("/go/src/example.com/a/.git/objects", 0755)
+	fileSystem.MkdirAll("/go/src/example.com/a/nested/.git", 0755)
+	fileSystem.MkdirAll("/go/src/example.com/plain", 0755)
+
+	repositories := packageService.Repositories()
+
+	if !reflect.DeepEqual(repositories, []string{"example.com/a", "example.com/a/nested"}) {
+		t.Errorf("unexpected repositories %v", repositories)
+	}
+	if len(runner.Calls()) != 0 {
+		t.Errorf("listing repositories ran %v", runner.Calls())
+	}
+}
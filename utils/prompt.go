@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// IPromptService is the interface to implement for asking the user questions.
+type IPromptService interface {
+	Ask(question string) string
+}
+
+// PromptService is the struct for this implementation of IPromptService.
+type PromptService struct {
+	reader *bufio.Reader
+	writer io.Writer
+}
+
+// GetPromptService returns a pointer to an implementation of IPromptService reading answers from in.
+func GetPromptService(in io.Reader, out io.Writer) *PromptService {
+	var prompt = PromptService{
+		bufio.NewReader(in),
+		out,
+	}
+
+	return &prompt
+}
+
+// Ask prints the question and returns the answer line without its line ending.
+func (prompt *PromptService) Ask(question string) string {
+	fmt.Fprint(prompt.writer, question)
+
+	answer, _ := prompt.reader.ReadString('\n')
+
+	return strings.TrimRight(answer, "\r\n")
+}
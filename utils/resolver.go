@@ -0,0 +1,287 @@
+package utils
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+// ownManifest is how requirements of the environment's own packages.toml name where they come from.
+const ownManifest = "packages.toml"
+
+// Requirement is a version or branch that a manifest requires of a package.
+type Requirement struct {
+	Path string
+
+	// By is packages.toml for the environment's own manifest, or the path of the package whose packages.toml
+	// declares the requirement.
+	By string
+
+	Version string
+	Branch  string
+}
+
+// String describes the requirement for conflict messages, for example "example.com/app requires ^1.4".
+func (requirement Requirement) String() string {
+	if requirement.Branch != "" {
+		return requirement.By + " requires branch " + requirement.Branch
+	}
+
+	return requirement.By + " requires " + requirement.Version
+}
+
+// IResolverService is the interface to implement for resolving version constraints into revisions.
+type IResolverService interface {
+	Requirements(packages []models.Package) []Requirement
+	Resolve(packages []models.Package) (map[string]string, error)
+	ResolvePaths(packages []models.Package, paths []string) (map[string]string, map[string]error)
+}
+
+// ResolverService is the struct for this implementation of IResolverService.
+type ResolverService struct {
+	logger         ILogger
+	configService  IConfigService
+	packageService IPackageService
+	gopath         string
+	separator      string
+}
+
+// GetResolverService returns a pointer to an implementation of IResolverService.
+func GetResolverService(
+	logger ILogger,
+	configService IConfigService,
+	packageService IPackageService,
+	gopath string,
+	separator string,
+) *ResolverService {
+	var resolverService = ResolverService{
+		logger,
+		configService,
+		packageService,
+		gopath,
+		separator,
+	}
+
+	return &resolverService
+}
+
+// Requirements returns the versions and branches the packages require, their own and those the packages.toml
+// of each package's source declares for other packages in the list.
+func (resolverService *ResolverService) Requirements(packages []models.Package) []Requirement {
+	var requirements []Requirement
+
+	listed := map[string]bool{}
+	for _, pak := range packages {
+		listed[pak.Path] = true
+
+		if pak.Version != "" || pak.Branch != "" {
+			requirements = append(requirements, Requirement{pak.Path, ownManifest, pak.Version, pak.Branch})
+		}
+	}
+
+	for _, pak := range packages {
+		file := resolverService.gopath + "src" + resolverService.separator + pak.Path + resolverService.separator + "packages.toml"
+
+		dependencies, err := resolverService.configService.ReadPackages(file)
+		if IsConfigNotFound(err) {
+			continue
+		}
+		if err != nil {
+			resolverService.logger.Warn("Ignoring the requirements of " + pak.Path + ": " + err.Error())
+			continue
+		}
+
+		for _, dependency := range dependencies.Package {
+			if dependency.Version == "" && dependency.Branch == "" {
+				continue
+			}
+
+			if !listed[dependency.Path] {
+				resolverService.logger.Warn(pak.Path + " requires " + dependency.Path + " which is not in packages.toml")
+				continue
+			}
+
+			requirements = append(requirements, Requirement{dependency.Path, pak.Path, dependency.Version, dependency.Branch})
+		}
+	}
+
+	return requirements
+}
+
+// Resolve returns the revision selected for every package something places a requirement on: the newest tag
+// satisfying all version constraints, or the tip of the required branch. Packages without requirements are
+// left out. Every conflict found is explained in the returned error.
+func (resolverService *ResolverService) Resolve(packages []models.Package) (map[string]string, error) {
+	resolved, failures := resolverService.ResolvePaths(packages, nil)
+
+	if len(failures) > 0 {
+		paths := []string{}
+		for path := range failures {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		messages := make([]string, len(paths))
+		for i, path := range paths {
+			messages[i] = failures[path].Error()
+		}
+
+		return resolved, errors.New("unable to resolve the package versions:\n" + strings.Join(messages, "\n"))
+	}
+
+	return resolved, nil
+}
+
+// ResolvePaths is Resolve for only the packages at paths, or all of them when paths is empty. Requirements are
+// still gathered from every package, but the other packages are not resolved so their conflicts can't get in
+// the way. The conflict of each path which can't be resolved is returned under its path.
+func (resolverService *ResolverService) ResolvePaths(
+	packages []models.Package,
+	paths []string,
+) (map[string]string, map[string]error) {
+	wanted := map[string]bool{}
+	for _, path := range paths {
+		wanted[path] = true
+	}
+
+	byPath := map[string][]Requirement{}
+	var order []string
+
+	for _, requirement := range resolverService.Requirements(packages) {
+		if len(wanted) > 0 && !wanted[requirement.Path] {
+			continue
+		}
+		if _, ok := byPath[requirement.Path]; !ok {
+			order = append(order, requirement.Path)
+		}
+		byPath[requirement.Path] = append(byPath[requirement.Path], requirement)
+	}
+
+	resolved := map[string]string{}
+	failures := map[string]error{}
+
+	for _, path := range order {
+		revision, err := resolverService.resolve(path, byPath[path])
+		if err != nil {
+			failures[path] = err
+			continue
+		}
+
+		resolverService.logger.Info("Resolved " + path + " to " + revision)
+		resolved[path] = revision
+	}
+
+	return resolved, failures
+}
+
+func (resolverService *ResolverService) resolve(path string, requirements []Requirement) (string, error) {
+	branch := ""
+	branches := map[string]bool{}
+	versions := false
+
+	for _, requirement := range requirements {
+		if requirement.Branch != "" {
+			branch = requirement.Branch
+			branches[branch] = true
+		} else {
+			versions = true
+		}
+	}
+
+	if len(branches) > 1 || len(branches) == 1 && versions {
+		return "", conflict(path+" cannot follow a branch and meet the other requirements at once:", requirements, nil)
+	}
+
+	if branch != "" {
+		return resolverService.packageService.BranchTip(path, branch)
+	}
+
+	tags, err := resolverService.packageService.Tags(path)
+	if err != nil {
+		return "", errors.New("unable to list the versions of " + path + ": " + err.Error())
+	}
+
+	return SelectVersion(path, tags, requirements)
+}
+
+// SelectVersion returns the newest of the tags which satisfies every version requirement, tags which are not
+// semantic versions are ignored.
+func SelectVersion(path string, tags []string, requirements []Requirement) (string, error) {
+	constraints := make([]Constraint, len(requirements))
+	for i, requirement := range requirements {
+		constraint, err := ParseConstraint(requirement.Version)
+		if err != nil {
+			return "", errors.New(requirement.By + ": " + err.Error())
+		}
+		constraints[i] = constraint
+	}
+
+	versions := []Version{}
+	for _, tag := range tags {
+		if version, err := ParseVersion(tag); err == nil {
+			versions = append(versions, version)
+		}
+	}
+
+	sort.SliceStable(versions, func(i int, j int) bool {
+		return versions[i].Compare(versions[j]) > 0
+	})
+
+	for _, version := range versions {
+		satisfied := true
+		for _, constraint := range constraints {
+			if !constraint.Check(version) {
+				satisfied = false
+				break
+			}
+		}
+
+		if satisfied {
+			return version.Original, nil
+		}
+	}
+
+	return "", conflict("no version of "+path+" satisfies every requirement:", requirements, versions)
+}
+
+// conflict explains why requirements cannot be met, listing the newest version each one accepts on its own.
+func conflict(summary string, requirements []Requirement, versions []Version) error {
+	lines := []string{summary}
+
+	for _, requirement := range requirements {
+		line := "    " + requirement.String()
+
+		if requirement.Version != "" && versions != nil {
+			newest := "no tag matches"
+			constraint, _ := ParseConstraint(requirement.Version)
+			for _, version := range versions {
+				if constraint.Check(version) {
+					newest = "newest match " + version.Original
+					break
+				}
+			}
+			line += " (" + newest + ")"
+		}
+
+		lines = append(lines, line)
+	}
+
+	if versions != nil {
+		available := []string{}
+		if len(versions) == 0 {
+			available = append(available, "none")
+		}
+		for i, version := range versions {
+			if i == 10 {
+				available = append(available, "...")
+				break
+			}
+			available = append(available, version.Original)
+		}
+		lines = append(lines, "    available versions: "+strings.Join(available, ", "))
+	}
+
+	return errors.New(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+func newTestResolver(runner *FakeRunner) (*ResolverService, IFileSystem) {
+	fileSystem := GetMemoryFileSystem()
+	logger, _ := GetConfiguredLogger(LogConfig{Level: PANIC})
+	configService := GetConfigService(logger, fileSystem)
+	packageService := GetPackageService(logger, fileSystem, runner, GetImportService(logger, fileSystem, "/go/", "/"), models.Host{}, "/go/", "/")
+
+	return GetResolverService(logger, configService, packageService, "/go/", "/"), fileSystem
+}
+
+func TestResolveHonoursDependencyConstraints(t *testing.T) {
+	runner := GetFakeRunner(
+		FakeCommand{Command: "git tag --list --sort=-v:refname", Dir: "/go/src/example.com/lib", Stdout: "v2.0.0\nv1.6.0\nv1.4.1\nv1.3.0\n"},
+		FakeCommand{Command: "git rev-parse origin/release-2", Dir: "/go/src/example.com/tool", Stdout: "abc123\n"},
+	)
+	resolver, fileSystem := newTestResolver(runner)
+
+	fileSystem.MkdirAll("/go/src/example.com/app", 0755)
+	fileSystem.WriteFile("/go/src/example.com/app/packages.toml",
+		[]byte("[[package]]\npath = \"example.com/lib\"\nversion = \"~1.4\"\n"), 0644)
+
+	resolved, err := resolver.Resolve([]models.Package{
+		{Path: "example.com/app", Revision: "v1.0.0"},
+		{Path: "example.com/lib", Version: "^1.3"},
+		{Path: "example.com/tool", Branch: "release-2"},
+	})
+	if err != nil {
+		t.Fatalf("Resolve returned %v", err)
+	}
+
+	if resolved["example.com/lib"] != "v1.4.1" {
+		t.Errorf("expected lib at v1.4.1, allowed by ^1.3 and ~1.4, got %s", resolved["example.com/lib"])
+	}
+	if resolved["example.com/tool"] != "abc123" {
+		t.Errorf("expected tool at the tip of release-2, got %s", resolved["example.com/tool"])
+	}
+	if _, ok := resolved["example.com/app"]; ok {
+		t.Error("resolved a package without requirements")
+	}
+}
+
+func TestResolveExplainsConflicts(t *testing.T) {
+	runner := GetFakeRunner(
+		FakeCommand{Command: "git tag --list --sort=-v:refname", Stdout: "v2.1.0\nv1.4.0\n"},
+	)
+	resolver, fileSystem := newTestResolver(runner)
+
+	fileSystem.MkdirAll("/go/src/example.com/app", 0755)
+	fileSystem.WriteFile("/go/src/example.com/app/packages.toml",
+		[]byte("[[package]]\npath = \"example.com/lib\"\nversion = \"^2\"\n"+
+			"[[package]]\npath = \"example.com/tool\"\nbranch = \"main\"\n"), 0644)
+
+	_, err := resolver.Resolve([]models.Package{
+		{Path: "example.com/app"},
+		{Path: "example.com/lib", Version: "^1.4"},
+		{Path: "example.com/tool", Branch: "release-2"},
+	})
+	if err == nil {
+		t.Fatal("Resolve accepted conflicting requirements")
+	}
+
+	for _, want := range []string{
+		"no version of example.com/lib satisfies every requirement",
+		"packages.toml requires ^1.4 (newest match v1.4.0)",
+		"example.com/app requires ^2 (newest match v2.1.0)",
+		"available versions: v2.1.0, v1.4.0",
+		"example.com/tool cannot follow a branch",
+		"example.com/app requires branch main",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("conflict explanation is missing %q:\n%s", want, err)
+		}
+	}
+}
+
+func TestResolvePathsLeavesOtherPackagesAlone(t *testing.T) {
+	runner := GetFakeRunner(
+		FakeCommand{Command: "git tag --list --sort=-v:refname", Dir: "/go/src/example.com/lib", Stdout: "v1.4.1\nv1.3.0\n"},
+	)
+	resolver, _ := newTestResolver(runner)
+
+	resolved, failures := resolver.ResolvePaths([]models.Package{
+		{Path: "example.com/lib", Version: "^1.3"},
+		{Path: "example.com/tool", Version: "^2"},
+	}, []string{"example.com/lib"})
+
+	if len(failures) > 0 {
+		t.Fatalf("ResolvePaths returned %v", failures)
+	}
+	if resolved["example.com/lib"] != "v1.4.1" {
+		t.Errorf("expected lib at v1.4.1, got %s", resolved["example.com/lib"])
+	}
+	if _, ok := resolved["example.com/tool"]; ok {
+		t.Error("resolved a package which was not asked for")
+	}
+
+	_, failures = resolver.ResolvePaths([]models.Package{
+		{Path: "example.com/tool", Version: "^2"},
+	}, nil)
+	if failures["example.com/tool"] == nil {
+		t.Error("ResolvePaths did not report that the versions of tool can't be listed")
+	}
+}
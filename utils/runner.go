@@ -0,0 +1,140 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// ICommandRunner is the interface to implement for running external programs such as git and go.
+type ICommandRunner interface {
+	Run(dir string, env []string, name string, args ...string) (string, string, error)
+	Exec(dir string, env []string, name string, args ...string) (int, error)
+}
+
+// CommandRunner is the struct for the implementation of ICommandRunner which executes real processes.
+type CommandRunner struct {
+}
+
+// GetCommandRunner returns a pointer to an implementation of ICommandRunner.
+func GetCommandRunner() *CommandRunner {
+	var runner = CommandRunner{}
+
+	return &runner
+}
+
+// Run executes name with args in dir, an empty dir means the current directory. The KEY=VALUE pairs in env
+// are added to gobo's own environment. Run returns the captured stdout and stderr.
+func (runner *CommandRunner) Run(dir string, env []string, name string, args ...string) (string, string, error) {
+
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	return out.String(), stderr.String(), err
+}
+
+// Exec runs name interactively, connected to gobo's stdin, stdout and stderr, and returns its exit code. A name
+// without a path is looked up in the PATH from env when it sets one. Interrupts and terminations gobo receives
+// are forwarded to the child rather than stopping gobo first.
+func (runner *CommandRunner) Exec(dir string, env []string, name string, args ...string) (int, error) {
+
+	path, err := lookPath(name, env)
+	if err != nil {
+		return 127, err
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(signals)
+
+	err = cmd.Start()
+	if err != nil {
+		return 127, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				cmd.Process.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			// report a child killed by a signal the way shells do
+			return 128 + int(status.Signal()), nil
+		}
+
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, err
+	}
+
+	return 0, nil
+}
+
+// lookPath finds name in the last PATH set in env, falling back to gobo's own PATH.
+func lookPath(name string, env []string) (string, error) {
+	if strings.ContainsRune(name, filepath.Separator) || strings.ContainsRune(name, '/') {
+		return name, nil
+	}
+
+	path := ""
+	for _, variable := range env {
+		if strings.HasPrefix(variable, "PATH=") {
+			path = strings.TrimPrefix(variable, "PATH=")
+		}
+	}
+
+	if path == "" {
+		return exec.LookPath(name)
+	}
+
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			dir = "."
+		}
+
+		for _, candidate := range []string{filepath.Join(dir, name), filepath.Join(dir, name+".exe")} {
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+				return candidate, nil
+			}
+		}
+	}
+
+	return "", errors.New(name + " was not found in the environment's PATH")
+}
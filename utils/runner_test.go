@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestExecReturnsExitCode(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh to run")
+	}
+
+	code, err := GetCommandRunner().Exec("", []string{"GOBO_TEST_CODE=7"}, "sh", "-c", "exit $GOBO_TEST_CODE")
+	if err != nil {
+		t.Fatalf("exec returned %v", err)
+	}
+
+	if code != 7 {
+		t.Errorf("expected exit code 7, got %d", code)
+	}
+}
@@ -0,0 +1,329 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version tag such as v1.4.2 or 2.0.0-rc.1.
+type Version struct {
+	Major      int
+	Minor      int
+	Patch      int
+	Prerelease string
+
+	// Original is the tag the version was parsed from.
+	Original string
+}
+
+// Constraint is a parsed version constraint, a list of alternatives separated by || of which a version must
+// satisfy at least one.
+type Constraint struct {
+	alternatives [][]comparator
+	original     string
+}
+
+// comparator is a single bound such as >=1.4.0.
+type comparator struct {
+	operator string
+	version  Version
+}
+
+// ParseVersion parses a tag of the form [v]MAJOR[.MINOR[.PATCH]][-PRERELEASE][+BUILD], missing parts are 0.
+func ParseVersion(tag string) (Version, error) {
+	version, _, err := parsePartial(tag)
+
+	return version, err
+}
+
+// parsePartial parses a version which may leave out its minor and patch numbers or give them as x or *, and
+// returns how many numbers were given.
+func parsePartial(tag string) (Version, int, error) {
+	version := Version{Original: tag}
+
+	text := strings.TrimPrefix(strings.TrimSpace(tag), "v")
+	if i := strings.Index(text, "+"); i >= 0 {
+		text = text[:i]
+	}
+	if i := strings.Index(text, "-"); i >= 0 {
+		version.Prerelease = text[i+1:]
+		text = text[:i]
+		if version.Prerelease == "" {
+			return version, 0, errors.New(tag + " is not a semantic version")
+		}
+	}
+
+	parts := strings.Split(text, ".")
+	if len(parts) > 3 || text == "" {
+		return version, 0, errors.New(tag + " is not a semantic version")
+	}
+
+	numbers := []*int{&version.Major, &version.Minor, &version.Patch}
+	given := 0
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return version, 0, errors.New(tag + " is not a semantic version")
+		}
+
+		*numbers[i] = number
+		given++
+	}
+
+	return version, given, nil
+}
+
+// String returns the version as MAJOR.MINOR.PATCH[-PRERELEASE].
+func (version Version) String() string {
+	text := strconv.Itoa(version.Major) + "." + strconv.Itoa(version.Minor) + "." + strconv.Itoa(version.Patch)
+	if version.Prerelease != "" {
+		text += "-" + version.Prerelease
+	}
+
+	return text
+}
+
+// Compare returns -1, 0 or 1 as version sorts before, with or after other. A pre-release sorts before its release.
+func (version Version) Compare(other Version) int {
+	for _, pair := range [][2]int{
+		{version.Major, other.Major},
+		{version.Minor, other.Minor},
+		{version.Patch, other.Patch},
+	} {
+		if pair[0] != pair[1] {
+			return compareInts(pair[0], pair[1])
+		}
+	}
+
+	switch {
+	case version.Prerelease == other.Prerelease:
+		return 0
+	case version.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	return comparePrerelease(version.Prerelease, other.Prerelease)
+}
+
+// comparePrerelease compares dot separated identifiers, numeric ones numerically and before alphanumeric ones.
+func comparePrerelease(a string, b string) int {
+	left := strings.Split(a, ".")
+	right := strings.Split(b, ".")
+
+	for i := 0; i < len(left) && i < len(right); i++ {
+		leftNumber, leftErr := strconv.Atoi(left[i])
+		rightNumber, rightErr := strconv.Atoi(right[i])
+
+		switch {
+		case leftErr == nil && rightErr == nil:
+			if leftNumber != rightNumber {
+				return compareInts(leftNumber, rightNumber)
+			}
+		case leftErr == nil:
+			return -1
+		case rightErr == nil:
+			return 1
+		case left[i] != right[i]:
+			return strings.Compare(left[i], right[i])
+		}
+	}
+
+	return compareInts(len(left), len(right))
+}
+
+func compareInts(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// ParseConstraint parses constraints such as ^1.4, ~1.2.3, 1.4.x, >=1.2 <2 and ^1.0 || ^2.0. Bounds in one
+// alternative are separated by spaces or commas. A bare partial version such as 1.4 matches every 1.4.x.
+func ParseConstraint(text string) (Constraint, error) {
+	constraint := Constraint{original: text}
+
+	for _, alternative := range strings.Split(text, "||") {
+		var bounds []comparator
+
+		fields := strings.Fields(strings.Replace(alternative, ",", " ", -1))
+		for i := 0; i < len(fields); i++ {
+			field := fields[i]
+
+			// allow a space between the operator and the version, as in ">= 1.2"
+			if isOperator(field) && i+1 < len(fields) {
+				i++
+				field += fields[i]
+			}
+
+			parsed, err := parseBound(field)
+			if err != nil {
+				return constraint, errors.New("invalid version constraint " + strconv.Quote(text) + ": " + err.Error())
+			}
+
+			bounds = append(bounds, parsed...)
+		}
+
+		if len(bounds) == 0 {
+			return constraint, errors.New("invalid version constraint " + strconv.Quote(text) + ": empty alternative")
+		}
+
+		constraint.alternatives = append(constraint.alternatives, bounds)
+	}
+
+	return constraint, nil
+}
+
+var operators = []string{">=", "<=", "!=", ">", "<", "=", "^", "~"}
+
+func isOperator(field string) bool {
+	for _, operator := range operators {
+		if field == operator {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseBound turns one constraint term into the comparators it stands for.
+func parseBound(field string) ([]comparator, error) {
+	operator := ""
+	for _, candidate := range operators {
+		if strings.HasPrefix(field, candidate) {
+			operator = candidate
+			break
+		}
+	}
+
+	text := strings.TrimPrefix(field, operator)
+	if text == "*" || text == "x" || text == "X" {
+		return []comparator{{">=", Version{}}}, nil
+	}
+
+	version, given, err := parsePartial(text)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operator {
+	case "^":
+		// the first non zero number given may not change
+		upper := Version{Major: version.Major + 1}
+		if version.Major == 0 && given > 1 {
+			upper = Version{Minor: version.Minor + 1}
+			if version.Minor == 0 && given > 2 {
+				upper = Version{Patch: version.Patch + 1}
+			}
+		}
+		return []comparator{{">=", version}, {"<", lowest(upper)}}, nil
+
+	case "~":
+		upper := Version{Major: version.Major, Minor: version.Minor + 1}
+		if given == 1 {
+			upper = Version{Major: version.Major + 1}
+		}
+		return []comparator{{">=", version}, {"<", lowest(upper)}}, nil
+
+	case "", "=":
+		if given == 3 {
+			return []comparator{{"=", version}}, nil
+		}
+		return []comparator{{">=", version}, {"<", lowest(nextPartial(version, given))}}, nil
+
+	case ">":
+		if given < 3 {
+			return []comparator{{">=", lowest(nextPartial(version, given))}}, nil
+		}
+
+	case "<=":
+		if given < 3 {
+			return []comparator{{"<", lowest(nextPartial(version, given))}}, nil
+		}
+	}
+
+	return []comparator{{operator, version}}, nil
+}
+
+// nextPartial returns the first version after every version matching a partial version, 1.4 gives 1.5.0.
+func nextPartial(version Version, given int) Version {
+	switch given {
+	case 0:
+		return Version{Major: 1 << 30}
+	case 1:
+		return Version{Major: version.Major + 1}
+	}
+
+	return Version{Major: version.Major, Minor: version.Minor + 1}
+}
+
+// lowest returns version as the lowest pre-release of itself, so an upper bound of <2.0.0 also excludes 2.0.0-rc.1.
+func lowest(version Version) Version {
+	version.Prerelease = "0"
+
+	return version
+}
+
+// Check reports whether version satisfies the constraint. Pre-releases only match an alternative which names a
+// pre-release of the same major, minor and patch numbers.
+func (constraint Constraint) Check(version Version) bool {
+	for _, bounds := range constraint.alternatives {
+		if matches(bounds, version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matches(bounds []comparator, version Version) bool {
+	prereleaseAllowed := version.Prerelease == ""
+
+	for _, bound := range bounds {
+		compared := version.Compare(bound.version)
+
+		var ok bool
+		switch bound.operator {
+		case "=":
+			ok = compared == 0
+		case "!=":
+			ok = compared != 0
+		case ">":
+			ok = compared > 0
+		case ">=":
+			ok = compared >= 0
+		case "<":
+			ok = compared < 0
+		case "<=":
+			ok = compared <= 0
+		}
+
+		if !ok {
+			return false
+		}
+
+		target := bound.version
+		if target.Prerelease != "" && target.Prerelease != "0" &&
+			target.Major == version.Major && target.Minor == version.Minor && target.Patch == version.Patch {
+			prereleaseAllowed = true
+		}
+	}
+
+	return prereleaseAllowed
+}
+
+// String returns the constraint as it was written.
+func (constraint Constraint) String() string {
+	return constraint.original
+}
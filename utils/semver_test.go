@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"^1.4", "v1.4.0", true},
+		{"^1.4", "v1.9.3", true},
+		{"^1.4", "v1.3.9", false},
+		{"^1.4", "v2.0.0", false},
+		{"^1.4", "v2.0.0-rc.1", false},
+		{"^0.3", "0.3.7", true},
+		{"^0.3", "0.4.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"~1.2", "1.2.9", true},
+		{"~1.2", "1.3.0", false},
+		{"~1", "1.9.0", true},
+		{"1.4", "1.4.2", true},
+		{"1.4", "1.5.0", false},
+		{"1.4.x", "1.4.2", true},
+		{"=1.4.2", "v1.4.2", true},
+		{">= 1.2, <2", "1.9.0", true},
+		{">=1.2 <2", "2.0.0", false},
+		{">1.2", "1.2.5", false},
+		{"<=1.2", "1.2.5", true},
+		{"!=1.2.3", "1.2.3", false},
+		{"^1.0 || ^3.0", "3.1.0", true},
+		{"^1.0 || ^3.0", "2.1.0", false},
+		{"*", "5.0.0", true},
+		{"^1.4", "1.5.0-beta", false},
+		{">=1.5.0-beta", "1.5.0-beta.2", true},
+		{">=1.5.0-beta", "1.5.0-alpha", false},
+	}
+
+	for _, test := range tests {
+		constraint, err := ParseConstraint(test.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q) returned %v", test.constraint, err)
+			continue
+		}
+
+		version, err := ParseVersion(test.version)
+		if err != nil {
+			t.Errorf("ParseVersion(%q) returned %v", test.version, err)
+			continue
+		}
+
+		if got := constraint.Check(version); got != test.want {
+			t.Errorf("%q checking %s gave %v, expected %v", test.constraint, test.version, got, test.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, constraint := range []string{"", "^", "^one", "1.2.3.4", ">=1.2 ||"} {
+		if _, err := ParseConstraint(constraint); err == nil {
+			t.Errorf("ParseConstraint accepted %q", constraint)
+		}
+	}
+
+	for _, tag := range []string{"release-2", "latest", "v1.2-"} {
+		if _, err := ParseVersion(tag); err == nil {
+			t.Errorf("ParseVersion accepted %q", tag)
+		}
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0", "1.0.1", "1.10.0"}
+
+	for i := 1; i < len(ordered); i++ {
+		lower, _ := ParseVersion(ordered[i-1])
+		higher, _ := ParseVersion(ordered[i])
+
+		if lower.Compare(higher) != -1 || higher.Compare(lower) != 1 {
+			t.Errorf("expected %s to sort before %s", ordered[i-1], ordered[i])
+		}
+	}
+}
@@ -3,7 +3,10 @@ package utils
 import (
 	"bytes"
 	"fmt"
-	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	"github.com/camronlevanger/gobo/models"
@@ -12,20 +15,72 @@ import (
 // IConfigService is the interface to implement for reading and writing environments.
 type IConfigService interface {
 	WriteEnvironment(path string, env models.Environment) error
-	ReadEnvironment(path string) models.Environment
+	ReadEnvironment(path string) (models.Environment, error)
 	WritePackages(path string, paks models.Dependencies) error
-	ReadPackages(path string) models.Dependencies
+	ReadPackages(path string) (models.Dependencies, error)
+	WriteLock(path string, lock models.PackageLock) error
+	ReadLock(path string) (models.PackageLock, error)
+	WriteSnapshot(path string, snapshot models.Snapshot) error
+	ReadSnapshot(path string) (models.Snapshot, error)
+	WriteManifest(path string, manifest models.Manifest) error
+	ReadManifest(path string) (models.Manifest, error)
 }
 
 // ConfigService is the struct for this implementation of IConfigService.
 type ConfigService struct {
-	logger ILogger
+	logger     ILogger
+	fileSystem IFileSystem
 }
 
+// ConfigNotFoundError is returned when a gobo toml file does not exist.
+type ConfigNotFoundError struct {
+	Path string
+}
+
+func (err *ConfigNotFoundError) Error() string {
+	return err.Path + " does not exist"
+}
+
+// ConfigParseError is returned when a gobo toml file is not valid TOML. Line is 0 when it is unknown.
+type ConfigParseError struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+func (err *ConfigParseError) Error() string {
+	if err.Line > 0 {
+		return fmt.Sprintf("%s: parse error at line %d: %s", err.Path, err.Line, err.Message)
+	}
+
+	return fmt.Sprintf("%s: parse error: %s", err.Path, err.Message)
+}
+
+// ConfigSchemaError is returned when a gobo toml file is valid TOML but does not describe a valid gobo file.
+type ConfigSchemaError struct {
+	Path   string
+	Field  string
+	Reason string
+}
+
+func (err *ConfigSchemaError) Error() string {
+	return fmt.Sprintf("%s: invalid %s: %s", err.Path, err.Field, err.Reason)
+}
+
+// IsConfigNotFound reports whether err means the toml file does not exist.
+func IsConfigNotFound(err error) bool {
+	_, ok := err.(*ConfigNotFoundError)
+
+	return ok
+}
+
+var parseErrorLine = regexp.MustCompile(`[Ll]ine (\d+)`)
+
 // GetConfigService returns a pointer to an implementation of IConfigService.
-func GetConfigService(logger ILogger) IConfigService {
+func GetConfigService(logger ILogger, fileSystem IFileSystem) IConfigService {
 	var configService = ConfigService{
 		logger,
+		fileSystem,
 	}
 
 	return &configService
@@ -36,38 +91,87 @@ func (configService *ConfigService) WriteEnvironment(path string, env models.Env
 
 	buf := new(bytes.Buffer)
 	if err := toml.NewEncoder(buf).Encode(env); err != nil {
-		configService.logger.Fatal(err.Error())
+		return fmt.Errorf("unable to encode environment for %s: %s", path, err.Error())
 	}
 	configService.logger.Info(fmt.Sprintf("Writing environment to %s:\n", path))
-	//configService.logger.Info(fmt.Sprint(buf.String()))
 
-	err := ioutil.WriteFile(path, []byte(buf.String()), 0755)
+	err := configService.fileSystem.WriteFile(path, []byte(buf.String()), 0755)
 
 	return err
 }
 
 // ReadEnvironment loads the toml file at the provided path into an Environment struct and returns it for use.
-func (configService *ConfigService) ReadEnvironment(path string) models.Environment {
+func (configService *ConfigService) ReadEnvironment(path string) (models.Environment, error) {
 
 	var env models.Environment
 
-	if _, err := toml.DecodeFile(path, &env); err != nil {
-		configService.logger.Fatal(fmt.Sprintf("Unable to read environment file at %s because: %s", path, err.Error()))
+	if err := configService.decode(path, &env); err != nil {
+		return env, err
+	}
+
+	if strings.TrimSpace(env.Name) == "" {
+		return env, &ConfigSchemaError{path, "name", "an environment must have a name"}
+	}
+
+	for key := range env.Env {
+		if err := ValidateVariable(key); err != nil {
+			return env, &ConfigSchemaError{path, "env." + key, err.Error()}
+		}
+	}
+
+	for hook, script := range env.Hooks {
+		if !isHook(hook) {
+			return env, &ConfigSchemaError{path, "hooks." + hook, "unknown hook, expected one of " + hookNames()}
+		}
+
+		if script == "" || strings.ContainsAny(script, "/\\") || script == "." || script == ".." {
+			return env, &ConfigSchemaError{path, "hooks." + hook, "a hook must name a script in the environment's hooks directory"}
+		}
+	}
+
+	for i, project := range env.Projects {
+		if strings.TrimSpace(project) == "" || strings.HasSuffix(project, "/") {
+			return env, &ConfigSchemaError{path, fmt.Sprintf("projects[%d]", i), "a project must be an import path such as github.com/you"}
+		}
 	}
 
-	return env
+	return env, nil
 }
 
 // ReadPackages loads the toml file at the provided path into a Dependencies struct and returns it for use.
-func (configService *ConfigService) ReadPackages(path string) models.Dependencies {
+func (configService *ConfigService) ReadPackages(path string) (models.Dependencies, error) {
 
 	var paks models.Dependencies
 
-	if _, err := toml.DecodeFile(path, &paks); err != nil {
-		configService.logger.Fatal(fmt.Sprintf("Unable to read packages file at %s because: %s", path, err.Error()))
+	if err := configService.decode(path, &paks); err != nil {
+		return paks, err
+	}
+
+	seen := map[string]bool{}
+	for i, pak := range paks.Package {
+		field := fmt.Sprintf("package[%d].path", i)
+
+		if strings.TrimSpace(pak.Path) == "" {
+			return paks, &ConfigSchemaError{path, field, "a package must have a path"}
+		}
+
+		if seen[pak.Path] {
+			return paks, &ConfigSchemaError{path, field, pak.Path + " is listed more than once"}
+		}
+		seen[pak.Path] = true
+
+		if pak.Version != "" && pak.Branch != "" {
+			return paks, &ConfigSchemaError{path, fmt.Sprintf("package[%d].version", i), "set either a version or a branch, not both"}
+		}
+
+		if pak.Version != "" {
+			if _, err := ParseConstraint(pak.Version); err != nil {
+				return paks, &ConfigSchemaError{path, fmt.Sprintf("package[%d].version", i), err.Error()}
+			}
+		}
 	}
 
-	return paks
+	return paks, nil
 }
 
 // WritePackages writes out a Dependencies struct to the given file location.
@@ -75,12 +179,157 @@ func (configService *ConfigService) WritePackages(path string, paks models.Depen
 
 	buf := new(bytes.Buffer)
 	if err := toml.NewEncoder(buf).Encode(paks); err != nil {
-		configService.logger.Fatal(err.Error())
+		return fmt.Errorf("unable to encode packages for %s: %s", path, err.Error())
 	}
 	configService.logger.Info(fmt.Sprintf("Writing packages to %s:\n", path))
-	//configService.logger.Info(fmt.Sprint(buf.String()))
 
-	err := ioutil.WriteFile(path, []byte(buf.String()), 0755)
+	err := configService.fileSystem.WriteFile(path, []byte(buf.String()), 0755)
 
 	return err
 }
+
+// lockHeader starts every packages.lock so nobody edits it by hand.
+const lockHeader = "# Generated by gobo save, do not edit. Edit packages.toml and run gobo update instead.\n\n"
+
+// WriteLock writes out a PackageLock struct to the given file location.
+func (configService *ConfigService) WriteLock(path string, lock models.PackageLock) error {
+
+	buf := bytes.NewBufferString(lockHeader)
+	if err := toml.NewEncoder(buf).Encode(lock); err != nil {
+		return fmt.Errorf("unable to encode the package lock for %s: %s", path, err.Error())
+	}
+	configService.logger.Info(fmt.Sprintf("Writing package lock to %s:\n", path))
+
+	return configService.fileSystem.WriteFile(path, buf.Bytes(), 0755)
+}
+
+// ReadLock loads the toml file at the provided path into a PackageLock struct and returns it for use.
+func (configService *ConfigService) ReadLock(path string) (models.PackageLock, error) {
+
+	var lock models.PackageLock
+
+	if err := configService.decode(path, &lock); err != nil {
+		return lock, err
+	}
+
+	seen := map[string]bool{}
+	for i, pak := range lock.Package {
+		field := fmt.Sprintf("package[%d]", i)
+
+		if strings.TrimSpace(pak.Path) == "" {
+			return lock, &ConfigSchemaError{path, field + ".path", "a locked package must have a path"}
+		}
+
+		if seen[pak.Path] {
+			return lock, &ConfigSchemaError{path, field + ".path", pak.Path + " is locked more than once"}
+		}
+		seen[pak.Path] = true
+
+		if strings.TrimSpace(pak.Revision) == "" {
+			return lock, &ConfigSchemaError{path, field + ".revision", pak.Path + " has no locked revision"}
+		}
+	}
+
+	return lock, nil
+}
+
+// WriteSnapshot writes out a Snapshot struct to the given file location.
+func (configService *ConfigService) WriteSnapshot(path string, snapshot models.Snapshot) error {
+
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(snapshot); err != nil {
+		return fmt.Errorf("unable to encode snapshot for %s: %s", path, err.Error())
+	}
+	configService.logger.Info(fmt.Sprintf("Writing snapshot to %s:\n", path))
+
+	return configService.fileSystem.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// ReadSnapshot loads the toml file at the provided path into a Snapshot struct and returns it for use.
+func (configService *ConfigService) ReadSnapshot(path string) (models.Snapshot, error) {
+
+	var snapshot models.Snapshot
+
+	if err := configService.decode(path, &snapshot); err != nil {
+		return snapshot, err
+	}
+
+	if snapshot.ID == "" || snapshot.Environment == "" {
+		return snapshot, &ConfigSchemaError{path, "id", "a snapshot must have an id and an environment"}
+	}
+
+	return snapshot, nil
+}
+
+// WriteManifest writes out a Manifest struct to the given file location.
+func (configService *ConfigService) WriteManifest(path string, manifest models.Manifest) error {
+
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(manifest); err != nil {
+		return fmt.Errorf("unable to encode manifest for %s: %s", path, err.Error())
+	}
+	configService.logger.Info(fmt.Sprintf("Writing manifest to %s:\n", path))
+
+	return configService.fileSystem.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// ReadManifest loads the toml file at the provided path into a Manifest struct and returns it for use.
+func (configService *ConfigService) ReadManifest(path string) (models.Manifest, error) {
+
+	var manifest models.Manifest
+
+	if err := configService.decode(path, &manifest); err != nil {
+		return manifest, err
+	}
+
+	for i, file := range manifest.File {
+		if file.Path == "" || file.SHA256 == "" && file.Link == "" {
+			return manifest, &ConfigSchemaError{path, fmt.Sprintf("file[%d]", i), "a file must have a path and a hash or a link"}
+		}
+	}
+
+	return manifest, nil
+}
+
+// decode reads the toml file at path into v, sorting failures into the typed config errors.
+func (configService *ConfigService) decode(path string, v interface{}) error {
+
+	data, err := configService.fileSystem.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &ConfigNotFoundError{path}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %s", path, err.Error())
+	}
+
+	_, err = toml.Decode(string(data), v)
+	if err == nil {
+		return nil
+	}
+
+	message := err.Error()
+	if strings.Contains(message, "mismatch") || strings.Contains(message, "incompatible types") {
+		return &ConfigSchemaError{path, "value", message}
+	}
+
+	line := 0
+	if match := parseErrorLine.FindStringSubmatch(message); match != nil {
+		line, _ = strconv.Atoi(match[1])
+	}
+
+	return &ConfigParseError{path, line, message}
+}
+
+func isHook(name string) bool {
+	for _, hook := range models.HOOKS {
+		if name == hook {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hookNames() string {
+	return strings.Join(models.HOOKS[:], ", ")
+}
@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+// IToolchainService is the interface to implement for finding the Go toolchains environments are pinned to.
+type IToolchainService interface {
+	Resolve(version string) (string, error)
+}
+
+// ToolchainService is the struct for this implementation of IToolchainService. Toolchains live in
+// directory/<version>, for example ~/.gobo/toolchains/go1.21.3.
+type ToolchainService struct {
+	logger     ILogger
+	fileSystem IFileSystem
+	directory  string
+}
+
+// GetToolchainService returns a pointer to an implementation of IToolchainService.
+func GetToolchainService(logger ILogger, fileSystem IFileSystem, directory string) *ToolchainService {
+	var toolchainService = ToolchainService{
+		logger,
+		fileSystem,
+		directory,
+	}
+
+	return &toolchainService
+}
+
+// NormalizeGoVersion turns versions such as 1.21.3 into the go1.21.3 form `go version` prints.
+func NormalizeGoVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if version == "" || strings.HasPrefix(version, "go") {
+		return version
+	}
+
+	return "go" + version
+}
+
+// ParseGoVersion extracts the version from the output of `go version`, for example go1.21.3.
+func ParseGoVersion(output string) (string, error) {
+	fields := strings.Fields(output)
+	if len(fields) < 3 || fields[0] != "go" || fields[1] != "version" {
+		return "", errors.New("unexpected go version output: " + strings.TrimSpace(output))
+	}
+
+	return fields[2], nil
+}
+
+// Resolve returns the GOROOT of the toolchain for version. A toolchain which isn't unpacked yet is unpacked from
+// a go<version>.<os>-<arch>.tar.gz or .zip archive the user placed in the toolchains directory.
+func (toolchainService *ToolchainService) Resolve(version string) (string, error) {
+
+	version = NormalizeGoVersion(version)
+	if version == "" {
+		return "", errors.New("no Go version given")
+	}
+
+	goroot := toolchainService.directory + version
+
+	if toolchainService.installed(goroot) {
+		return goroot, nil
+	}
+
+	archive, err := toolchainService.findArchive(version)
+	if err != nil {
+		return "", err
+	}
+
+	toolchainService.logger.Info("Unpacking " + archive + " into " + goroot)
+
+	// unpack next to the final location, so a failed unpack never looks like an installed toolchain
+	partial := goroot + ".partial"
+	toolchainService.fileSystem.RemoveAll(partial)
+
+	if strings.HasSuffix(archive, ".zip") {
+		err = toolchainService.unzip(archive, partial)
+	} else {
+		err = toolchainService.untar(archive, partial)
+	}
+	if err == nil && !toolchainService.installed(partial) {
+		err = errors.New(archive + " does not contain a Go toolchain")
+	}
+	if err != nil {
+		toolchainService.fileSystem.RemoveAll(partial)
+		return "", errors.New("unable to unpack " + archive + ": " + err.Error())
+	}
+
+	toolchainService.fileSystem.RemoveAll(goroot)
+
+	return goroot, toolchainService.fileSystem.Rename(partial, goroot)
+}
+
+// installed reports whether goroot holds a go command.
+func (toolchainService *ToolchainService) installed(goroot string) bool {
+	for _, name := range []string{"go", "go.exe"} {
+		if info, err := toolchainService.fileSystem.Stat(goroot + "/bin/" + name); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findArchive returns the archive of version for this platform in the toolchains directory.
+func (toolchainService *ToolchainService) findArchive(version string) (string, error) {
+	platform := version + "." + runtime.GOOS + "-" + runtime.GOARCH
+
+	for _, extension := range []string{".tar.gz", ".tgz", ".zip"} {
+		archive := toolchainService.directory + platform + extension
+		if _, err := toolchainService.fileSystem.Stat(archive); err == nil {
+			return archive, nil
+		}
+	}
+
+	return "", errors.New(
+		"Go " + version + " is not installed, unpack it into " + toolchainService.directory + version +
+			" or place " + platform + ".tar.gz in " + toolchainService.directory,
+	)
+}
+
+// untar unpacks a gzipped tar archive into destination, dropping the go/ directory official archives start with.
+// The archive is streamed, entry by entry, so it is never held in memory.
+func (toolchainService *ToolchainService) untar(archive string, destination string) error {
+	file, err := toolchainService.fileSystem.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	compressed, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer compressed.Close()
+
+	reader := tar.NewReader(compressed)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = toolchainService.extract(destination, header.Name, os.FileMode(header.Mode)|os.ModeDir, nil)
+		case tar.TypeReg, tar.TypeRegA:
+			err = toolchainService.extract(destination, header.Name, os.FileMode(header.Mode), reader)
+		default:
+			toolchainService.logger.Debug("Skipping " + header.Name + " in " + archive)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// unzip unpacks a zip archive into destination, dropping the go/ directory official archives start with. Entries
+// are read straight from the archive, which is only read into memory when it can't be read at random.
+func (toolchainService *ToolchainService) unzip(archive string, destination string) error {
+	info, err := toolchainService.fileSystem.Stat(archive)
+	if err != nil {
+		return err
+	}
+
+	file, err := toolchainService.fileSystem.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	readerAt, ok := file.(io.ReaderAt)
+	if !ok {
+		data, err := ioutil.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		readerAt = bytes.NewReader(data)
+	}
+
+	reader, err := zip.NewReader(readerAt, info.Size())
+	if err != nil {
+		return err
+	}
+
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			err = toolchainService.extract(destination, file.Name, file.Mode(), nil)
+		} else {
+			var content io.ReadCloser
+			content, err = file.Open()
+			if err == nil {
+				err = toolchainService.extract(destination, file.Name, file.Mode(), content)
+				content.Close()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extract writes one archive entry below destination.
+func (toolchainService *ToolchainService) extract(destination string, name string, mode os.FileMode, content io.Reader) error {
+	// cleaning the name as an absolute path drops any .. which would escape destination
+	name = path.Clean("/" + strings.Replace(name, "\\", "/", -1))
+	if name == "/" || name == "/go" {
+		return nil
+	}
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "/go/"), "/")
+
+	target := destination + "/" + name
+
+	if mode.IsDir() {
+		return toolchainService.fileSystem.MkdirAll(target, models.FILEMODE)
+	}
+
+	err := toolchainService.fileSystem.MkdirAll(path.Dir(target), models.FILEMODE)
+	if err != nil {
+		return err
+	}
+
+	file, err := toolchainService.fileSystem.Create(target, mode.Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// toolchainArchives returns a tar.gz and a zip archive of a toolchain whose go command is content.
+func toolchainArchives(t *testing.T, content []byte) map[string][]byte {
+	var tarred bytes.Buffer
+	compressed := gzip.NewWriter(&tarred)
+	archive := tar.NewWriter(compressed)
+	archive.WriteHeader(&tar.Header{Name: "go/bin/", Typeflag: tar.TypeDir, Mode: 0755})
+	archive.WriteHeader(&tar.Header{Name: "go/bin/go", Typeflag: tar.TypeReg, Mode: 0755, Size: int64(len(content))})
+	archive.Write(content)
+	archive.WriteHeader(&tar.Header{Name: "../escaped", Typeflag: tar.TypeReg, Mode: 0644, Size: 1})
+	archive.Write([]byte("x"))
+	archive.Close()
+	compressed.Close()
+
+	var zipped bytes.Buffer
+	writer := zip.NewWriter(&zipped)
+	header := &zip.FileHeader{Name: "go/bin/go", Method: zip.Deflate}
+	header.SetMode(0755)
+	entry, err := writer.CreateHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Write(content)
+	writer.Close()
+
+	return map[string][]byte{".tar.gz": tarred.Bytes(), ".zip": zipped.Bytes()}
+}
+
+func TestToolchainUnpacksArchives(t *testing.T) {
+	logger, _ := GetConfiguredLogger(LogConfig{Level: PANIC})
+	content := bytes.Repeat([]byte("#!/bin/sh\n"), 100*1024)
+
+	for extension, data := range toolchainArchives(t, content) {
+		memory := GetMemoryFileSystem()
+		memory.MkdirAll("/toolchains", 0755)
+
+		for name, fileSystem := range map[string]IFileSystem{"memory": memory, "os": GetFileSystem()} {
+			directory := "/toolchains/"
+			if name == "os" {
+				directory = t.TempDir() + "/toolchains/"
+				fileSystem.MkdirAll(directory, 0755)
+			}
+
+			archive := directory + "go1.21.3." + runtime.GOOS + "-" + runtime.GOARCH + extension
+			fileSystem.WriteFile(archive, data, 0644)
+
+			goroot, err := GetToolchainService(logger, fileSystem, directory).Resolve("1.21.3")
+			if err != nil {
+				t.Fatalf("%s %s: Resolve returned %v", name, extension, err)
+			}
+
+			unpacked, err := fileSystem.ReadFile(filepath.Join(goroot, "bin", "go"))
+			if err != nil || !bytes.Equal(unpacked, content) {
+				t.Errorf("%s %s: unpacked %d bytes, %v, want %d", name, extension, len(unpacked), err, len(content))
+			}
+
+			info, err := fileSystem.Stat(filepath.Join(goroot, "bin", "go"))
+			if err != nil || info.Mode().Perm() != 0755 {
+				t.Errorf("%s %s: the go command was not unpacked as executable: %v", name, extension, err)
+			}
+
+			if _, err := fileSystem.Stat(directory + "escaped"); err == nil {
+				t.Errorf("%s %s: an entry escaped the toolchain directory", name, extension)
+			}
+			if _, err := fileSystem.Stat(goroot + ".partial"); err == nil {
+				t.Errorf("%s %s: the partial directory was left behind", name, extension)
+			}
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/camronlevanger/gobo/models"
+)
+
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateVariable checks name can be set as an environment variable of a gobo environment.
+func ValidateVariable(name string) error {
+	if !variableName.MatchString(name) {
+		return errors.New(name + " is not a valid environment variable name")
+	}
+
+	for _, managed := range models.MANAGEDVARIABLES {
+		if name == managed {
+			return errors.New(name + " is managed by gobo and can't be set per environment")
+		}
+	}
+
+	if strings.HasPrefix(name, "GOBO_") {
+		return errors.New(name + " is reserved for gobo's own variables")
+	}
+
+	return nil
+}
+
+// EnvironmentVariables returns the variables of env as KEY=VALUE pairs sorted by key, ready for a command runner.
+func EnvironmentVariables(env models.Environment) []string {
+	var variables []string
+
+	for _, key := range VariableNames(env) {
+		variables = append(variables, key+"="+env.Env[key])
+	}
+
+	return variables
+}
+
+// VariableNames returns the names of the variables of env in sorted order.
+func VariableNames(env models.Environment) []string {
+	var names []string
+
+	for key := range env.Env {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	return names
+}